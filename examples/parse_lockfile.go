@@ -41,10 +41,12 @@ func parseLockfile(path string) *lockfile.Lockfile {
 }
 
 func printStatistics(lock *lockfile.Lockfile) {
+	stats := lock.Stats()
 	fmt.Printf("\n📊 Gemfile.lock Statistics:\n")
-	fmt.Printf("├─ Total gems: %d\n", len(lock.GemSpecs))
-	fmt.Printf("├─ Git gems: %d\n", len(lock.GitSpecs))
-	fmt.Printf("├─ Path gems: %d\n", len(lock.PathSpecs))
+	fmt.Printf("├─ Total gems: %d\n", stats.TotalGems)
+	fmt.Printf("├─ Git gems: %d\n", stats.GitGems)
+	fmt.Printf("├─ Path gems: %d\n", stats.PathGems)
+	fmt.Printf("├─ Direct / transitive: %d / %d\n", stats.DirectGems, stats.TransitiveGems)
 	fmt.Printf("├─ Platforms: %v\n", lock.Platforms)
 	fmt.Printf("└─ Bundled with: %s\n", lock.BundledWith)
 }
@@ -130,25 +132,12 @@ func checkWebServers(lock *lockfile.Lockfile) {
 }
 
 func checkSecurity(lock *lockfile.Lockfile) {
-	gemsWithChecksum := 0
-	for i := range lock.GemSpecs {
-		gem := &lock.GemSpecs[i]
-		if gem.Checksum != "" {
-			gemsWithChecksum++
-		}
-	}
-	fmt.Printf("   🔒 %d/%d gems have security checksums\n", gemsWithChecksum, len(lock.GemSpecs))
+	stats := lock.Stats()
+	fmt.Printf("   🔒 %d/%d gems have security checksums\n", stats.WithChecksums, len(lock.GemSpecs))
 }
 
 func checkPlatformGems(lock *lockfile.Lockfile) {
-	platformGems := 0
-	for i := range lock.GemSpecs {
-		gem := &lock.GemSpecs[i]
-		if gem.Platform != "" && gem.Platform != "ruby" {
-			platformGems++
-		}
-	}
-	if platformGems > 0 {
+	if platformGems := lock.Stats().PlatformSpecific; platformGems > 0 {
 		fmt.Printf("   💻 %d platform-specific gems found\n", platformGems)
 	}
 }
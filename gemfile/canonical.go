@@ -0,0 +1,109 @@
+package gemfile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CanonicalHash returns a stable SHA-256 digest of what the Gemfile declares:
+// dependencies, sources, git_source templates, plugins, ruby version and
+// gemspec directives. Declaration order, formatting and comments don't affect
+// the result, so two Gemfiles hash equally when they mean the same thing.
+// Sources are the exception: their order decides where gems resolve from, so
+// they are hashed in declaration order.
+func (p *ParsedGemfile) CanonicalHash() string {
+	var lines []string
+
+	if len(p.RubyVersionConstraints) > 0 {
+		lines = append(lines, "ruby "+strings.Join(p.RubyVersionConstraints, ", "))
+	} else if p.RubyVersion != "" {
+		lines = append(lines, "ruby "+p.RubyVersion)
+	}
+
+	for name, template := range p.GitSources {
+		lines = append(lines, fmt.Sprintf("git_source %s=%s", name, template))
+	}
+
+	for i := range p.Dependencies {
+		lines = append(lines, "gem "+canonicalDependency(&p.Dependencies[i]))
+	}
+
+	for i := range p.Plugins {
+		lines = append(lines, "plugin "+canonicalPlugin(&p.Plugins[i]))
+	}
+
+	for _, ref := range p.Gemspecs {
+		require := "<auto>"
+		if ref.Require != nil {
+			require = *ref.Require
+		}
+		lines = append(lines, fmt.Sprintf("gemspec path=%s name=%s development_group=%s glob=%s name_group=%t require=%s",
+			ref.Path, ref.Name, ref.DevelopmentGroup, ref.Glob, ref.NameGroup, require))
+	}
+
+	slices.Sort(lines)
+
+	sources := make([]string, 0, len(p.Sources))
+	for i := range p.Sources {
+		sources = append(sources, "source "+canonicalSource(&p.Sources[i]))
+	}
+	lines = append(sources, lines...)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalDependency renders a dependency with its unordered fields sorted
+func canonicalDependency(dep *GemDependency) string {
+	groups := sortedCopy(dep.Groups)
+	if len(groups) == 0 {
+		groups = []string{defaultGroup}
+	}
+
+	require := "<auto>"
+	if dep.Require != nil {
+		require = *dep.Require
+	}
+
+	source := ""
+	if dep.Source != nil {
+		source = canonicalSource(dep.Source)
+	}
+
+	return fmt.Sprintf("%s constraints=%s source=%s groups=%s platforms=%s require=%s force_ruby_platform=%t install_if=%s",
+		dep.Name,
+		strings.Join(sortedCopy(dep.Constraints), ","),
+		source,
+		strings.Join(groups, ","),
+		strings.Join(sortedCopy(dep.Platforms), ","),
+		require,
+		dep.ForceRubyPlatform,
+		installCondition(dep),
+	)
+}
+
+// canonicalPlugin renders a plugin declaration with its constraints sorted
+func canonicalPlugin(plugin *Plugin) string {
+	source := ""
+	if plugin.Source != nil {
+		source = canonicalSource(plugin.Source)
+	}
+	return fmt.Sprintf("%s constraints=%s source=%s",
+		plugin.Name, strings.Join(sortedCopy(plugin.Constraints), ","), source)
+}
+
+// canonicalSource renders a canonicalized source in a fixed field order
+func canonicalSource(source *Source) string {
+	canonical := source.Canonical()
+	return fmt.Sprintf("%s:%s branch=%s tag=%s ref=%s", canonical.Type, canonical.URL, canonical.Branch, canonical.Tag, canonical.Ref)
+}
+
+// sortedCopy returns a sorted copy of values
+func sortedCopy(values []string) []string {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return sorted
+}
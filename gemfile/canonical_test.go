@@ -0,0 +1,81 @@
+package gemfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCanonicalHash(t *testing.T) {
+	original := `source 'https://rubygems.org'
+ruby '3.3.0'
+
+gem 'rails', '~> 7.0', '>= 7.0.4'
+gem 'puma'
+
+group :development, :test do
+  gem 'rspec', require: false
+end
+`
+	reordered := `# Reordered, reformatted and commented
+source "https://rubygems.org"
+
+ruby "3.3.0"
+
+group :test, :development do
+  gem "rspec", require: false # specs
+end
+
+gem "puma"
+gem "rails", ">= 7.0.4", "~> 7.0"
+`
+	changed := `source 'https://rubygems.org'
+ruby '3.3.0'
+
+gem 'rails', '~> 7.1'
+gem 'puma'
+
+group :development, :test do
+  gem 'rspec', require: false
+end
+`
+
+	hash := func(t *testing.T, content string) string {
+		t.Helper()
+		parser := &GemfileParser{content: content}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		return parsed.CanonicalHash()
+	}
+
+	originalHash := hash(t, original)
+	if len(originalHash) != 64 {
+		t.Errorf("Expected a hex SHA-256 digest, got %q", originalHash)
+	}
+	if reorderedHash := hash(t, reordered); reorderedHash != originalHash {
+		t.Errorf("Expected equivalent Gemfiles to hash equally, got %s and %s", originalHash, reorderedHash)
+	}
+	if changedHash := hash(t, changed); changedHash == originalHash {
+		t.Error("Expected a changed constraint to change the hash")
+	}
+
+	// Each of these changes how Bundler installs the same gems
+	behaviorChanges := map[string]string{
+		"plugin":     original + "plugin 'bundler-multilock'\n",
+		"git_source": original + "git_source(:internal) { |repo| \"https://git.example.com/#{repo}.git\" }\n",
+		"install_if": strings.Replace(original, "gem 'puma'", "gem 'puma', install_if: -> { ENV['WEB'] }", 1),
+		"source":     strings.Replace(original, "source 'https://rubygems.org'", "source 'https://gems.example.com'\nsource 'https://rubygems.org'", 1),
+	}
+	for name, content := range behaviorChanges {
+		if changedHash := hash(t, content); changedHash == originalHash {
+			t.Errorf("Expected a %s change to change the hash", name)
+		}
+	}
+
+	twoSources := "source 'https://gems.example.com'\nsource 'https://rubygems.org'\ngem 'rails'\n"
+	swapped := "source 'https://rubygems.org'\nsource 'https://gems.example.com'\ngem 'rails'\n"
+	if hash(t, twoSources) == hash(t, swapped) {
+		t.Error("Expected source order to change the hash")
+	}
+}
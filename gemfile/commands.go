@@ -3,6 +3,7 @@ package gemfile
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 )
 
@@ -10,6 +11,23 @@ const (
 	defaultGemfileName = "Gemfile"
 )
 
+var (
+	// validGemNameRe mirrors RubyGems' Gem::Specification::VALID_NAME_PATTERN
+	validGemNameRe = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	// gemNameLetterRe requires at least one letter, as RubyGems does
+	gemNameLetterRe = regexp.MustCompile(`[a-zA-Z]`)
+)
+
+// ValidGemName reports whether name is accepted by RubyGems: only letters,
+// digits, dots, dashes and underscores, not starting with one of the
+// punctuation characters, and containing at least one letter.
+// Ruby equivalent: Gem::SpecificationPolicy#validate_name
+func ValidGemName(name string) bool {
+	return validGemNameRe.MatchString(name) &&
+		!strings.ContainsAny(name[:1], "._-") &&
+		gemNameLetterRe.MatchString(name)
+}
+
 // AddOptions represents options for the add command
 type AddOptions struct {
 	Name        string
@@ -36,9 +54,46 @@ type RemoveOptions struct {
 
 // AddGemCommand handles the ore add command
 func AddGemCommand(gemfilePath string, opts *AddOptions) error {
+	gemfilePath, dep, err := prepareAddGem(gemfilePath, opts)
+	if err != nil {
+		return err
+	}
+
+	// Add gem to Gemfile
+	if err := AddGemToFile(gemfilePath, &dep); err != nil {
+		return fmt.Errorf("failed to add gem to Gemfile: %w", err)
+	}
+
+	return nil
+}
+
+// PreviewAddGem returns the Gemfile content AddGemCommand would write with the
+// same options, without touching the file
+func PreviewAddGem(gemfilePath string, opts *AddOptions) (string, error) {
+	gemfilePath, dep, err := prepareAddGem(gemfilePath, opts)
+	if err != nil {
+		return "", err
+	}
+
+	writer := NewGemfileWriter(gemfilePath)
+	if err := writer.Load(); err != nil {
+		return "", err
+	}
+	if err := writer.addGem(&dep); err != nil {
+		return "", fmt.Errorf("failed to add gem to Gemfile: %w", err)
+	}
+
+	return writer.render(), nil
+}
+
+// prepareAddGem validates the add options, locates the Gemfile and builds the dependency to add
+func prepareAddGem(gemfilePath string, opts *AddOptions) (string, GemDependency, error) {
 	// Validate gem name
 	if opts.Name == "" {
-		return fmt.Errorf("gem name is required")
+		return "", GemDependency{}, fmt.Errorf("gem name is required")
+	}
+	if !ValidGemName(opts.Name) {
+		return "", GemDependency{}, fmt.Errorf("invalid gem name %q: use only letters, numbers, dots, dashes and underscores", opts.Name)
 	}
 
 	// Find Gemfile
@@ -47,7 +102,7 @@ func AddGemCommand(gemfilePath string, opts *AddOptions) error {
 	}
 
 	if _, err := os.Stat(gemfilePath); os.IsNotExist(err) {
-		return fmt.Errorf("gemfile not found, use 'ore init' to create one")
+		return "", GemDependency{}, fmt.Errorf("gemfile not found, use 'ore init' to create one")
 	}
 
 	// Build dependency
@@ -102,19 +157,52 @@ func AddGemCommand(gemfilePath string, opts *AddOptions) error {
 		dep.Groups = []string{"default"}
 	}
 
-	// Add gem to Gemfile
-	if err := AddGemToFile(gemfilePath, &dep); err != nil {
-		return fmt.Errorf("failed to add gem to Gemfile: %w", err)
+	return gemfilePath, dep, nil
+}
+
+// RemoveGemCommand handles the ore remove command
+func RemoveGemCommand(gemfilePath string, opts RemoveOptions) error {
+	gemfilePath, err := prepareRemoveGems(gemfilePath, opts)
+	if err != nil {
+		return err
+	}
+
+	// Remove each gem
+	for _, gemName := range opts.GemNames {
+		if err := RemoveGemFromFile(gemfilePath, gemName); err != nil {
+			return fmt.Errorf("failed to remove gem %q: %w", gemName, err)
+		}
 	}
 
 	return nil
 }
 
-// RemoveGemCommand handles the ore remove command
-func RemoveGemCommand(gemfilePath string, opts RemoveOptions) error {
+// PreviewRemoveGem returns the Gemfile content RemoveGemCommand would write
+// with the same options, without touching the file
+func PreviewRemoveGem(gemfilePath string, opts RemoveOptions) (string, error) {
+	gemfilePath, err := prepareRemoveGems(gemfilePath, opts)
+	if err != nil {
+		return "", err
+	}
+
+	writer := NewGemfileWriter(gemfilePath)
+	if err := writer.Load(); err != nil {
+		return "", err
+	}
+	for _, gemName := range opts.GemNames {
+		if err := writer.removeGem(gemName); err != nil {
+			return "", fmt.Errorf("failed to remove gem %q: %w", gemName, err)
+		}
+	}
+
+	return writer.render(), nil
+}
+
+// prepareRemoveGems validates the remove options and locates the Gemfile
+func prepareRemoveGems(gemfilePath string, opts RemoveOptions) (string, error) {
 	// Validate gem names
 	if len(opts.GemNames) == 0 {
-		return fmt.Errorf("at least one gem name is required")
+		return "", fmt.Errorf("at least one gem name is required")
 	}
 
 	// Find Gemfile
@@ -123,17 +211,10 @@ func RemoveGemCommand(gemfilePath string, opts RemoveOptions) error {
 	}
 
 	if _, err := os.Stat(gemfilePath); os.IsNotExist(err) {
-		return fmt.Errorf("gemfile not found")
+		return "", fmt.Errorf("gemfile not found")
 	}
 
-	// Remove each gem
-	for _, gemName := range opts.GemNames {
-		if err := RemoveGemFromFile(gemfilePath, gemName); err != nil {
-			return fmt.Errorf("failed to remove gem %q: %w", gemName, err)
-		}
-	}
-
-	return nil
+	return gemfilePath, nil
 }
 
 // findGemfile finds the Gemfile in the current directory
@@ -7,6 +7,22 @@ import (
 	"testing"
 )
 
+func TestValidGemName(t *testing.T) {
+	valid := []string{"rails", "concurrent-ruby", "net_http", "i18n", "2fa-tools", "ruby.wasm", "A"}
+	for _, name := range valid {
+		if !ValidGemName(name) {
+			t.Errorf("Expected %q to be a valid gem name", name)
+		}
+	}
+
+	invalid := []string{"", "my gem", "owner/repo", "-dash", ".hidden", "_private", "1234", "rails\n", "gem!"}
+	for _, name := range invalid {
+		if ValidGemName(name) {
+			t.Errorf("Expected %q to be an invalid gem name", name)
+		}
+	}
+}
+
 // TestAddGemCommand tests the add gem command
 func TestAddGemCommand(t *testing.T) {
 	tests := []struct {
@@ -152,6 +168,14 @@ gem 'bootsnap', require: false`,
 			},
 			expectedErr: "gem name is required",
 		},
+		{
+			name:           "error on invalid name",
+			initialGemfile: `source 'https://rubygems.org'`,
+			opts: AddOptions{
+				Name: "my gem",
+			},
+			expectedErr: `invalid gem name "my gem"`,
+		},
 		{
 			name: "error on duplicate gem",
 			initialGemfile: `source 'https://rubygems.org'
@@ -176,6 +200,12 @@ gem 'rails'`,
 				t.Fatalf("Failed to write initial Gemfile: %v", err)
 			}
 
+			// Preview first; it must leave the file untouched
+			preview, previewErr := PreviewAddGem(gemfilePath, &tt.opts)
+			if unchanged, _ := os.ReadFile(gemfilePath); string(unchanged) != tt.initialGemfile {
+				t.Fatalf("Expected preview to leave the Gemfile untouched, got:\n%s", unchanged)
+			}
+
 			// Run add command
 			err = AddGemCommand(gemfilePath, &tt.opts)
 
@@ -187,9 +217,16 @@ gem 'rails'`,
 				if !strings.Contains(err.Error(), tt.expectedErr) {
 					t.Fatalf("Expected error containing %q but got %q", tt.expectedErr, err.Error())
 				}
+				if previewErr == nil || !strings.Contains(previewErr.Error(), tt.expectedErr) {
+					t.Fatalf("Expected preview error containing %q but got %v", tt.expectedErr, previewErr)
+				}
 				return
 			}
 
+			if previewErr != nil {
+				t.Fatalf("Unexpected preview error: %v", previewErr)
+			}
+
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -203,6 +240,9 @@ gem 'rails'`,
 			if string(content) != tt.expectedContent {
 				t.Fatalf("Expected content:\n%s\n\nActual content:\n%s", tt.expectedContent, string(content))
 			}
+			if preview != string(content) {
+				t.Fatalf("Expected preview to match the written Gemfile:\n%s\n\nPreview:\n%s", content, preview)
+			}
 		})
 	}
 }
@@ -277,6 +317,12 @@ gem 'rails'`,
 				t.Fatalf("Failed to write initial Gemfile: %v", err)
 			}
 
+			// Preview first; it must leave the file untouched
+			preview, previewErr := PreviewRemoveGem(gemfilePath, tt.opts)
+			if unchanged, _ := os.ReadFile(gemfilePath); string(unchanged) != tt.initialGemfile {
+				t.Fatalf("Expected preview to leave the Gemfile untouched, got:\n%s", unchanged)
+			}
+
 			// Run remove command
 			err = RemoveGemCommand(gemfilePath, tt.opts)
 
@@ -288,9 +334,16 @@ gem 'rails'`,
 				if !strings.Contains(err.Error(), tt.expectedErr) {
 					t.Fatalf("Expected error containing %q but got %q", tt.expectedErr, err.Error())
 				}
+				if previewErr == nil || !strings.Contains(previewErr.Error(), tt.expectedErr) {
+					t.Fatalf("Expected preview error containing %q but got %v", tt.expectedErr, previewErr)
+				}
 				return
 			}
 
+			if previewErr != nil {
+				t.Fatalf("Unexpected preview error: %v", previewErr)
+			}
+
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
 			}
@@ -304,6 +357,9 @@ gem 'rails'`,
 			if string(content) != tt.expectedContent {
 				t.Fatalf("Expected content:\n%s\n\nActual content:\n%s", tt.expectedContent, string(content))
 			}
+			if preview != string(content) {
+				t.Fatalf("Expected preview to match the written Gemfile:\n%s\n\nPreview:\n%s", content, preview)
+			}
 		})
 	}
 }
@@ -0,0 +1,150 @@
+package gemfile
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseDiscrepancy lists the dependencies the tree-sitter and regex backends
+// parsed differently
+type ParseDiscrepancy struct {
+	OnlyTreeSitter []string             // Gems only the tree-sitter parser found
+	OnlyRegex      []string             // Gems only the regex parser found
+	Mismatched     []DependencyMismatch // Gems both found but with different details
+}
+
+// DependencyMismatch records one field of a gem that the two backends disagree on
+type DependencyMismatch struct {
+	Name       string // Gem name
+	Field      string // GemDependency field, e.g. "Constraints" or "Source"
+	TreeSitter string // Value from the tree-sitter parser
+	Regex      string // Value from the regex parser
+}
+
+// String summarizes the discrepancy, one difference per line
+func (d *ParseDiscrepancy) String() string {
+	var lines []string
+	for _, name := range d.OnlyTreeSitter {
+		lines = append(lines, fmt.Sprintf("gem %q: only found by tree-sitter", name))
+	}
+	for _, name := range d.OnlyRegex {
+		lines = append(lines, fmt.Sprintf("gem %q: only found by regex", name))
+	}
+	for _, m := range d.Mismatched {
+		lines = append(lines, fmt.Sprintf("gem %q: %s differs: tree-sitter %s, regex %s", m.Name, m.Field, m.TreeSitter, m.Regex))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseCompare parses the Gemfile with both the tree-sitter and the regex
+// backend and reports where their dependencies differ. It returns the same
+// result as Parse, plus a nil discrepancy when both backends agree.
+// Meant for CI and debugging the parsers themselves; it does twice the work of Parse.
+func (p *GemfileParser) ParseCompare() (*ParsedGemfile, *ParseDiscrepancy, error) {
+	content, err := os.ReadFile(p.filepath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Gemfile: %w", err)
+	}
+
+	p.content = string(content)
+
+	tsParser := NewTreeSitterGemfileParser(content)
+	tsParser.Limits = p.Limits
+	tsParser.lint = p.Lint
+	tsGemfile, err := tsParser.ParseWithTreeSitter()
+	if err != nil {
+		return nil, nil, fmt.Errorf("tree-sitter parse failed: %w", err)
+	}
+
+	regexGemfile, err := p.parseContent()
+	if err != nil {
+		return nil, nil, fmt.Errorf("regex parse failed: %w", err)
+	}
+
+	gemfile, err := p.preferTreeSitter(tsGemfile, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return gemfile, compareDependencies(tsGemfile.Dependencies, regexGemfile.Dependencies), nil
+}
+
+// compareDependencies pairs up gems by name, in declaration order for gems
+// declared more than once, and returns nil when both lists match
+func compareDependencies(tsDeps, regexDeps []GemDependency) *ParseDiscrepancy {
+	regexByName := make(map[string][]GemDependency)
+	for _, dep := range regexDeps {
+		regexByName[dep.Name] = append(regexByName[dep.Name], dep)
+	}
+
+	var discrepancy ParseDiscrepancy
+	for _, tsDep := range tsDeps {
+		candidates := regexByName[tsDep.Name]
+		if len(candidates) == 0 {
+			discrepancy.OnlyTreeSitter = append(discrepancy.OnlyTreeSitter, tsDep.Name)
+			continue
+		}
+		regexByName[tsDep.Name] = candidates[1:]
+		discrepancy.Mismatched = append(discrepancy.Mismatched, compareDependency(&tsDep, &candidates[0])...)
+	}
+	for _, dep := range regexDeps {
+		if remaining := regexByName[dep.Name]; len(remaining) > 0 {
+			discrepancy.OnlyRegex = append(discrepancy.OnlyRegex, dep.Name)
+			regexByName[dep.Name] = remaining[1:]
+		}
+	}
+
+	if len(discrepancy.OnlyTreeSitter) == 0 && len(discrepancy.OnlyRegex) == 0 && len(discrepancy.Mismatched) == 0 {
+		return nil
+	}
+	return &discrepancy
+}
+
+// compareDependency returns the fields two parses of the same gem disagree on
+func compareDependency(ts, regex *GemDependency) []DependencyMismatch {
+	fields := []struct {
+		name                string
+		tsValue, regexValue string
+	}{
+		{"Constraints", formatList(ts.Constraints), formatList(regex.Constraints)},
+		{"Groups", formatList(ts.Groups), formatList(regex.Groups)},
+		{"Platforms", formatList(ts.Platforms), formatList(regex.Platforms)},
+		{"Source", formatSource(ts.Source), formatSource(regex.Source)},
+		{"Require", formatRequire(ts.Require), formatRequire(regex.Require)},
+	}
+
+	var mismatches []DependencyMismatch
+	for _, f := range fields {
+		if f.tsValue != f.regexValue {
+			mismatches = append(mismatches, DependencyMismatch{
+				Name:       ts.Name,
+				Field:      f.name,
+				TreeSitter: f.tsValue,
+				Regex:      f.regexValue,
+			})
+		}
+	}
+	return mismatches
+}
+
+// formatList renders a string list so that nil and empty lists compare equal
+func formatList(values []string) string {
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+// formatSource renders a gem source for comparison
+func formatSource(source *Source) string {
+	if source == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%s %s (branch %q, tag %q, ref %q)", source.Type, source.URL, source.Branch, source.Tag, source.Ref)
+}
+
+// formatRequire renders a require option for comparison
+func formatRequire(require *string) string {
+	if require == nil {
+		return "default"
+	}
+	return fmt.Sprintf("%q", *require)
+}
@@ -0,0 +1,97 @@
+package gemfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompareDependencies(t *testing.T) {
+	falseRequire := ""
+	tsDeps := []GemDependency{
+		{Name: "rails", Constraints: []string{"~> 7.0"}, Groups: []string{"default"}},
+		{Name: "pg", Groups: []string{"default"}, Platforms: []string{"ruby"}},
+		{Name: "byebug", Groups: []string{"development"}, Require: &falseRequire},
+		{Name: "dotenv", Groups: []string{"default"}},
+	}
+	regexDeps := []GemDependency{
+		{Name: "rails", Constraints: []string{"~> 7.0"}, Groups: []string{"default"}},
+		{Name: "pg", Groups: []string{"default"}},
+		{Name: "byebug", Groups: []string{"development"}, Require: &falseRequire},
+		{Name: "sqlite3", Groups: []string{"default"}},
+	}
+
+	if d := compareDependencies(tsDeps[:1], regexDeps[:1]); d != nil {
+		t.Errorf("expected no discrepancy, got:\n%s", d)
+	}
+
+	d := compareDependencies(tsDeps, regexDeps)
+	if d == nil {
+		t.Fatal("expected a discrepancy")
+	}
+	if strings.Join(d.OnlyTreeSitter, ",") != "dotenv" || strings.Join(d.OnlyRegex, ",") != "sqlite3" {
+		t.Errorf("unexpected missing gems: tree-sitter only %v, regex only %v", d.OnlyTreeSitter, d.OnlyRegex)
+	}
+	if len(d.Mismatched) != 1 {
+		t.Fatalf("expected 1 mismatch, got %+v", d.Mismatched)
+	}
+	want := DependencyMismatch{Name: "pg", Field: "Platforms", TreeSitter: "[ruby]", Regex: "[]"}
+	if d.Mismatched[0] != want {
+		t.Errorf("expected mismatch %+v, got %+v", want, d.Mismatched[0])
+	}
+	if !strings.Contains(d.String(), `gem "pg": Platforms differs`) {
+		t.Errorf("unexpected summary:\n%s", d)
+	}
+}
+
+func TestParseCompare(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		wantDiscrepancy bool
+	}{
+		{
+			name: "identical",
+			content: `source 'https://rubygems.org'
+
+gem 'rails', '~> 7.0'
+
+group :development, :test do
+  gem 'rspec-rails', require: false
+end
+`,
+		},
+		{
+			// The regex parser doesn't track platforms blocks
+			name: "platforms block",
+			content: `source 'https://rubygems.org'
+
+platforms :jruby do
+  gem 'activerecord-jdbc-adapter'
+end
+`,
+			wantDiscrepancy: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+			if err := os.WriteFile(gemfilePath, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("Failed to write Gemfile: %v", err)
+			}
+
+			parsed, discrepancy, err := NewGemfileParser(gemfilePath).ParseCompare()
+			if err != nil {
+				t.Fatalf("ParseCompare failed: %v", err)
+			}
+			if len(parsed.Dependencies) == 0 {
+				t.Error("expected dependencies to be parsed")
+			}
+			if (discrepancy != nil) != tt.wantDiscrepancy {
+				t.Errorf("expected discrepancy %v, got %+v", tt.wantDiscrepancy, discrepancy)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package gemfile
+
+import (
+	"fmt"
+
+	"github.com/contriboss/gemfile-go/internal/yaml"
+)
+
+// ToYAML renders the parsed Gemfile as YAML, using the same field names as its JSON form.
+func (g *ParsedGemfile) ToYAML() ([]byte, error) {
+	return yaml.Marshal(g)
+}
+
+// FromYAML reads a parsed Gemfile previously exported with ToYAML.
+func FromYAML(data []byte) (*ParsedGemfile, error) {
+	var g ParsedGemfile
+	if err := yaml.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("failed to read Gemfile YAML: %w", err)
+	}
+	return &g, nil
+}
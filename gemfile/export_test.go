@@ -0,0 +1,30 @@
+package gemfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGemfileYAMLRoundTrip(t *testing.T) {
+	parsed, err := NewGemfileParser("../testdata/Gemfile").Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse Gemfile: %v", err)
+	}
+
+	data, err := parsed.ToYAML()
+	if err != nil {
+		t.Fatalf("ToYAML failed: %v", err)
+	}
+	if !strings.Contains(string(data), "Dependencies:\n  - Name: ") {
+		t.Errorf("expected block-style dependencies, got:\n%s", data)
+	}
+
+	decoded, err := FromYAML(data)
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, parsed) {
+		t.Errorf("round trip mismatch:\n got: %+v\nwant: %+v", decoded, parsed)
+	}
+}
@@ -3,6 +3,7 @@ package gemfile
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	tree_sitter "github.com/tree-sitter/go-tree-sitter"
@@ -10,10 +11,16 @@ import (
 
 // TreeSitterGemfileParser handles parsing of Gemfile using tree-sitter
 type TreeSitterGemfileParser struct {
-	content      []byte
-	helper       *RubyASTHelper
-	contextStack *parserContextStack
-	variables    map[string]string // Track variable assignments
+	content        []byte
+	helper         *RubyASTHelper
+	contextStack   *parserContextStack
+	variables      map[string]string // Track variable assignments
+	Limits         ParseLimits       // Guards against pathological input (zero values use defaults)
+	lint           bool              // Also warn about likely mistakes, see GemfileParser.Lint
+	primarySources []Source          // Top-level source calls without a block, for the multiple sources warning
+	gitSources     map[string]string // git_source templates registered so far, expanded by applyGemOption
+	nestingDepth   int               // Blocks, collections and conditionals currently open in the walk
+	limitErr       error             // First limit exceeded during the walk, which stops it
 }
 
 // parserContext tracks the current parsing context (groups, platforms, sources, conditions)
@@ -22,12 +29,14 @@ type parserContext struct {
 	platforms   []string       // Current platform restrictions
 	source      *Source        // Current source block
 	conditional bool           // Whether we're inside a conditional
+	installIf   []string       // Conditions of the enclosing install_if blocks, outermost first
 	parent      *parserContext // Parent context for nested blocks
 }
 
 // parserContextStack manages the context stack for nested blocks
 type parserContextStack struct {
 	current *parserContext
+	depth   int // Current nesting depth
 }
 
 // newParserContextStack creates a new context stack with default context
@@ -47,6 +56,7 @@ func (s *parserContextStack) push(modifyFn func(*parserContext)) {
 		platforms:   make([]string, len(s.current.platforms)),
 		source:      s.current.source,
 		conditional: s.current.conditional,
+		installIf:   s.current.installIf,
 		parent:      s.current,
 	}
 	copy(newCtx.groups, s.current.groups)
@@ -58,12 +68,14 @@ func (s *parserContextStack) push(modifyFn func(*parserContext)) {
 	}
 
 	s.current = newCtx
+	s.depth++
 }
 
 // pop restores the parent context
 func (s *parserContextStack) pop() {
 	if s.current.parent != nil {
 		s.current = s.current.parent
+		s.depth--
 	}
 }
 
@@ -79,18 +91,32 @@ func NewTreeSitterGemfileParser(content []byte) *TreeSitterGemfileParser {
 
 // ParseWithTreeSitter parses a Gemfile using tree-sitter and returns structured data
 func (p *TreeSitterGemfileParser) ParseWithTreeSitter() (*ParsedGemfile, error) {
+	gemfile, tree, err := p.parseTree(nil)
+	if tree != nil {
+		tree.Close()
+	}
+	return gemfile, err
+}
+
+// parseTree parses the content, reusing oldTree for an incremental parse when it
+// is not nil. oldTree must already reflect the edit via Tree.Edit.
+// The returned syntax tree is owned by the caller, even when an error is returned.
+func (p *TreeSitterGemfileParser) parseTree(oldTree *tree_sitter.Tree) (*ParsedGemfile, *tree_sitter.Tree, error) {
+	if err := p.Limits.checkFileSize(len(p.content)); err != nil {
+		return nil, nil, err
+	}
+
 	parser := tree_sitter.NewParser()
 	defer parser.Close()
 
 	if err := parser.SetLanguage(rubyLanguage); err != nil {
-		return nil, fmt.Errorf("failed to set language: %w", err)
+		return nil, nil, fmt.Errorf("failed to set language: %w", err)
 	}
 
-	tree := parser.Parse(p.content, nil)
+	tree := parser.Parse(p.content, oldTree)
 	if tree == nil {
-		return nil, fmt.Errorf("failed to parse Gemfile")
+		return nil, nil, fmt.Errorf("failed to parse Gemfile")
 	}
-	defer tree.Close()
 
 	root := tree.RootNode()
 
@@ -98,22 +124,61 @@ func (p *TreeSitterGemfileParser) ParseWithTreeSitter() (*ParsedGemfile, error)
 		Sources:      []Source{},
 		Dependencies: []GemDependency{},
 		Gemspecs:     []GemspecReference{},
+		GitSources:   make(map[string]string),
 	}
 
 	// Walk the AST and extract Gemfile data
+	p.primarySources = nil
+	p.gitSources = gemfile.GitSources
+	p.nestingDepth = 0
+	p.limitErr = nil
 	p.extractGemfileData(root, gemfile)
 
-	return gemfile, nil
+	if p.limitErr != nil {
+		return nil, tree, p.limitErr
+	}
+
+	collectSourceWarnings(gemfile)
+	collectPrimarySourcesWarning(gemfile, p.primarySources)
+
+	return gemfile, tree, nil
+}
+
+// nestingNodes are the node kinds counted against ParseLimits.MaxNestingDepth:
+// blocks, collections and conditionals
+var nestingNodes = map[string]bool{
+	nodeDoBlock:                true,
+	nodeBlock:                  true,
+	nodeArray:                  true,
+	"hash":                     true,
+	nodeIf:                     true,
+	nodeUnless:                 true,
+	"case":                     true,
+	"while":                    true,
+	"until":                    true,
+	"begin":                    true,
+	"lambda":                   true,
+	"parenthesized_statements": true,
 }
 
 // extractGemfileData walks the AST to extract Gemfile data
 func (p *TreeSitterGemfileParser) extractGemfileData(node *tree_sitter.Node, gemfile *ParsedGemfile) {
-	if node == nil {
+	if node == nil || p.limitErr != nil {
 		return
 	}
 
 	kind := node.Kind()
 
+	// Count nesting as it is entered, so deeply nested input stops the walk early
+	if nestingNodes[kind] {
+		p.nestingDepth++
+		defer func() { p.nestingDepth-- }()
+		if err := p.Limits.checkNestingDepth(p.nestingDepth); err != nil {
+			p.limitErr = err
+			return
+		}
+	}
+
 	// Process different node types
 	switch kind {
 	case nodeAssignment:
@@ -163,15 +228,33 @@ func (p *TreeSitterGemfileParser) processCall(node *tree_sitter.Node, gemfile *P
 		p.processGroup(node, gemfile)
 	case platformsMethod, platformMethod:
 		p.processPlatform(node, gemfile)
+	case installIfKey:
+		p.processInstallIf(node, gemfile)
 	case sourceKey:
 		p.processSource(node, gemfile)
 	case "ruby":
 		p.processRubyVersion(node, gemfile)
 	case gemspecDirective:
 		p.processGemspec(node, gemfile)
+	case pluginMethod:
+		p.processPlugin(node, gemfile)
 	case "git_source":
-		// Skip git_source definitions for now
+		p.processGitSource(node, gemfile)
 	default:
+		// Gems declared inside Dir[]/Dir.glob loops can't be resolved statically
+		if strings.HasPrefix(methodName, "each") && p.isDirGlob(node.Child(0)) {
+			line := int(node.StartPosition().Row) + 1
+			gemfile.Warnings = append(gemfile.Warnings, dynamicGemLoadingWarning(line))
+			return
+		}
+
+		if p.lint {
+			if directive := p.extractCommandName(node); gemTypos[directive] {
+				line := int(node.StartPosition().Row) + 1
+				gemfile.Warnings = append(gemfile.Warnings, gemTypoWarning(line, directive))
+			}
+		}
+
 		// For unknown methods, still traverse children
 		for i := uint(0); i < node.ChildCount(); i++ {
 			p.extractGemfileData(node.Child(i), gemfile)
@@ -179,7 +262,8 @@ func (p *TreeSitterGemfileParser) processCall(node *tree_sitter.Node, gemfile *P
 	}
 }
 
-// processGem processes a gem declaration
+// processGem processes a gem declaration.
+// A do...end block passed to gem (plugin configuration) is not traversed.
 func (p *TreeSitterGemfileParser) processGem(node *tree_sitter.Node, gemfile *ParsedGemfile) {
 	args := p.extractArguments(node)
 	if len(args) == 0 {
@@ -191,24 +275,78 @@ func (p *TreeSitterGemfileParser) processGem(node *tree_sitter.Node, gemfile *Pa
 		Groups:    make([]string, len(p.contextStack.current.groups)),
 		Platforms: make([]string, len(p.contextStack.current.platforms)),
 		Source:    p.contextStack.current.source,
+		InstallIf: slices.Clone(p.contextStack.current.installIf),
 	}
 	copy(dep.Groups, p.contextStack.current.groups)
 	copy(dep.Platforms, p.contextStack.current.platforms)
 
-	// Extract version constraints (strings after the gem name)
+	// Extract version constraints (strings after the gem name).
+	// Variable references were already resolved by extractArguments.
 	for i := 1; i < len(args); i++ {
 		// Skip if it looks like an option hash
 		if !strings.Contains(args[i], ":") {
-			// Expand variables in constraints (e.g., rails_version -> '~> 8.1.0')
-			constraint := p.expandVariable(args[i])
-			dep.Constraints = append(dep.Constraints, constraint)
+			dep.Constraints = append(dep.Constraints, args[i])
 		}
 	}
 
 	// Extract hash options (require, platforms, groups, git, path, etc.)
-	p.extractGemOptions(node, &dep)
+	blockPlatforms := dep.Platforms
+	dep.Platforms = nil
+	for _, key := range p.extractGemOptions(node, &dep) {
+		gemfile.Warnings = append(gemfile.Warnings, dynamicOptionWarning(dep.Name, key))
+	}
+
+	// Inline platforms narrow those of an enclosing platforms block
+	switch {
+	case len(dep.Platforms) == 0:
+		dep.Platforms = blockPlatforms
+	case len(blockPlatforms) > 0:
+		if common := intersectPlatforms(blockPlatforms, dep.Platforms); len(common) > 0 {
+			dep.Platforms = common
+		} else {
+			// Keep the gem's own platforms, an empty list would mean "any platform"
+			gemfile.Warnings = append(gemfile.Warnings, disjointPlatformsWarning(dep.Name, dep.Platforms, blockPlatforms))
+		}
+	}
 
 	gemfile.Dependencies = append(gemfile.Dependencies, dep)
+	if err := p.Limits.checkDependencies(len(gemfile.Dependencies)); err != nil {
+		p.limitErr = err
+	}
+}
+
+// intersectPlatforms returns the inline platforms also allowed by the block, in inline order
+func intersectPlatforms(block, inline []string) []string {
+	var common []string
+	for _, platform := range inline {
+		if slices.Contains(block, platform) {
+			common = append(common, platform)
+		}
+	}
+	return common
+}
+
+// processPlugin processes a Bundler plugin declaration
+func (p *TreeSitterGemfileParser) processPlugin(node *tree_sitter.Node, gemfile *ParsedGemfile) {
+	args := p.extractArguments(node)
+	if len(args) == 0 {
+		return
+	}
+
+	plugin := Plugin{Name: args[0]}
+	for i := 1; i < len(args); i++ {
+		// Skip if it looks like an option hash
+		if !strings.Contains(args[i], ":") {
+			plugin.Constraints = append(plugin.Constraints, args[i])
+		}
+	}
+
+	// Source options are the same as for gems
+	var dep GemDependency
+	p.extractGemOptions(node, &dep)
+	plugin.Source = dep.Source
+
+	gemfile.Plugins = append(gemfile.Plugins, plugin)
 }
 
 // processGroup processes a group block
@@ -267,9 +405,42 @@ func (p *TreeSitterGemfileParser) processPlatform(node *tree_sitter.Node, gemfil
 	}
 }
 
+// processInstallIf processes an install_if block, e.g.
+// install_if -> { RUBY_PLATFORM =~ /darwin/ } do ... end.
+// The condition is kept as source text on each gem in the block.
+func (p *TreeSitterGemfileParser) processInstallIf(node *tree_sitter.Node, gemfile *ParsedGemfile) {
+	argList := p.helper.FindChildByKind(node, nodeArgumentList)
+	block := p.helper.FindChildByKind(node, nodeDoBlock)
+	if block == nil {
+		block = p.helper.FindChildByKind(node, nodeBlock)
+	}
+	if argList == nil || argList.NamedChildCount() == 0 || block == nil {
+		return
+	}
+	condition := p.helper.GetNodeText(argList.NamedChild(0))
+
+	p.contextStack.push(func(ctx *parserContext) {
+		// Nested blocks add their condition; all of them must hold
+		ctx.installIf = append(slices.Clip(ctx.installIf), condition)
+	})
+
+	// Process block body
+	p.extractGemfileData(block, gemfile)
+
+	p.contextStack.pop()
+}
+
 // processSource processes a source declaration or source block
 func (p *TreeSitterGemfileParser) processSource(node *tree_sitter.Node, gemfile *ParsedGemfile) {
 	args := p.extractArguments(node)
+	if len(args) == 0 {
+		// Legacy symbol form: source :rubygems
+		for _, symbol := range p.extractSymbolArguments(node) {
+			if url, ok := legacySourceURL(symbol); ok {
+				args = append(args, url)
+			}
+		}
+	}
 	if len(args) == 0 {
 		return
 	}
@@ -302,14 +473,54 @@ func (p *TreeSitterGemfileParser) processSource(node *tree_sitter.Node, gemfile
 	} else {
 		// Global source declaration
 		gemfile.Sources = append(gemfile.Sources, source)
+		if p.contextStack.depth == 0 {
+			p.primarySources = append(p.primarySources, source)
+		}
 	}
 }
 
+// processGitSource records a git_source registration such as
+// git_source(:gitlab) { |repo| "https://gitlab.com/#{repo}.git" }.
+// The block must end with the URL string; blocks that compute it are skipped.
+func (p *TreeSitterGemfileParser) processGitSource(node *tree_sitter.Node, gemfile *ParsedGemfile) {
+	names := p.extractSymbolArguments(node)
+	block := p.helper.FindChildByKind(node, nodeDoBlock)
+	if block == nil {
+		block = p.helper.FindChildByKind(node, nodeBlock)
+	}
+	if len(names) == 0 || block == nil {
+		return
+	}
+
+	body := block
+	for _, kind := range []string{nodeBlockBody, nodeBodyStatement} {
+		if child := p.helper.FindChildByKind(block, kind); child != nil {
+			body = child
+		}
+	}
+	if body.NamedChildCount() == 0 {
+		return
+	}
+	last := body.NamedChild(body.NamedChildCount() - 1)
+	if last == nil || last.Kind() != nodeString {
+		return
+	}
+
+	// Keep interpolations as written; the quotes are the first and last characters
+	text := p.helper.GetNodeText(last)
+	if len(text) < 2 {
+		return
+	}
+	gemfile.GitSources[names[0]] = gitSourceTemplate(text[1:len(text)-1], p.helper.ExtractBlockParameter(block))
+}
+
 // processRubyVersion processes a ruby version declaration
 func (p *TreeSitterGemfileParser) processRubyVersion(node *tree_sitter.Node, gemfile *ParsedGemfile) {
+	// Options such as engine: are pairs, so only the version constraints are collected
 	args := p.extractArguments(node)
 	if len(args) > 0 {
 		gemfile.RubyVersion = args[0]
+		gemfile.RubyVersionConstraints = args
 	}
 }
 
@@ -344,6 +555,28 @@ func (p *TreeSitterGemfileParser) processConditional(node *tree_sitter.Node, gem
 	p.contextStack.pop()
 }
 
+// isDirGlob reports whether a receiver expression is built from Dir[] or Dir.glob,
+// possibly followed by further calls such as .sort or .select
+func (p *TreeSitterGemfileParser) isDirGlob(node *tree_sitter.Node) bool {
+	if node == nil {
+		return false
+	}
+
+	switch node.Kind() {
+	case nodeElementReference:
+		object := node.Child(0)
+		return object != nil && object.Kind() == nodeConstant && p.helper.GetNodeText(object) == "Dir"
+	case nodeCall, nodeMethodCall:
+		receiver := node.Child(0)
+		if receiver != nil && receiver.Kind() == nodeConstant && p.helper.GetNodeText(receiver) == "Dir" {
+			return p.extractMethodName(node) == "glob"
+		}
+		return p.isDirGlob(receiver)
+	}
+
+	return false
+}
+
 // extractMethodName extracts the method name from a call node
 func (p *TreeSitterGemfileParser) extractMethodName(node *tree_sitter.Node) string {
 	if node == nil {
@@ -361,6 +594,19 @@ func (p *TreeSitterGemfileParser) extractMethodName(node *tree_sitter.Node) stri
 	return ""
 }
 
+// extractCommandName returns the method of a receiver-less call with arguments,
+// such as gems in gems 'rails' or Gem in Gem 'rails', and "" for other calls
+func (p *TreeSitterGemfileParser) extractCommandName(node *tree_sitter.Node) string {
+	if node.ChildCount() < 2 || node.Child(1).Kind() != nodeArgumentList {
+		return ""
+	}
+	method := node.Child(0)
+	if method.Kind() != nodeIdentifier && method.Kind() != nodeConstant {
+		return ""
+	}
+	return p.helper.GetNodeText(method)
+}
+
 // extractArguments extracts string arguments from a call node
 func (p *TreeSitterGemfileParser) extractArguments(node *tree_sitter.Node) []string {
 	var args []string
@@ -381,11 +627,12 @@ func (p *TreeSitterGemfileParser) extractArguments(node *tree_sitter.Node) []str
 			value := p.helper.ExtractStringValue(child)
 			args = append(args, value)
 		case nodeIdentifier:
-			// Handle variable references (e.g., rails_version)
+			// Handle variable references (e.g., rails_version). Identifiers that
+			// are not known variables are method calls we cannot evaluate, so skip them.
 			varName := p.helper.GetNodeText(child)
-			// Expand variable to its value if it exists
-			value := p.expandVariable(varName)
-			args = append(args, value)
+			if value, exists := p.variables[varName]; exists {
+				args = append(args, value)
+			}
 		}
 	}
 
@@ -406,39 +653,53 @@ func (p *TreeSitterGemfileParser) extractSymbolArguments(node *tree_sitter.Node)
 	for i := uint(0); i < argList.ChildCount(); i++ {
 		child := argList.Child(i)
 		kind := child.Kind()
-		if kind == nodeSymbol || kind == nodeSimpleSymbol {
-			value := p.helper.ExtractSymbolValue(child)
-			if value != "" {
-				symbols = append(symbols, value)
-			}
+		var value string
+		switch kind {
+		case nodeSymbol, nodeSimpleSymbol:
+			value = p.helper.ExtractSymbolValue(child)
+		case nodeString:
+			// Mixed arrays like [:jruby, 'windows']
+			value = p.helper.ExtractStringValue(child)
+		}
+		if value != "" {
+			symbols = append(symbols, value)
 		}
 	}
 
 	return symbols
 }
 
-// extractGemOptions extracts hash options from a gem declaration
-func (p *TreeSitterGemfileParser) extractGemOptions(node *tree_sitter.Node, dep *GemDependency) {
+// extractGemOptions extracts hash options from a gem declaration.
+// It returns the keys of options that were skipped because their value is dynamic.
+func (p *TreeSitterGemfileParser) extractGemOptions(node *tree_sitter.Node, dep *GemDependency) []string {
 	// Find argument_list
 	argList := p.helper.FindChildByKind(node, nodeArgumentList)
 	if argList == nil {
-		return
+		return nil
 	}
 
 	// Look for pair nodes directly in argument_list (Ruby 2.x+ style) or hash node (older style)
+	var skipped []string
 	for i := uint(0); i < argList.ChildCount(); i++ {
 		child := argList.Child(i)
 		switch child.Kind() {
 		case nodePair:
-			p.extractPairOption(child, dep)
+			if key := p.extractPairOption(child, dep); key != "" {
+				skipped = append(skipped, key)
+			}
 		case "hash":
-			p.extractHashOptions(child, dep)
+			skipped = append(skipped, p.extractHashOptions(child, dep)...)
 		}
 	}
+
+	return skipped
 }
 
-// extractPairOption extracts a single key-value pair option
-func (p *TreeSitterGemfileParser) extractPairOption(pair *tree_sitter.Node, dep *GemDependency) {
+// extractPairOption extracts a single key-value pair option.
+// Parenthesized values such as require: ('foo' if cond) and Ruby 3.1 shorthand
+// keys such as require: (the value of a local variable) can't be resolved
+// statically; they are skipped and their key is returned.
+func (p *TreeSitterGemfileParser) extractPairOption(pair *tree_sitter.Node, dep *GemDependency) (skippedKey string) {
 	var key, value string
 	var arrayValues []string
 	hasArray := false
@@ -460,7 +721,12 @@ func (p *TreeSitterGemfileParser) extractPairOption(pair *tree_sitter.Node, dep
 				value = symbolValue
 			}
 		case nodeString:
-			value = p.helper.ExtractStringValue(child)
+			// The key comes first, so "require" => false has a string key
+			if j == 0 {
+				key = p.helper.ExtractStringValue(child)
+			} else {
+				value = p.helper.ExtractStringValue(child)
+			}
 		case falseValue, trueValue:
 			value = p.helper.GetNodeText(child)
 		case nodeArray:
@@ -470,6 +736,19 @@ func (p *TreeSitterGemfileParser) extractPairOption(pair *tree_sitter.Node, dep
 		}
 	}
 
+	// install_if takes arbitrary Ruby (usually a lambda), so keep its source text
+	if key == installIfKey && pair.ChildCount() > 0 {
+		dep.InstallIfExpr = p.helper.GetNodeText(pair.Child(pair.ChildCount() - 1))
+		return ""
+	}
+
+	if pair.ChildCount() > 0 {
+		switch pair.Child(pair.ChildCount() - 1).Kind() {
+		case nodeParenthesizedStatements, nodeHashKeySymbol, ":":
+			return key
+		}
+	}
+
 	// Handle array values
 	if hasArray {
 		switch key {
@@ -478,27 +757,37 @@ func (p *TreeSitterGemfileParser) extractPairOption(pair *tree_sitter.Node, dep
 		case groupsKey, groupMethod:
 			dep.Groups = arrayValues
 		}
-		return
+		return ""
 	}
 
 	// Apply scalar options
 	p.applyGemOption(key, value, dep)
+	return ""
 }
 
-// extractHashOptions extracts options from a hash node
-func (p *TreeSitterGemfileParser) extractHashOptions(hashNode *tree_sitter.Node, dep *GemDependency) {
+// extractHashOptions extracts options from a hash node, returning the keys of skipped options
+func (p *TreeSitterGemfileParser) extractHashOptions(hashNode *tree_sitter.Node, dep *GemDependency) []string {
+	var skipped []string
 	for i := uint(0); i < hashNode.ChildCount(); i++ {
 		pair := hashNode.Child(i)
 		if pair.Kind() == nodePair {
-			p.extractPairOption(pair, dep)
+			if key := p.extractPairOption(pair, dep); key != "" {
+				skipped = append(skipped, key)
+			}
 		}
 	}
+	return skipped
 }
 
 // applyGemOption applies a single gem option
 //
 //nolint:gocyclo // Switch statement with many gem options is acceptable
 func (p *TreeSitterGemfileParser) applyGemOption(key, value string, dep *GemDependency) {
+	// Options naming a registered git_source, e.g. gitlab: 'org/repo', act as git: with the expanded URL
+	if template, ok := p.gitSources[key]; ok {
+		key, value = gitKey, expandGitSource(template, value)
+	}
+
 	switch key {
 	case "require":
 		if value == falseValue {
@@ -516,8 +805,15 @@ func (p *TreeSitterGemfileParser) applyGemOption(key, value string, dep *GemDepe
 			dep.Groups = []string{value}
 		}
 	case gitKey, githubKey:
-		// Always create a new source for explicit git/github options
-		dep.Source = &Source{Type: gitKey}
+		// Always create a new source for explicit git/github options,
+		// keeping any branch/tag/ref that was given before the git option
+		source := &Source{Type: gitKey}
+		if dep.Source != nil && dep.Source.Type == gitKey {
+			source.Branch = dep.Source.Branch
+			source.Tag = dep.Source.Tag
+			source.Ref = dep.Source.Ref
+		}
+		dep.Source = source
 		if key == githubKey {
 			dep.Source.URL = fmt.Sprintf("https://github.com/%s.git", value)
 		} else {
@@ -550,21 +846,26 @@ func (p *TreeSitterGemfileParser) applyGemOption(key, value string, dep *GemDepe
 			dep.Source = &Source{Type: gitKey}
 		}
 		dep.Source.Ref = value
+	case forceRubyKey:
+		dep.ForceRubyPlatform = value == trueValue
 	}
 }
 
-// extractArraySymbols extracts symbol values from an array node
+// extractArraySymbols extracts symbol and string values from an array node
 func (p *TreeSitterGemfileParser) extractArraySymbols(arrayNode *tree_sitter.Node) []string {
 	var symbols []string
 
 	for i := uint(0); i < arrayNode.ChildCount(); i++ {
 		child := arrayNode.Child(i)
-		kind := child.Kind()
-		if kind == nodeSymbol || kind == nodeSimpleSymbol {
-			value := p.helper.ExtractSymbolValue(child)
-			if value != "" {
-				symbols = append(symbols, value)
-			}
+		var value string
+		switch child.Kind() {
+		case nodeSymbol, nodeSimpleSymbol:
+			value = p.helper.ExtractSymbolValue(child)
+		case nodeString:
+			value = p.helper.ExtractStringValue(child)
+		}
+		if value != "" {
+			symbols = append(symbols, value)
 		}
 	}
 
@@ -598,12 +899,3 @@ func (p *TreeSitterGemfileParser) processVariableAssignment(node *tree_sitter.No
 		p.variables[varName] = varValue
 	}
 }
-
-// expandVariable expands a variable reference to its value
-// Returns the value if the input is a variable name, otherwise returns the input unchanged
-func (p *TreeSitterGemfileParser) expandVariable(input string) string {
-	if value, exists := p.variables[input]; exists {
-		return value
-	}
-	return input
-}
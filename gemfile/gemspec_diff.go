@@ -0,0 +1,132 @@
+package gemfile
+
+import (
+	"slices"
+	"strings"
+)
+
+// GemspecDiff describes what changed between two versions of a gemspec
+type GemspecDiff struct {
+	OldVersion  string           // Version of the first gemspec
+	NewVersion  string           // Version of the second gemspec
+	Runtime     DependencyDiff   // Changes to runtime dependencies
+	Development DependencyDiff   // Changes to development dependencies
+	Metadata    []MetadataChange // Added, removed and changed metadata keys, sorted by key
+}
+
+// DependencyDiff lists added, removed and re-constrained dependencies
+type DependencyDiff struct {
+	Added   []GemDependency    // Dependencies only in the second gemspec
+	Removed []GemDependency    // Dependencies only in the first gemspec
+	Changed []DependencyChange // Dependencies whose constraints changed
+}
+
+// DependencyChange records the constraints of a dependency before and after
+type DependencyChange struct {
+	Name           string
+	OldConstraints []string
+	NewConstraints []string
+}
+
+// MetadataChange records a metadata value before and after.
+// OldValue is empty for added keys and NewValue is empty for removed keys.
+type MetadataChange struct {
+	Key      string
+	OldValue string
+	NewValue string
+}
+
+// VersionChanged reports whether the gem version differs
+func (d *GemspecDiff) VersionChanged() bool {
+	return d.OldVersion != d.NewVersion
+}
+
+// IsEmpty reports whether the two gemspecs have no version, dependency or metadata changes
+func (d *GemspecDiff) IsEmpty() bool {
+	return !d.VersionChanged() && d.Runtime.IsEmpty() && d.Development.IsEmpty() && len(d.Metadata) == 0
+}
+
+// IsEmpty reports whether no dependencies were added, removed or changed
+func (d *DependencyDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffGemspecs compares two gemspecs, typically two releases of the same gem.
+// Dependencies are reported in the order they appear in their gemspec.
+// A nil gemspec is treated as empty.
+func DiffGemspecs(a, b *GemspecFile) *GemspecDiff {
+	if a == nil {
+		a = &GemspecFile{}
+	}
+	if b == nil {
+		b = &GemspecFile{}
+	}
+
+	return &GemspecDiff{
+		OldVersion:  a.Version,
+		NewVersion:  b.Version,
+		Runtime:     diffDependencies(a.RuntimeDependencies, b.RuntimeDependencies),
+		Development: diffDependencies(a.DevelopmentDependencies, b.DevelopmentDependencies),
+		Metadata:    diffMetadata(a.Metadata, b.Metadata),
+	}
+}
+
+// diffDependencies compares two dependency lists by gem name
+func diffDependencies(oldDeps, newDeps []GemDependency) DependencyDiff {
+	var diff DependencyDiff
+
+	oldByName := make(map[string]GemDependency, len(oldDeps))
+	for _, dep := range oldDeps {
+		oldByName[dep.Name] = dep
+	}
+	newByName := make(map[string]GemDependency, len(newDeps))
+	for _, dep := range newDeps {
+		newByName[dep.Name] = dep
+	}
+
+	for _, dep := range oldDeps {
+		if _, ok := newByName[dep.Name]; !ok {
+			diff.Removed = append(diff.Removed, dep)
+		}
+	}
+
+	for _, dep := range newDeps {
+		old, ok := oldByName[dep.Name]
+		if !ok {
+			diff.Added = append(diff.Added, dep)
+			continue
+		}
+		if !slices.Equal(old.Constraints, dep.Constraints) {
+			diff.Changed = append(diff.Changed, DependencyChange{
+				Name:           dep.Name,
+				OldConstraints: old.Constraints,
+				NewConstraints: dep.Constraints,
+			})
+		}
+	}
+
+	return diff
+}
+
+// diffMetadata compares two metadata maps key by key
+func diffMetadata(oldMeta, newMeta map[string]string) []MetadataChange {
+	var changes []MetadataChange
+
+	for key, oldValue := range oldMeta {
+		newValue, ok := newMeta[key]
+		if !ok || newValue != oldValue {
+			changes = append(changes, MetadataChange{Key: key, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	for key, newValue := range newMeta {
+		if _, ok := oldMeta[key]; !ok {
+			changes = append(changes, MetadataChange{Key: key, NewValue: newValue})
+		}
+	}
+
+	slices.SortFunc(changes, func(x, y MetadataChange) int {
+		return strings.Compare(x.Key, y.Key)
+	})
+
+	return changes
+}
@@ -0,0 +1,84 @@
+package gemfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffGemspecs(t *testing.T) {
+	oldSpec := &GemspecFile{
+		Name:    "widget",
+		Version: "1.2.0",
+		RuntimeDependencies: []GemDependency{
+			{Name: "rack", Constraints: []string{"~> 2.0"}},
+			{Name: "json", Constraints: []string{">= 2.0"}},
+			{Name: "legacy_support"},
+		},
+		DevelopmentDependencies: []GemDependency{
+			{Name: "rspec", Constraints: []string{"~> 3.12"}},
+		},
+		Metadata: map[string]string{
+			"homepage_uri":    "https://example.com/widget",
+			"changelog_uri":   "https://example.com/widget/CHANGELOG.md",
+			"source_code_uri": "https://github.com/example/widget",
+		},
+	}
+	newSpec := &GemspecFile{
+		Name:    "widget",
+		Version: "2.0.0",
+		RuntimeDependencies: []GemDependency{
+			{Name: "rack", Constraints: []string{">= 2.2", "< 4"}},
+			{Name: "json", Constraints: []string{">= 2.0"}},
+			{Name: "zeitwerk", Constraints: []string{"~> 2.6"}},
+		},
+		DevelopmentDependencies: []GemDependency{
+			{Name: "rspec", Constraints: []string{"~> 3.12"}},
+			{Name: "rubocop"},
+		},
+		Metadata: map[string]string{
+			"homepage_uri":          "https://example.com/widget",
+			"source_code_uri":       "https://codeberg.org/example/widget",
+			"rubygems_mfa_required": "true",
+		},
+	}
+
+	diff := DiffGemspecs(oldSpec, newSpec)
+
+	if !diff.VersionChanged() || diff.OldVersion != "1.2.0" || diff.NewVersion != "2.0.0" {
+		t.Errorf("Expected version change 1.2.0 -> 2.0.0, got %q -> %q", diff.OldVersion, diff.NewVersion)
+	}
+
+	expectedRuntime := DependencyDiff{
+		Added:   []GemDependency{{Name: "zeitwerk", Constraints: []string{"~> 2.6"}}},
+		Removed: []GemDependency{{Name: "legacy_support"}},
+		Changed: []DependencyChange{
+			{Name: "rack", OldConstraints: []string{"~> 2.0"}, NewConstraints: []string{">= 2.2", "< 4"}},
+		},
+	}
+	if !reflect.DeepEqual(diff.Runtime, expectedRuntime) {
+		t.Errorf("Runtime diff = %+v, want %+v", diff.Runtime, expectedRuntime)
+	}
+
+	expectedDevelopment := DependencyDiff{
+		Added: []GemDependency{{Name: "rubocop"}},
+	}
+	if !reflect.DeepEqual(diff.Development, expectedDevelopment) {
+		t.Errorf("Development diff = %+v, want %+v", diff.Development, expectedDevelopment)
+	}
+
+	expectedMetadata := []MetadataChange{
+		{Key: "changelog_uri", OldValue: "https://example.com/widget/CHANGELOG.md"},
+		{Key: "rubygems_mfa_required", NewValue: "true"},
+		{Key: "source_code_uri", OldValue: "https://github.com/example/widget", NewValue: "https://codeberg.org/example/widget"},
+	}
+	if !reflect.DeepEqual(diff.Metadata, expectedMetadata) {
+		t.Errorf("Metadata diff = %+v, want %+v", diff.Metadata, expectedMetadata)
+	}
+
+	if diff.IsEmpty() {
+		t.Error("Expected diff to be non-empty")
+	}
+	if same := DiffGemspecs(oldSpec, oldSpec); !same.IsEmpty() {
+		t.Errorf("Expected no changes when comparing a gemspec with itself, got %+v", same)
+	}
+}
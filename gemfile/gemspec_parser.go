@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 )
@@ -42,9 +43,11 @@ type gemspecJSON struct {
 	Homepage                string            `json:"homepage"`
 	License                 string            `json:"license"`
 	Licenses                []string          `json:"licenses"`
+	Platform                string            `json:"platform"`
 	RequiredRubyVersion     string            `json:"required_ruby_version"`
 	Files                   []string          `json:"files"`
 	Metadata                map[string]string `json:"metadata"`
+	Requirements            []string          `json:"requirements"`
 	RuntimeDependencies     []dependencyJSON  `json:"runtime_dependencies"`
 	DevelopmentDependencies []dependencyJSON  `json:"development_dependencies"`
 }
@@ -86,6 +89,37 @@ func (p *GemspecParser) Parse() (*GemspecFile, error) {
 	return p.parseWithRuby()
 }
 
+// MFARequired reports whether the gem requires multi-factor authentication for
+// privileged RubyGems.org operations such as pushing new versions.
+// Ruby equivalent: spec.metadata["rubygems_mfa_required"] == "true"
+func (g *GemspecFile) MFARequired() bool {
+	return g.Metadata["rubygems_mfa_required"] == trueValue
+}
+
+// GroupByLicense maps each license to the names of the gems declaring it, in
+// the order the specs are given. Multi-license gems (stored comma-joined in
+// License) are listed under every license; gems without one are listed under "".
+func GroupByLicense(specs []*GemspecFile) map[string][]string {
+	byLicense := make(map[string][]string)
+	for _, spec := range specs {
+		if spec == nil {
+			continue
+		}
+		if strings.TrimSpace(spec.License) == "" {
+			byLicense[""] = append(byLicense[""], spec.Name)
+			continue
+		}
+		for _, license := range strings.Split(spec.License, ",") {
+			license = strings.TrimSpace(license)
+			if license == "" || slices.Contains(byLicense[license], spec.Name) {
+				continue
+			}
+			byLicense[license] = append(byLicense[license], spec.Name)
+		}
+	}
+	return byLicense
+}
+
 // parseWithRuby attempts to parse the gemspec using Ruby execution
 func (p *GemspecParser) parseWithRuby() (*GemspecFile, error) {
 	rubyScript := `
@@ -112,9 +146,11 @@ begin
     homepage: spec.homepage || "",
     license: spec.license || (spec.licenses.first if spec.licenses && !spec.licenses.empty?) || "",
     licenses: Array(spec.licenses),
+    platform: spec.platform.to_s,
     required_ruby_version: spec.required_ruby_version ? spec.required_ruby_version.to_s : "",
     files: spec.files || [],
     metadata: spec.metadata || {},
+    requirements: Array(spec.requirements),
     runtime_dependencies: spec.runtime_dependencies.map do |dep|
       {
         name: dep.name,
@@ -181,6 +217,8 @@ func (p *GemspecParser) convertJSONToGemspecFile(result *gemspecJSON) *GemspecFi
 		RequiredRubyVersion: result.RequiredRubyVersion,
 		Files:               result.Files,
 		Metadata:            result.Metadata,
+		Platform:            result.Platform,
+		Requirements:        result.Requirements,
 	}
 
 	// Convert runtime dependencies
@@ -221,27 +259,58 @@ func (p *GemspecParser) fallbackParse() (*GemspecFile, error) {
 	}
 
 	contentStr := string(content)
+	receiver := specReceiver(contentStr)
 
 	// Extract all gemspec fields
-	p.extractSimpleFields(contentStr, gemspec)
-	p.extractAuthors(contentStr, gemspec)
-	p.extractEmail(contentStr, gemspec)
-	p.extractDependencies(contentStr, gemspec)
-	p.extractMetadata(contentStr, gemspec)
+	p.extractSimpleFields(contentStr, receiver, gemspec)
+	p.extractAuthors(contentStr, receiver, gemspec)
+	p.extractEmail(contentStr, receiver, gemspec)
+	p.extractDependencies(contentStr, receiver, gemspec)
+	p.extractMetadata(contentStr, receiver, gemspec)
+	p.extractRequirements(contentStr, receiver, gemspec)
 
 	return gemspec, nil
 }
 
-// extractSimpleFields extracts simple string fields from gemspec content
-func (p *GemspecParser) extractSimpleFields(content string, gemspec *GemspecFile) {
+// specReceiverRe matches the variable the specification is bound to, either as
+// the block parameter of Gem::Specification.new or by assignment
+var specReceiverRe = regexp.MustCompile(
+	`Gem::Specification\.new\s*(?:\(\s*\))?\s*(?:do|\{)\s*\|\s*(\w+)\s*\||(?m:^\s*(\w+)\s*=\s*Gem::Specification\.new\b)`)
+
+// specReceiver returns the variable the gemspec configures the specification
+// through, e.g. s for Gem::Specification.new do |s|, defaulting to spec
+func specReceiver(content string) string {
+	if match := specReceiverRe.FindStringSubmatch(content); match != nil {
+		return match[1] + match[2]
+	}
+	return "spec"
+}
+
+// specPattern compiles pattern as an attribute of the specification variable,
+// so receiver.name = ... matches but an unrelated foo.name = ... does not
+func specPattern(receiver, pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`\b` + regexp.QuoteMeta(receiver) + `\.` + pattern)
+}
+
+// extractSimpleFields extracts simple string fields from gemspec content.
+// The patterns in this and the following extractors only match attributes of
+// the specification variable, so both |spec| and |s| style gemspecs are
+// recognized while helper objects are ignored.
+func (p *GemspecParser) extractSimpleFields(content, receiver string, gemspec *GemspecFile) {
 	patterns := map[string]*regexp.Regexp{
-		"name":                  regexp.MustCompile(`spec\.name\s*=\s*['"](.*?)['"]`),
-		"version":               regexp.MustCompile(`spec\.version\s*=\s*['"](.*?)['"]`),
-		"summary":               regexp.MustCompile(`spec\.summary\s*=\s*['"](.*?)['"]`),
-		"description":           regexp.MustCompile(`spec\.description\s*=\s*['"](.*?)['"]`),
-		"homepage":              regexp.MustCompile(`spec\.homepage\s*=\s*['"](.*?)['"]`),
-		"license":               regexp.MustCompile(`spec\.licenses?\s*=\s*['"](.*?)['"]`),
-		"required_ruby_version": regexp.MustCompile(`spec\.required_ruby_version\s*=\s*['"](.*?)['"]`),
+		"name":                  specPattern(receiver, `name\s*=\s*['"](.*?)['"]`),
+		"version":               specPattern(receiver, `version\s*=\s*['"](.*?)['"]`),
+		"summary":               specPattern(receiver, `summary\s*=\s*['"](.*?)['"]`),
+		"description":           specPattern(receiver, `description\s*=\s*['"](.*?)['"]`),
+		"homepage":              specPattern(receiver, `homepage\s*=\s*['"](.*?)['"]`),
+		"license":               specPattern(receiver, `licenses?\s*=\s*['"](.*?)['"]`),
+		"required_ruby_version": specPattern(receiver, `required_ruby_version\s*=\s*['"](.*?)['"]`),
+		// Fallback forms: a wrapped or constant version, a list of Ruby version
+		// constraints and a platform string or constant
+		"wrapped_version":        specPattern(receiver, `version\s*=\s*Gem::Version\.new\(\s*['"](.*?)['"]\s*\)`),
+		"constant_version":       specPattern(receiver, `version\s*=\s*([\w:]+)`),
+		"required_ruby_versions": specPattern(receiver, `required_ruby_version\s*=\s*(?:\[|Gem::Requirement\.new\()(.*?)[\])]`),
+		"platform":               specPattern(receiver, `platform\s*=\s*(?:['"](.*?)['"]|([\w:]+))`),
 	}
 
 	if match := patterns["name"].FindStringSubmatch(content); len(match) > 1 {
@@ -249,7 +318,9 @@ func (p *GemspecParser) extractSimpleFields(content string, gemspec *GemspecFile
 	}
 	if match := patterns["version"].FindStringSubmatch(content); len(match) > 1 {
 		gemspec.Version = match[1]
-	} else if match := regexp.MustCompile(`spec\.version\s*=\s*([\w:]+)`).FindStringSubmatch(content); len(match) > 1 {
+	} else if match := patterns["wrapped_version"].FindStringSubmatch(content); len(match) > 1 {
+		gemspec.Version = match[1]
+	} else if match := patterns["constant_version"].FindStringSubmatch(content); len(match) > 1 {
 		gemspec.Version = match[1]
 	}
 	if match := patterns["summary"].FindStringSubmatch(content); len(match) > 1 {
@@ -266,37 +337,70 @@ func (p *GemspecParser) extractSimpleFields(content string, gemspec *GemspecFile
 	}
 	if match := patterns["required_ruby_version"].FindStringSubmatch(content); len(match) > 1 {
 		gemspec.RequiredRubyVersion = match[1]
+	} else if match := patterns["required_ruby_versions"].FindStringSubmatch(content); len(match) > 1 {
+		// Array or Gem::Requirement form with several constraints
+		gemspec.RequiredRubyVersion = strings.Join(parseQuotedArray(match[1]), ", ")
+	}
+	if match := patterns["platform"].FindStringSubmatch(content); len(match) > 2 {
+		gemspec.Platform = normalizeGemspecPlatform(match[1] + match[2])
+	}
+}
+
+// normalizeGemspecPlatform converts Gem::Platform constants to their platform strings
+// Ruby equivalent: spec.platform.to_s
+func normalizeGemspecPlatform(platform string) string {
+	switch platform {
+	case "Gem::Platform::RUBY":
+		return "ruby"
+	case "Gem::Platform::CURRENT":
+		return "current"
 	}
+	return platform
 }
 
 // extractAuthors extracts author information from gemspec content
-func (p *GemspecParser) extractAuthors(content string, gemspec *GemspecFile) {
-	if match := regexp.MustCompile(`spec\.authors?\s*=\s*\[(.*?)\]`).FindStringSubmatch(content); len(match) > 1 {
+func (p *GemspecParser) extractAuthors(content, receiver string, gemspec *GemspecFile) {
+	if match := specPattern(receiver, `authors?\s*=\s*\[(.*?)\]`).FindStringSubmatch(content); len(match) > 1 {
 		gemspec.Authors = parseQuotedArray(match[1])
-	} else if match := regexp.MustCompile(`spec\.authors?\s*=\s*['"](.*?)['"]`).FindStringSubmatch(content); len(match) > 1 {
+	} else if match := specPattern(receiver, `authors?\s*=\s*['"](.*?)['"]`).FindStringSubmatch(content); len(match) > 1 {
 		gemspec.Authors = []string{match[1]}
 	}
 }
 
 // extractEmail extracts email information from gemspec content
-func (p *GemspecParser) extractEmail(content string, gemspec *GemspecFile) {
-	if match := regexp.MustCompile(`spec\.email\s*=\s*\[(.*?)\]`).FindStringSubmatch(content); len(match) > 1 {
+func (p *GemspecParser) extractEmail(content, receiver string, gemspec *GemspecFile) {
+	if match := specPattern(receiver, `email\s*=\s*\[(.*?)\]`).FindStringSubmatch(content); len(match) > 1 {
 		gemspec.Email = parseQuotedArray(match[1])
-	} else if match := regexp.MustCompile(`spec\.email\s*=\s*['"](.*?)['"]`).FindStringSubmatch(content); len(match) > 1 {
+	} else if match := specPattern(receiver, `email\s*=\s*['"](.*?)['"]`).FindStringSubmatch(content); len(match) > 1 {
 		gemspec.Email = []string{match[1]}
 	}
 }
 
 // extractDependencies extracts runtime and development dependencies from gemspec content
-func (p *GemspecParser) extractDependencies(content string, gemspec *GemspecFile) {
-	depPattern := regexp.MustCompile(`spec\.add_(?:(runtime|development)_)?dependency\s*\(?\s*['"]([\w\-]+)['"]([^)]*)\)?`)
+func (p *GemspecParser) extractDependencies(content, receiver string, gemspec *GemspecFile) {
+	// Gem names may be given through a constant assigned a string literal
+	constants := make(map[string]string)
+	constantPattern := regexp.MustCompile(`(?m)^\s*([A-Z]\w*)\s*=\s*['"]([^'"]+)['"]\s*$`)
+	for _, match := range constantPattern.FindAllStringSubmatch(content, -1) {
+		constants[match[1]] = match[2]
+	}
+
+	depPattern := specPattern(receiver, `add_(?:(runtime|development)_)?dependency\s*\(?\s*(?:['"]([\w\-]+)['"]|([A-Z]\w*))([^)\n]*)\)?`)
 	depMatches := depPattern.FindAllStringSubmatch(content, -1)
 
 	for _, match := range depMatches {
-		if len(match) >= 3 {
+		if len(match) >= 5 {
+			name := match[2]
+			if match[3] != "" {
+				name = constants[match[3]]
+			}
+			if name == "" {
+				continue
+			}
+
 			dep := GemDependency{
-				Name:        match[2],
-				Constraints: extractVersionConstraints(match[3]),
+				Name:        name,
+				Constraints: extractVersionConstraints(match[4]),
 			}
 
 			if match[1] == developmentGroup {
@@ -309,8 +413,8 @@ func (p *GemspecParser) extractDependencies(content string, gemspec *GemspecFile
 }
 
 // extractMetadata extracts metadata from gemspec content
-func (p *GemspecParser) extractMetadata(content string, gemspec *GemspecFile) {
-	metadataPattern := regexp.MustCompile(`spec\.metadata\[['"](.*?)['"]\]\s*=\s*['"](.*?)['"]`)
+func (p *GemspecParser) extractMetadata(content, receiver string, gemspec *GemspecFile) {
+	metadataPattern := specPattern(receiver, `metadata\[['"](.*?)['"]\]\s*=\s*['"](.*?)['"]`)
 	metadataMatches := metadataPattern.FindAllStringSubmatch(content, -1)
 	for _, match := range metadataMatches {
 		if len(match) > 2 {
@@ -319,6 +423,18 @@ func (p *GemspecParser) extractMetadata(content string, gemspec *GemspecFile) {
 	}
 }
 
+// extractRequirements extracts external requirements, assigned as an array,
+// possibly spread over several lines, or appended one at a time with
+// spec.requirements << "libmagic"
+func (p *GemspecParser) extractRequirements(content, receiver string, gemspec *GemspecFile) {
+	if match := specPattern(receiver, `requirements\s*=\s*\[((?s:.*?))\]`).FindStringSubmatch(content); len(match) > 1 {
+		gemspec.Requirements = parseQuotedArray(match[1])
+	}
+	for _, match := range specPattern(receiver, `requirements((?:\s*<<\s*['"][^'"]*['"])+)`).FindAllStringSubmatch(content, -1) {
+		gemspec.Requirements = append(gemspec.Requirements, parseQuotedArray(match[1])...)
+	}
+}
+
 // parseQuotedArray parses an array of quoted strings
 func parseQuotedArray(arrayContent string) []string {
 	var result []string
@@ -419,8 +535,8 @@ func LoadGemspecDependencies(gemspecRef GemspecReference, gemfileDir string) ([]
 	searchPath := gemspecRef.Path
 	if searchPath == "" {
 		searchPath = gemfileDir
-	} else if !filepath.IsAbs(searchPath) {
-		searchPath = filepath.Join(gemfileDir, searchPath)
+	} else {
+		searchPath = resolvePath(gemfileDir, searchPath)
 	}
 
 	gemspecs, err := FindGemspecs(searchPath, gemspecRef.Glob, gemspecRef.Name)
@@ -449,6 +565,7 @@ func LoadGemspecDependencies(gemspecRef GemspecReference, gemfileDir string) ([]
 	for _, dep := range gemspecFile.RuntimeDependencies {
 		// Runtime deps go to default group
 		dep.Groups = []string{"default"}
+		dep.FromGemspec = true
 		dependencies = append(dependencies, dep)
 	}
 
@@ -458,8 +575,15 @@ func LoadGemspecDependencies(gemspecRef GemspecReference, gemfileDir string) ([]
 		devGroup = developmentGroup
 	}
 
+	devGroups := []string{devGroup}
+	if gemspecRef.NameGroup && gemspecFile.Name != "" && !slices.Contains(devGroups, gemspecFile.Name) {
+		// Lets callers tell apart dev deps coming from different gemspecs
+		devGroups = append(devGroups, gemspecFile.Name)
+	}
+
 	for _, dep := range gemspecFile.DevelopmentDependencies {
-		dep.Groups = []string{devGroup}
+		dep.Groups = slices.Clone(devGroups)
+		dep.FromGemspec = true
 		dependencies = append(dependencies, dep)
 	}
 
@@ -471,9 +595,40 @@ func LoadGemspecDependencies(gemspecRef GemspecReference, gemfileDir string) ([]
 			Type: "path",
 			URL:  gemPath,
 		},
-		Groups: []string{"default"},
+		Groups:      []string{"default"},
+		Require:     gemspecRef.Require,
+		FromGemspec: true,
 	}
 	dependencies = append([]GemDependency{selfDep}, dependencies...)
+	reconcileGemspecGroups(dependencies)
 
 	return dependencies, nil
 }
+
+// reconcileGemspecGroups gives every entry of a gem loaded from a gemspec the
+// union of the groups of all its entries, without duplicates. This covers a
+// gem that is both in a Gemfile group :test block and a gemspec development
+// dependency with development_group: :test, and a gemspec listing the same gem
+// as runtime and development dependency. Entries are kept, so their
+// constraints can still be compared; gems only declared in the Gemfile are left as is.
+func reconcileGemspecGroups(deps []GemDependency) {
+	groups := make(map[string][]string)
+	fromGemspec := make(map[string]bool)
+	for i := range deps {
+		dep := &deps[i]
+		for _, group := range dep.Groups {
+			if !slices.Contains(groups[dep.Name], group) {
+				groups[dep.Name] = append(groups[dep.Name], group)
+			}
+		}
+		if dep.FromGemspec {
+			fromGemspec[dep.Name] = true
+		}
+	}
+
+	for i := range deps {
+		if dep := &deps[i]; fromGemspec[dep.Name] {
+			dep.Groups = slices.Clone(groups[dep.Name])
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package gemfile
 
 import (
+	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -118,6 +120,340 @@ func TestGemspecParser(t *testing.T) {
 	}
 }
 
+// gemspecFixture returns the path of a gemspec fixture kept in its own gem
+// directory, out of reach of the default glob run over testdata
+func gemspecFixture(name string) string {
+	return filepath.Join("..", "testdata", "gemspecs", name, name+".gemspec")
+}
+
+func TestGemspecPlatform(t *testing.T) {
+	gemspecPath := gemspecFixture("platform_gem")
+	parser := NewGemspecParser(gemspecPath)
+	gemspec, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse gemspec: %v", err)
+	}
+
+	if gemspec.Name != "platform_gem" {
+		t.Errorf("Expected name 'platform_gem', got %s", gemspec.Name)
+	}
+	if gemspec.Platform != "java" {
+		t.Errorf("Expected platform 'java', got %q", gemspec.Platform)
+	}
+
+	// Gem::Platform constants are normalized to their platform strings
+	content := []byte(`Gem::Specification.new do |spec|
+  spec.name = "pure_gem"
+  spec.platform = Gem::Platform::RUBY
+end`)
+	tsGemspec, err := NewTreeSitterGemspecParser(content).ParseWithTreeSitter()
+	if err != nil {
+		t.Fatalf("ParseWithTreeSitter failed: %v", err)
+	}
+	if tsGemspec.Platform != "ruby" {
+		t.Errorf("Expected platform 'ruby', got %q", tsGemspec.Platform)
+	}
+}
+
+func TestGemspecFallbackParseShortVariable(t *testing.T) {
+	parser := NewGemspecParser(gemspecFixture("short_var"))
+	gemspec, err := parser.fallbackParse()
+	if err != nil {
+		t.Fatalf("fallbackParse failed: %v", err)
+	}
+
+	if gemspec.Name != "short_var" {
+		t.Errorf("Expected name 'short_var', got %q", gemspec.Name)
+	}
+	if gemspec.Version != "0.3.0" {
+		t.Errorf("Expected version '0.3.0', got %q", gemspec.Version)
+	}
+	if !reflect.DeepEqual(gemspec.Authors, []string{"Short Var Dev"}) {
+		t.Errorf("Expected authors [Short Var Dev], got %v", gemspec.Authors)
+	}
+	if gemspec.RequiredRubyVersion != ">= 3.1" {
+		t.Errorf("Expected required ruby version '>= 3.1', got %q", gemspec.RequiredRubyVersion)
+	}
+	if gemspec.Metadata["source_code_uri"] != "https://github.com/example/short_var" {
+		t.Errorf("Expected source_code_uri metadata, got %v", gemspec.Metadata)
+	}
+	if len(gemspec.RuntimeDependencies) != 1 || gemspec.RuntimeDependencies[0].Name != "zeitwerk" {
+		t.Errorf("Expected runtime dependency zeitwerk, got %+v", gemspec.RuntimeDependencies)
+	}
+	if len(gemspec.DevelopmentDependencies) != 1 || gemspec.DevelopmentDependencies[0].Name != "minitest" {
+		t.Errorf("Expected development dependency minitest, got %+v", gemspec.DevelopmentDependencies)
+	}
+}
+
+func TestGemspecRequiredRubyVersionList(t *testing.T) {
+	gemspecPath := gemspecFixture("ruby_range")
+
+	gemspec, err := NewGemspecParser(gemspecPath).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse gemspec: %v", err)
+	}
+	if gemspec.RequiredRubyVersion != ">= 2.6, < 3.2" {
+		t.Errorf("Expected required ruby version '>= 2.6, < 3.2', got %q", gemspec.RequiredRubyVersion)
+	}
+
+	t.Run("regex fallback", func(t *testing.T) {
+		fallback, err := NewGemspecParser(gemspecPath).fallbackParse()
+		if err != nil {
+			t.Fatalf("fallbackParse failed: %v", err)
+		}
+		if fallback.RequiredRubyVersion != ">= 2.6, < 3.2" {
+			t.Errorf("Expected required ruby version '>= 2.6, < 3.2', got %q", fallback.RequiredRubyVersion)
+		}
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		content := []byte(`Gem::Specification.new do |spec|
+  spec.name = "requirement_gem"
+  spec.required_ruby_version = Gem::Requirement.new(">= 3.0", "< 4.0")
+end`)
+		tsGemspec, err := NewTreeSitterGemspecParser(content).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		if tsGemspec.RequiredRubyVersion != ">= 3.0, < 4.0" {
+			t.Errorf("Expected required ruby version '>= 3.0, < 4.0', got %q", tsGemspec.RequiredRubyVersion)
+		}
+	})
+}
+
+func TestGemspecConstantDependencyNames(t *testing.T) {
+	gemspecPath := gemspecFixture("constant_deps")
+
+	check := func(t *testing.T, gemspec *GemspecFile) {
+		t.Helper()
+
+		runtime := []GemDependency{
+			{Name: "rack", Constraints: []string{"~> 3.0"}},
+			{Name: "json"},
+		}
+		if len(gemspec.RuntimeDependencies) != len(runtime) {
+			t.Fatalf("Expected %d runtime dependencies, got %+v", len(runtime), gemspec.RuntimeDependencies)
+		}
+		for i, want := range runtime {
+			got := gemspec.RuntimeDependencies[i]
+			if got.Name != want.Name || strings.Join(got.Constraints, ", ") != strings.Join(want.Constraints, ", ") {
+				t.Errorf("Expected runtime dependency %+v, got %+v", want, got)
+			}
+		}
+
+		if len(gemspec.DevelopmentDependencies) != 1 || gemspec.DevelopmentDependencies[0].Name != "rspec" {
+			t.Fatalf("Expected rspec development dependency, got %+v", gemspec.DevelopmentDependencies)
+		}
+		if constraints := gemspec.DevelopmentDependencies[0].Constraints; len(constraints) != 1 || constraints[0] != ">= 3.12" {
+			t.Errorf("Expected rspec constraint '>= 3.12', got %v", constraints)
+		}
+	}
+
+	t.Run("regex fallback", func(t *testing.T) {
+		fallback, err := NewGemspecParser(gemspecPath).fallbackParse()
+		if err != nil {
+			t.Fatalf("fallbackParse failed: %v", err)
+		}
+		check(t, fallback)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		content, err := os.ReadFile(gemspecPath)
+		if err != nil {
+			t.Fatalf("Failed to read gemspec: %v", err)
+		}
+		tsGemspec, err := NewTreeSitterGemspecParser(content).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, tsGemspec)
+	})
+}
+
+func TestGemspecRequirements(t *testing.T) {
+	gemspecPath := gemspecFixture("system_requirements")
+	expected := []string{"libmagic, v5.0 or greater", "ImageMagick", "a working C compiler"}
+
+	check := func(t *testing.T, gemspec *GemspecFile) {
+		t.Helper()
+
+		if !reflect.DeepEqual(gemspec.Requirements, expected) {
+			t.Errorf("Expected requirements %q, got %q", expected, gemspec.Requirements)
+		}
+		if len(gemspec.RuntimeDependencies) != 1 || gemspec.RuntimeDependencies[0].Name != "ffi" {
+			t.Errorf("Expected runtime dependency ffi, got %+v", gemspec.RuntimeDependencies)
+		}
+	}
+
+	t.Run("regex fallback", func(t *testing.T) {
+		fallback, err := NewGemspecParser(gemspecPath).fallbackParse()
+		if err != nil {
+			t.Fatalf("fallbackParse failed: %v", err)
+		}
+		check(t, fallback)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		content, err := os.ReadFile(gemspecPath)
+		if err != nil {
+			t.Fatalf("Failed to read gemspec: %v", err)
+		}
+		tsGemspec, err := NewTreeSitterGemspecParser(content).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, tsGemspec)
+
+		assigned, err := NewTreeSitterGemspecParser([]byte(`Gem::Specification.new do |spec|
+  spec.name = "assigned"
+  spec.requirements = ["libpq"]
+end`)).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		if !reflect.DeepEqual(assigned.Requirements, []string{"libpq"}) {
+			t.Errorf("Expected requirements [libpq], got %q", assigned.Requirements)
+		}
+	})
+}
+
+func TestGemspecMultilineRequirements(t *testing.T) {
+	gemspecPath := gemspecFixture("multiline_requirements")
+	expected := []string{"libvips 8.10 or greater", "pkg-config"}
+
+	fallback, err := NewGemspecParser(gemspecPath).fallbackParse()
+	if err != nil {
+		t.Fatalf("fallbackParse failed: %v", err)
+	}
+	if !reflect.DeepEqual(fallback.Requirements, expected) {
+		t.Errorf("regex fallback: expected requirements %q, got %q", expected, fallback.Requirements)
+	}
+
+	content, err := os.ReadFile(gemspecPath)
+	if err != nil {
+		t.Fatalf("Failed to read gemspec: %v", err)
+	}
+	tsGemspec, err := NewTreeSitterGemspecParser(content).ParseWithTreeSitter()
+	if err != nil {
+		t.Fatalf("ParseWithTreeSitter failed: %v", err)
+	}
+	if !reflect.DeepEqual(tsGemspec.Requirements, expected) {
+		t.Errorf("tree-sitter: expected requirements %q, got %q", expected, tsGemspec.Requirements)
+	}
+}
+
+func TestGemspecAssignedSpecification(t *testing.T) {
+	content, err := os.ReadFile(gemspecFixture("assigned_spec"))
+	if err != nil {
+		t.Fatalf("Failed to read gemspec: %v", err)
+	}
+
+	// Parsed without the Ruby interpreter, which used to be the only way to read this form
+	gemspec, err := NewTreeSitterGemspecParser(content).ParseWithTreeSitter()
+	if err != nil {
+		t.Fatalf("ParseWithTreeSitter failed: %v", err)
+	}
+
+	if gemspec.Name != "assigned_spec" || gemspec.Version != "0.3.1" {
+		t.Errorf("Expected assigned_spec 0.3.1, got %s %s", gemspec.Name, gemspec.Version)
+	}
+	if !reflect.DeepEqual(gemspec.Authors, []string{"Assign Dev"}) {
+		t.Errorf("Expected authors [Assign Dev], got %v", gemspec.Authors)
+	}
+	if gemspec.Metadata["source_code_uri"] != "https://github.com/example/assigned_spec" {
+		t.Errorf("Expected source_code_uri metadata, got %v", gemspec.Metadata)
+	}
+	if len(gemspec.RuntimeDependencies) != 1 || gemspec.RuntimeDependencies[0].Name != "rack" {
+		t.Errorf("Expected runtime dependency rack, got %+v", gemspec.RuntimeDependencies)
+	}
+	if len(gemspec.DevelopmentDependencies) != 1 || gemspec.DevelopmentDependencies[0].Name != "rake" {
+		t.Errorf("Expected development dependency rake, got %+v", gemspec.DevelopmentDependencies)
+	}
+}
+
+func TestGemspecWrappedVersion(t *testing.T) {
+	gemspecPath := gemspecFixture("wrapped_version")
+
+	gemspec, err := NewGemspecParser(gemspecPath).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse gemspec: %v", err)
+	}
+	if gemspec.Version != "1.0.0" {
+		t.Errorf("Expected version 1.0.0, got %q", gemspec.Version)
+	}
+
+	t.Run("regex fallback", func(t *testing.T) {
+		fallback, err := NewGemspecParser(gemspecPath).fallbackParse()
+		if err != nil {
+			t.Fatalf("fallbackParse failed: %v", err)
+		}
+		if fallback.Version != "1.0.0" {
+			t.Errorf("Expected version 1.0.0, got %q", fallback.Version)
+		}
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		content, err := os.ReadFile(gemspecPath)
+		if err != nil {
+			t.Fatalf("Failed to read gemspec: %v", err)
+		}
+		tsGemspec, err := NewTreeSitterGemspecParser(content).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		if tsGemspec.Version != "1.0.0" {
+			t.Errorf("Expected version 1.0.0, got %q", tsGemspec.Version)
+		}
+	})
+}
+
+func TestGemspecMFARequired(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected bool
+	}{
+		{gemspecFixture("platform_gem"), true},
+		{filepath.Join("..", "testdata", "test_gem.gemspec"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(filepath.Base(tt.fixture), func(t *testing.T) {
+			gemspec, err := NewGemspecParser(tt.fixture).Parse()
+			if err != nil {
+				t.Fatalf("Failed to parse gemspec: %v", err)
+			}
+			if got := gemspec.MFARequired(); got != tt.expected {
+				t.Errorf("Expected MFARequired() = %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if (&GemspecFile{}).MFARequired() {
+		t.Error("Expected MFARequired() = false for gemspec without metadata")
+	}
+}
+
+func TestGroupByLicense(t *testing.T) {
+	specs := []*GemspecFile{
+		{Name: "rack", License: "MIT"},
+		{Name: "json", License: "Ruby, BSD-2-Clause"},
+		{Name: "nokogiri", License: "MIT"},
+		{Name: "mystery"},
+		nil,
+	}
+
+	expected := map[string][]string{
+		"MIT":          {"rack", "nokogiri"},
+		"Ruby":         {"json"},
+		"BSD-2-Clause": {"json"},
+		"":             {"mystery"},
+	}
+
+	if got := GroupByLicense(specs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("GroupByLicense() = %v, want %v", got, expected)
+	}
+}
+
 func TestParseGemspecDirective(t *testing.T) {
 	parser := NewGemfileParser("test.gemfile")
 
@@ -371,6 +707,226 @@ func TestLoadGemspecDependencies(t *testing.T) {
 	}
 }
 
+func TestLoadGemspecDependenciesNameGroup(t *testing.T) {
+	dir := t.TempDir()
+	gemspecContent := `Gem::Specification.new do |spec|
+  spec.name = "payment_core"
+  spec.version = "1.0.0"
+  spec.add_dependency("money", "~> 6.0")
+  spec.add_development_dependency("rspec", "~> 3.0")
+end
+`
+	if err := os.WriteFile(filepath.Join(dir, "payment_core.gemspec"), []byte(gemspecContent), 0600); err != nil {
+		t.Fatalf("Failed to write gemspec: %v", err)
+	}
+
+	groupsFor := func(deps []GemDependency, name string) []string {
+		for _, dep := range deps {
+			if dep.Name == name {
+				return dep.Groups
+			}
+		}
+		t.Fatalf("Expected to find %q in dependencies", name)
+		return nil
+	}
+
+	deps, err := LoadGemspecDependencies(GemspecReference{NameGroup: true}, dir)
+	if err != nil {
+		t.Fatalf("Failed to load gemspec dependencies: %v", err)
+	}
+	if groups := groupsFor(deps, "rspec"); !reflect.DeepEqual(groups, []string{"development", "payment_core"}) {
+		t.Errorf("Expected rspec groups [development payment_core], got %v", groups)
+	}
+	if groups := groupsFor(deps, "money"); !reflect.DeepEqual(groups, []string{"default"}) {
+		t.Errorf("Expected money groups [default], got %v", groups)
+	}
+
+	deps, err = LoadGemspecDependencies(GemspecReference{}, dir)
+	if err != nil {
+		t.Fatalf("Failed to load gemspec dependencies: %v", err)
+	}
+	if groups := groupsFor(deps, "rspec"); !reflect.DeepEqual(groups, []string{"development"}) {
+		t.Errorf("Expected rspec groups [development] by default, got %v", groups)
+	}
+}
+
+func TestLoadGemspecDependenciesRequire(t *testing.T) {
+	dir := t.TempDir()
+	gemspecContent := `Gem::Specification.new do |spec|
+  spec.name = "cli_tool"
+  spec.version = "0.3.0"
+  spec.add_dependency("thor", "~> 1.2")
+end
+`
+	if err := os.WriteFile(filepath.Join(dir, "cli_tool.gemspec"), []byte(gemspecContent), 0600); err != nil {
+		t.Fatalf("Failed to write gemspec: %v", err)
+	}
+
+	noRequire := ""
+	tests := []struct {
+		name    string
+		require *string
+	}{
+		{"default", nil},
+		{"require false", &noRequire},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, err := LoadGemspecDependencies(GemspecReference{Require: tt.require}, dir)
+			if err != nil {
+				t.Fatalf("Failed to load gemspec dependencies: %v", err)
+			}
+			self := deps[0]
+			if self.Name != "cli_tool" {
+				t.Fatalf("Expected the gem itself first, got %s", self.Name)
+			}
+			if !reflect.DeepEqual(self.Require, tt.require) {
+				t.Errorf("Expected self dependency require %v, got %v", tt.require, self.Require)
+			}
+			if thor := deps[1]; thor.Require != nil {
+				t.Errorf("Expected runtime dependency require to be unset, got %q", *thor.Require)
+			}
+		})
+	}
+
+	t.Run("gemspec directive", func(t *testing.T) {
+		parser := &GemfileParser{}
+		ref := parser.parseGemspecDirective(`gemspec name: "cli_tool", require: false`)
+		if ref.Require == nil || *ref.Require != "" {
+			t.Errorf("Expected require: false to be parsed, got %v", ref.Require)
+		}
+		if line := (&GemfileWriter{}).formatGemspecDirective(ref); line != `gemspec name: 'cli_tool', require: false` {
+			t.Errorf("Unexpected formatted directive: %s", line)
+		}
+		pathRef := parser.parseGemspecDirective(`gemspec path: "engines/core", require: false`)
+		if line := (&GemfileWriter{}).formatGemspecDirective(pathRef); line != `gemspec path: 'engines/core', require: false` {
+			t.Errorf("Unexpected formatted path directive: %s", line)
+		}
+		if ref := parser.parseGemspecDirective(`gemspec`); ref.Require != nil {
+			t.Errorf("Expected no require option by default, got %q", *ref.Require)
+		}
+	})
+}
+
+func TestGemspecBeforeSource(t *testing.T) {
+	dir := t.TempDir()
+	gemspecContent := `Gem::Specification.new do |spec|
+  spec.name = "early_gemspec"
+  spec.version = "1.0.0"
+  spec.add_dependency "zeitwerk", "~> 2.6"
+  spec.add_development_dependency "minitest", "~> 5.0"
+end
+`
+	gemfileContent := `gemspec
+source 'https://rubygems.org'
+
+gem 'rake', '~> 13.0'
+
+group :test do
+  gem 'rspec'
+end
+`
+	gemfilePath := filepath.Join(dir, "Gemfile")
+	if err := os.WriteFile(filepath.Join(dir, "early_gemspec.gemspec"), []byte(gemspecContent), 0600); err != nil {
+		t.Fatalf("Failed to write gemspec: %v", err)
+	}
+	if err := os.WriteFile(gemfilePath, []byte(gemfileContent), 0600); err != nil {
+		t.Fatalf("Failed to write Gemfile: %v", err)
+	}
+
+	parsed, err := NewGemfileParser(gemfilePath).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse Gemfile: %v", err)
+	}
+	if len(parsed.Gemspecs) != 1 || len(parsed.Sources) != 1 {
+		t.Fatalf("Expected 1 gemspec and 1 source, got %+v", parsed)
+	}
+
+	dependencyNames := func(deps []GemDependency) []string {
+		var names []string
+		for _, dep := range deps {
+			names = append(names, dep.Name)
+		}
+		return names
+	}
+	expected := []string{"early_gemspec", "zeitwerk", "minitest", "rake", "rspec"}
+	if names := dependencyNames(parsed.Dependencies); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected dependencies %v, got %v", expected, names)
+	}
+
+	// Adding a gem goes after the last top-level gem, not next to the gemspec line
+	if err := NewGemfileWriter(gemfilePath).AddGem(&GemDependency{Name: "puma", Groups: []string{defaultGroup}}); err != nil {
+		t.Fatalf("AddGem failed: %v", err)
+	}
+	content, err := os.ReadFile(gemfilePath)
+	if err != nil {
+		t.Fatalf("Failed to read Gemfile: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "gemspec\nsource 'https://rubygems.org'\n\ngem 'rake', '~> 13.0'\ngem 'puma'\n") {
+		t.Errorf("Unexpected Gemfile after AddGem:\n%s", content)
+	}
+
+	// Round trip through the writer
+	roundTripPath := filepath.Join(dir, "Gemfile.roundtrip")
+	if err := WriteGemfile(roundTripPath, parsed); err != nil {
+		t.Fatalf("WriteGemfile failed: %v", err)
+	}
+	reparsed, err := NewGemfileParser(roundTripPath).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse written Gemfile: %v", err)
+	}
+	if !reflect.DeepEqual(reparsed.Gemspecs, parsed.Gemspecs) || !reflect.DeepEqual(reparsed.Sources, parsed.Sources) {
+		t.Errorf("Round trip mismatch:\n got: %+v\nwant: %+v", reparsed, parsed)
+	}
+	if names := dependencyNames(reparsed.Dependencies); !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected dependencies %v after round trip, got %v", expected, names)
+	}
+}
+
+func TestGemspecDevelopmentGroupReconciled(t *testing.T) {
+	dir := t.TempDir()
+	gemspecContent := `Gem::Specification.new do |spec|
+  spec.name = "tested_gem"
+  spec.version = "1.0.0"
+  spec.add_dependency "rake", ">= 12"
+  spec.add_development_dependency "rake", "~> 13.0"
+  spec.add_development_dependency "rspec", "~> 3.0"
+end
+`
+	gemfileContent := `source 'https://rubygems.org'
+
+group :test do
+  gem 'rspec'
+end
+
+gemspec development_group: :test
+`
+	gemfilePath := filepath.Join(dir, "Gemfile")
+	if err := os.WriteFile(filepath.Join(dir, "tested_gem.gemspec"), []byte(gemspecContent), 0600); err != nil {
+		t.Fatalf("Failed to write gemspec: %v", err)
+	}
+	if err := os.WriteFile(gemfilePath, []byte(gemfileContent), 0600); err != nil {
+		t.Fatalf("Failed to write Gemfile: %v", err)
+	}
+
+	parsed, err := NewGemfileParser(gemfilePath).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse Gemfile: %v", err)
+	}
+
+	expected := map[string][]string{
+		"tested_gem": {"default"},
+		"rake":       {"default", "test"},
+		"rspec":      {"test"},
+	}
+	for _, dep := range parsed.Dependencies {
+		if groups := expected[dep.Name]; !reflect.DeepEqual(dep.Groups, groups) {
+			t.Errorf("Expected %s (from gemspec: %v) groups %v, got %v", dep.Name, dep.FromGemspec, groups, dep.Groups)
+		}
+	}
+}
+
 func TestGemfileWithGemspecDirective(t *testing.T) {
 	// Test parsing a Gemfile that contains a gemspec directive
 	gemfilePath := filepath.Join("..", "testdata", "gemspec_test_gemfile")
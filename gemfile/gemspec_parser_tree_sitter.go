@@ -10,9 +10,10 @@ import (
 
 // TreeSitterGemspecParser handles parsing of .gemspec files using tree-sitter
 type TreeSitterGemspecParser struct {
-	content   []byte
-	helper    *RubyASTHelper
-	variables map[string]string // Track variable assignments
+	content      []byte
+	helper       *RubyASTHelper
+	variables    map[string]string // Track variable assignments
+	specVariable string            // Variable holding a block-less Gem::Specification.new, e.g. spec
 }
 
 // NewTreeSitterGemspecParser creates a new tree-sitter based gemspec parser
@@ -70,6 +71,16 @@ func (p *TreeSitterGemspecParser) extractGemspecData(node *tree_sitter.Node, gem
 		return
 	}
 
+	// Look for spec = Gem::Specification.new, configured by the statements that follow
+	if name := p.gemSpecVariable(node); name != "" {
+		p.specVariable = name
+		return
+	}
+	if p.specVariable != "" && p.configuresSpecVariable(node) {
+		p.processStatement(node, gemspec)
+		return
+	}
+
 	// Recursively process children
 	for i := uint(0); i < node.ChildCount(); i++ {
 		child := node.Child(i)
@@ -95,6 +106,44 @@ func (p *TreeSitterGemspecParser) isGemSpecBlock(node *tree_sitter.Node) bool {
 	return false
 }
 
+// gemSpecVariable returns the variable a block-less Gem::Specification.new is
+// assigned to, as in spec = Gem::Specification.new, or "" for any other node
+func (p *TreeSitterGemspecParser) gemSpecVariable(node *tree_sitter.Node) string {
+	if node.Kind() != nodeAssignment || node.ChildCount() < 3 {
+		return ""
+	}
+
+	left, right := node.Child(0), node.Child(node.ChildCount()-1)
+	if left.Kind() != nodeIdentifier || right.Kind() != nodeCall {
+		return ""
+	}
+	if p.nodeHasBlock(right) || !p.containsGemSpecConstructor(right) {
+		return ""
+	}
+	return p.getNodeText(left)
+}
+
+// configuresSpecVariable reports whether a statement is called on the spec
+// variable, e.g. spec.name = "x", spec.add_dependency "rack",
+// spec.metadata["key"] = "value" or spec.requirements << "libpq"
+func (p *TreeSitterGemspecParser) configuresSpecVariable(node *tree_sitter.Node) bool {
+	for node.ChildCount() > 0 {
+		switch node.Kind() {
+		case nodeAssignment, nodeBinary, nodeElementReference:
+			node = node.Child(0)
+		case nodeCall:
+			receiver := node.Child(0)
+			if receiver.Kind() == nodeIdentifier {
+				return p.getNodeText(receiver) == p.specVariable
+			}
+			node = receiver
+		default:
+			return false
+		}
+	}
+	return false
+}
+
 // nodeHasBlock returns true when the call node has a block/do_block child.
 func (p *TreeSitterGemspecParser) nodeHasBlock(node *tree_sitter.Node) bool {
 	for i := uint(0); i < node.ChildCount(); i++ {
@@ -197,6 +246,11 @@ func (p *TreeSitterGemspecParser) processStatement(node *tree_sitter.Node, gemsp
 		return
 	}
 
+	// Handle appends like spec.requirements << "libmagic"
+	if node.Kind() == nodeBinary && p.processRequirementsAppend(node, gemspec) {
+		return
+	}
+
 	// Recursively process children for other node types
 	for i := uint(0); i < node.ChildCount(); i++ {
 		child := node.Child(i)
@@ -214,6 +268,13 @@ func (p *TreeSitterGemspecParser) processAssignment(node *tree_sitter.Node, gems
 	property := p.getPropertyName(leftSide)
 	value := p.extractValue(rightSide)
 
+	// required_ruby_version may be a list of constraints or a Gem::Requirement
+	if property == "required_ruby_version" {
+		if constraints := p.extractRequirementList(rightSide); len(constraints) > 0 {
+			value = strings.Join(constraints, ", ")
+		}
+	}
+
 	// Handle simple string properties
 	if p.assignSimpleProperty(property, value, gemspec) {
 		return
@@ -256,6 +317,23 @@ func (p *TreeSitterGemspecParser) extractAssignmentSides(node *tree_sitter.Node)
 	return
 }
 
+// extractRequirementList extracts the constraints from an array such as
+// [">= 2.6", "< 3.2"] or a call such as Gem::Requirement.new(">= 2.6", "< 3.2")
+func (p *TreeSitterGemspecParser) extractRequirementList(node *tree_sitter.Node) []string {
+	switch node.Kind() {
+	case nodeArray:
+		return p.extractStringArray(node)
+	case nodeCall:
+		if !strings.HasPrefix(p.getNodeText(node), "Gem::Requirement") {
+			return nil
+		}
+		if argList := p.helper.FindChildByKind(node, nodeArgumentList); argList != nil {
+			return p.extractStringArray(argList)
+		}
+	}
+	return nil
+}
+
 // assignSimpleProperty assigns simple string properties to gemspec
 func (p *TreeSitterGemspecParser) assignSimpleProperty(property, value string, gemspec *GemspecFile) bool {
 	switch property {
@@ -275,6 +353,8 @@ func (p *TreeSitterGemspecParser) assignSimpleProperty(property, value string, g
 		gemspec.RequiredRubyVersion = value
 	case "post_install_message":
 		gemspec.PostInstallMessage = value
+	case "platform":
+		gemspec.Platform = normalizeGemspecPlatform(value)
 	default:
 		return false
 	}
@@ -305,12 +385,39 @@ func (p *TreeSitterGemspecParser) assignArrayProperty(property, value string, ri
 		}
 	case "files":
 		gemspec.Files = p.extractStringArray(rightSide)
+	case "requirements":
+		if rightSide.Kind() == nodeArray {
+			gemspec.Requirements = p.extractStringArray(rightSide)
+		} else {
+			gemspec.Requirements = []string{value}
+		}
 	default:
 		return false
 	}
 	return true
 }
 
+// processRequirementsAppend handles spec.requirements << "a", including
+// chained appends such as spec.requirements << "a" << "b".
+// It reports whether node appended to spec.requirements.
+func (p *TreeSitterGemspecParser) processRequirementsAppend(node *tree_sitter.Node, gemspec *GemspecFile) bool {
+	// Appends are left-associative: ((spec.requirements << "a") << "b")
+	var values []string
+	for node.Kind() == nodeBinary {
+		if node.ChildCount() != 3 || p.getNodeText(node.Child(1)) != "<<" {
+			return false
+		}
+		values = append([]string{p.extractValue(node.Child(2))}, values...)
+		node = node.Child(0)
+	}
+	if node.Kind() != nodeCall || p.getPropertyName(node) != "requirements" {
+		return false
+	}
+
+	gemspec.Requirements = append(gemspec.Requirements, values...)
+	return true
+}
+
 // processMethodCall handles method calls like spec.add_runtime_dependency
 func (p *TreeSitterGemspecParser) processMethodCall(node *tree_sitter.Node, gemspec *GemspecFile) {
 	methodName := ""
@@ -401,11 +508,18 @@ func (p *TreeSitterGemspecParser) extractValue(node *tree_sitter.Node) string {
 		varName := p.getNodeText(node)
 		return p.expandVariable(varName)
 	case nodeConstant:
-		return p.getNodeText(node)
+		// Constants assigned a string earlier in the file resolve like variables
+		return p.expandVariable(p.getNodeText(node))
 	case nodeScopeResolution:
 		// For things like MyGem::VERSION
 		return p.getNodeText(node)
 	case nodeCall:
+		// Gem::Version.new("1.0.0") wraps the version string
+		if strings.HasPrefix(p.getNodeText(node), "Gem::Version.new") {
+			if argList := p.helper.FindChildByKind(node, nodeArgumentList); argList != nil && argList.NamedChildCount() > 0 {
+				return p.extractValue(argList.NamedChild(0))
+			}
+		}
 		return strings.TrimSpace(p.getNodeText(node))
 	case nodeSymbol:
 		return strings.TrimPrefix(p.getNodeText(node), ":")
@@ -508,7 +622,8 @@ func (p *TreeSitterGemspecParser) getNodeText(node *tree_sitter.Node) string {
 	return p.helper.GetNodeText(node)
 }
 
-// processVariableAssignment parses variable assignments like: rails_version = '~> 8.1.0'
+// processVariableAssignment parses variable and constant assignments like:
+// rails_version = '~> 8.1.0' or RACK_GEM = 'rack'
 func (p *TreeSitterGemspecParser) processVariableAssignment(node *tree_sitter.Node) {
 	if node == nil {
 		return
@@ -524,7 +639,7 @@ func (p *TreeSitterGemspecParser) processVariableAssignment(node *tree_sitter.No
 		kind := child.Kind()
 		text := p.getNodeText(child)
 
-		if kind == nodeIdentifier && varName == "" {
+		if (kind == nodeIdentifier || kind == nodeConstant) && varName == "" {
 			varName = text
 		} else if kind == nodeString {
 			// Extract string value
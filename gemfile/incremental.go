@@ -0,0 +1,91 @@
+package gemfile
+
+import (
+	"bytes"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// incrementalState holds the syntax tree from the last ParseIncremental call
+// together with the content it was parsed from
+type incrementalState struct {
+	tree    *tree_sitter.Tree
+	content []byte
+}
+
+// ParseIncremental re-parses a Gemfile after an edit, for editor integrations
+// that parse on every change. old and oldContent are the result and content of
+// the previous call; old is returned as is when the content did not change.
+//
+// The parser keeps the tree-sitter syntax tree between calls and hands it to
+// tree-sitter so unchanged parts of the file are not re-parsed. The first call,
+// or a call whose oldContent doesn't match the kept tree, parses in full.
+// Gemfile data is always extracted from the whole tree, and the regex fallback
+// of Parse still applies. Call Close to release the kept tree.
+func (p *GemfileParser) ParseIncremental(old *ParsedGemfile, oldContent, newContent []byte) (*ParsedGemfile, error) {
+	if old != nil && bytes.Equal(oldContent, newContent) {
+		return old, nil
+	}
+
+	var oldTree *tree_sitter.Tree
+	if p.incremental != nil && bytes.Equal(p.incremental.content, oldContent) {
+		oldTree = p.incremental.tree
+		oldTree.Edit(inputEditFor(oldContent, newContent))
+	}
+
+	tsParser := NewTreeSitterGemfileParser(newContent)
+	tsParser.Limits = p.Limits
+	tsParser.lint = p.Lint
+	gemfile, tree, err := tsParser.parseTree(oldTree)
+
+	p.Close()
+	if tree != nil {
+		p.incremental = &incrementalState{tree: tree, content: bytes.Clone(newContent)}
+	}
+
+	p.content = string(newContent)
+	return p.preferTreeSitter(gemfile, err)
+}
+
+// Close releases the syntax tree kept by ParseIncremental. The parser remains usable.
+func (p *GemfileParser) Close() {
+	if p.incremental != nil {
+		p.incremental.tree.Close()
+		p.incremental = nil
+	}
+}
+
+// inputEditFor describes the change from oldContent to newContent as a single
+// edit spanning everything between their common prefix and common suffix
+func inputEditFor(oldContent, newContent []byte) *tree_sitter.InputEdit {
+	prefix := 0
+	for prefix < len(oldContent) && prefix < len(newContent) && oldContent[prefix] == newContent[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(oldContent)-prefix && suffix < len(newContent)-prefix &&
+		oldContent[len(oldContent)-1-suffix] == newContent[len(newContent)-1-suffix] {
+		suffix++
+	}
+
+	oldEnd := len(oldContent) - suffix
+	newEnd := len(newContent) - suffix
+
+	return &tree_sitter.InputEdit{
+		StartByte:      uint(prefix),
+		OldEndByte:     uint(oldEnd),
+		NewEndByte:     uint(newEnd),
+		StartPosition:  pointAt(oldContent, prefix),
+		OldEndPosition: pointAt(oldContent, oldEnd),
+		NewEndPosition: pointAt(newContent, newEnd),
+	}
+}
+
+// pointAt converts a byte offset into a tree-sitter row/column position
+func pointAt(content []byte, offset int) tree_sitter.Point {
+	before := content[:offset]
+	row := bytes.Count(before, []byte("\n"))
+	column := offset - (bytes.LastIndexByte(before, '\n') + 1)
+	return tree_sitter.NewPoint(uint(row), uint(column))
+}
@@ -0,0 +1,122 @@
+package gemfile
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+func TestParseIncremental(t *testing.T) {
+	oldContent := []byte(`source 'https://rubygems.org'
+
+gem 'rails', '~> 7.0'
+gem 'puma'
+`)
+	newContent := []byte(`source 'https://rubygems.org'
+
+gem 'rails', '~> 7.1'
+gem 'puma'
+gem 'sidekiq'
+`)
+
+	parser := &GemfileParser{}
+	defer parser.Close()
+
+	first, err := parser.ParseIncremental(nil, nil, oldContent)
+	if err != nil {
+		t.Fatalf("initial ParseIncremental failed: %v", err)
+	}
+	if len(first.Dependencies) != 2 {
+		t.Fatalf("expected 2 gems, got %d", len(first.Dependencies))
+	}
+
+	if same, err := parser.ParseIncremental(first, oldContent, oldContent); err != nil || same != first {
+		t.Errorf("expected unchanged content to return the previous result, got %p (%v)", same, err)
+	}
+
+	second, err := parser.ParseIncremental(first, oldContent, newContent)
+	if err != nil {
+		t.Fatalf("ParseIncremental failed: %v", err)
+	}
+	if len(second.Dependencies) != 3 {
+		t.Fatalf("expected 3 gems, got %d", len(second.Dependencies))
+	}
+	rails := findGem(second.Dependencies, "rails")
+	if rails == nil || len(rails.Constraints) != 1 || rails.Constraints[0] != "~> 7.1" {
+		t.Errorf("expected rails constraint ~> 7.1, got %+v", rails)
+	}
+	if findGem(second.Dependencies, "sidekiq") == nil {
+		t.Error("expected sidekiq to be parsed")
+	}
+}
+
+func TestInputEditFor(t *testing.T) {
+	oldContent := []byte("gem 'a'\ngem 'rails', '~> 7.0'\n")
+	newContent := []byte("gem 'a'\ngem 'rails', '~> 7.10'\n")
+
+	edit := inputEditFor(oldContent, newContent)
+	expected := tree_sitter.InputEdit{
+		// "1" inserted before the trailing "0'" shared by both versions
+		StartByte:      27,
+		OldEndByte:     27,
+		NewEndByte:     28,
+		StartPosition:  tree_sitter.NewPoint(1, 19),
+		OldEndPosition: tree_sitter.NewPoint(1, 19),
+		NewEndPosition: tree_sitter.NewPoint(1, 20),
+	}
+	if *edit != expected {
+		t.Errorf("inputEditFor() = %+v, want %+v", *edit, expected)
+	}
+}
+
+// benchmarkGemfile builds a large Gemfile and a copy with one constraint changed
+func benchmarkGemfile() (before, after []byte) {
+	var buf bytes.Buffer
+	buf.WriteString("source 'https://rubygems.org'\n\n")
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&buf, "gem 'gem_%d', '~> 1.%d'\n", i, i)
+	}
+	before = buf.Bytes()
+	after = bytes.Replace(before, []byte("'gem_150', '~> 1.150'"), []byte("'gem_150', '~> 2.0'"), 1)
+	return before, after
+}
+
+// BenchmarkReparseAfterEdit compares a full re-parse with ParseIncremental after a small edit
+func BenchmarkReparseAfterEdit(b *testing.B) {
+	before, after := benchmarkGemfile()
+
+	b.Run("full", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			content := after
+			if i%2 == 1 {
+				content = before
+			}
+			if _, err := NewTreeSitterGemfileParser(content).ParseWithTreeSitter(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("incremental", func(b *testing.B) {
+		parser := &GemfileParser{}
+		defer parser.Close()
+
+		parsed, err := parser.ParseIncremental(nil, nil, before)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.ResetTimer()
+		b.ReportAllocs()
+		oldContent, newContent := before, after
+		for i := 0; i < b.N; i++ {
+			if parsed, err = parser.ParseIncremental(parsed, oldContent, newContent); err != nil {
+				b.Fatal(err)
+			}
+			oldContent, newContent = newContent, oldContent
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package gemfile
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// defaultMaxFileSize is the default maximum Gemfile size in bytes (10 MiB)
+	defaultMaxFileSize = 10 << 20
+	// defaultMaxNestingDepth is the default maximum depth of nested blocks
+	defaultMaxNestingDepth = 100
+	// defaultMaxDependencies is the default maximum number of declared gems
+	defaultMaxDependencies = 10000
+)
+
+// ErrLimitExceeded is returned when a Gemfile exceeds one of the configured ParseLimits
+var ErrLimitExceeded = errors.New("gemfile parse limit exceeded")
+
+// ParseLimits guards the parsers against pathological input.
+// Zero values fall back to generous defaults.
+type ParseLimits struct {
+	MaxFileSize     int // Maximum Gemfile size in bytes
+	MaxNestingDepth int // Maximum depth of nested group/source/platforms/conditional blocks
+	MaxDependencies int // Maximum number of gem declarations
+}
+
+// DefaultParseLimits returns the limits used when none are configured
+func DefaultParseLimits() ParseLimits {
+	return ParseLimits{
+		MaxFileSize:     defaultMaxFileSize,
+		MaxNestingDepth: defaultMaxNestingDepth,
+		MaxDependencies: defaultMaxDependencies,
+	}
+}
+
+// withDefaults fills zero-valued limits with their defaults
+func (l ParseLimits) withDefaults() ParseLimits {
+	defaults := DefaultParseLimits()
+	if l.MaxFileSize <= 0 {
+		l.MaxFileSize = defaults.MaxFileSize
+	}
+	if l.MaxNestingDepth <= 0 {
+		l.MaxNestingDepth = defaults.MaxNestingDepth
+	}
+	if l.MaxDependencies <= 0 {
+		l.MaxDependencies = defaults.MaxDependencies
+	}
+	return l
+}
+
+// checkFileSize returns an error if the content is larger than allowed
+func (l ParseLimits) checkFileSize(size int) error {
+	if limit := l.withDefaults().MaxFileSize; size > limit {
+		return fmt.Errorf("%w: file size %d bytes exceeds maximum of %d", ErrLimitExceeded, size, limit)
+	}
+	return nil
+}
+
+// checkNestingDepth returns an error if blocks are nested deeper than allowed
+func (l ParseLimits) checkNestingDepth(depth int) error {
+	if limit := l.withDefaults().MaxNestingDepth; depth > limit {
+		return fmt.Errorf("%w: nesting depth %d exceeds maximum of %d", ErrLimitExceeded, depth, limit)
+	}
+	return nil
+}
+
+// checkDependencies returns an error if more gems were declared than allowed
+func (l ParseLimits) checkDependencies(count int) error {
+	if limit := l.withDefaults().MaxDependencies; count > limit {
+		return fmt.Errorf("%w: %d dependencies exceed maximum of %d", ErrLimitExceeded, count, limit)
+	}
+	return nil
+}
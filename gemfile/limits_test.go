@@ -0,0 +1,99 @@
+package gemfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLimits(t *testing.T) {
+	nestedGemfile := `group :development do
+  group :test do
+    group :ci do
+      gem 'rspec'
+    end
+  end
+end
+`
+	manyGemsGemfile := `gem 'rails'
+gem 'puma'
+gem 'pg'
+`
+
+	t.Run("file size", func(t *testing.T) {
+		gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+		if err := os.WriteFile(gemfilePath, []byte(manyGemsGemfile), 0600); err != nil {
+			t.Fatalf("Failed to write test Gemfile: %v", err)
+		}
+
+		parser := NewGemfileParser(gemfilePath)
+		parser.Limits = ParseLimits{MaxFileSize: 10}
+		if _, err := parser.Parse(); !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("expected ErrLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("file size at shared entry points", func(t *testing.T) {
+		parser := &GemfileParser{content: manyGemsGemfile, Limits: ParseLimits{MaxFileSize: 10}}
+		if _, err := parser.parseContent(); !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("parseContent: expected ErrLimitExceeded, got %v", err)
+		}
+
+		tsParser := NewTreeSitterGemfileParser([]byte(manyGemsGemfile))
+		tsParser.Limits = ParseLimits{MaxFileSize: 10}
+		if _, err := tsParser.ParseWithTreeSitter(); !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("ParseWithTreeSitter: expected ErrLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("defaults allow normal input", func(t *testing.T) {
+		parser := &GemfileParser{content: nestedGemfile}
+		if _, err := parser.parseContent(); err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+	})
+
+	t.Run("regex parser nesting depth", func(t *testing.T) {
+		parser := &GemfileParser{content: nestedGemfile, Limits: ParseLimits{MaxNestingDepth: 2}}
+		if _, err := parser.parseContent(); !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("expected ErrLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("regex parser dependency count", func(t *testing.T) {
+		parser := &GemfileParser{content: manyGemsGemfile, Limits: ParseLimits{MaxDependencies: 2}}
+		if _, err := parser.parseContent(); !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("expected ErrLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("tree-sitter parser nesting depth", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(nestedGemfile))
+		parser.Limits = ParseLimits{MaxNestingDepth: 2}
+		if _, err := parser.ParseWithTreeSitter(); !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("expected ErrLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("tree-sitter parser dependency count", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(manyGemsGemfile))
+		parser.Limits = ParseLimits{MaxDependencies: 2}
+		if _, err := parser.ParseWithTreeSitter(); !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("expected ErrLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("tree-sitter parser counts arrays and conditionals", func(t *testing.T) {
+		content := `if ENV['CI']
+  matrix = [[[[:test]]]]
+  gem 'rspec'
+end
+`
+		parser := NewTreeSitterGemfileParser([]byte(content))
+		parser.Limits = ParseLimits{MaxNestingDepth: 3}
+		if _, err := parser.ParseWithTreeSitter(); !errors.Is(err, ErrLimitExceeded) {
+			t.Fatalf("expected ErrLimitExceeded, got %v", err)
+		}
+	})
+}
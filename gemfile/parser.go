@@ -6,10 +6,12 @@ package gemfile
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -18,27 +20,41 @@ import (
 type GemfileParser struct {
 	filepath string
 	content  string
+	Limits   ParseLimits // Guards against pathological input (zero values use defaults)
+	Lint     bool        // Also warn about likely mistakes, e.g. gems 'rails' instead of gem 'rails'
+
+	incremental *incrementalState // Syntax tree kept by ParseIncremental for the next call
 }
 
 // ParsedGemfile represents the parsed Gemfile content.
 type ParsedGemfile struct {
-	Dependencies []GemDependency    // Declared gems
-	Sources      []Source           // Gem sources
-	RubyVersion  string             // Ruby version requirement
-	GitSources   map[string]string  // Gem name to git URL mapping
-	Gemspecs     []GemspecReference // Gemspec references
+	Dependencies           []GemDependency    // Declared gems
+	Sources                []Source           // Gem sources
+	RubyVersion            string             // Ruby version requirement (the first one when several are given)
+	RubyVersionConstraints []string           // Every constraint of the ruby directive, e.g. [">= 3.0", "< 3.3"]
+	GitSources             map[string]string  // Custom git_source name to URL template, e.g. "gitlab" => "https://gitlab.com/#{repo}.git"
+	Gemspecs               []GemspecReference // Gemspec references
+	Warnings               []string           // Non-fatal issues found while parsing
+	Plugins                []Plugin           // Bundler plugin declarations
 }
 
 // GemDependency represents a gem dependency.
 // Ruby equivalent: gem "name", "version", options
+//
+// The gem is only installed when its InstallIfExpr and every InstallIf
+// condition hold; the parser records them without evaluating any.
 type GemDependency struct {
-	Name        string   // Gem name
-	Constraints []string // Version constraints (e.g., "~> 2.0" means >= 2.0.0 and < 3.0.0)
-	Source      *Source  // Git, path, source block URL, or nil for default source
-	Groups      []string // Groups (empty means :default)
-	Require     *string  // Require behavior (nil = normal, "false" = no auto-require)
-	Platforms   []string // Platform restrictions (e.g., [:jruby, :windows_31])
-	Comment     string   // Inline comment if present
+	Name              string   // Gem name
+	Constraints       []string // Version constraints (e.g., "~> 2.0" means >= 2.0.0 and < 3.0.0)
+	Source            *Source  // Git, path, source block URL, or nil for default source
+	Groups            []string // Groups (empty means :default)
+	Require           *string  // Require behavior (nil = normal, "false" = no auto-require)
+	Platforms         []string // Platform restrictions (e.g., [:jruby, :windows_31]); platforms blocks apply with the tree-sitter parser only
+	Comment           string   // Inline comment if present
+	ForceRubyPlatform bool     // Install the pure-Ruby variant even where a native gem exists
+	InstallIfExpr     string   // Raw install_if: option of the gem line (e.g. "-> { RUBY_PLATFORM =~ /darwin/ }"), not evaluated
+	InstallIf         []string // Raw conditions of the enclosing install_if blocks, outermost first, one per nested block
+	FromGemspec       bool     // Loaded through a gemspec directive rather than declared in the Gemfile
 }
 
 // Source represents a gem source (RubyGems, Git, Path)
@@ -50,13 +66,23 @@ type Source struct {
 	Ref    string // for git sources
 }
 
+// Plugin represents a Bundler plugin declaration.
+// Ruby equivalent: plugin "name", "version", git: "url"
+type Plugin struct {
+	Name        string   // Plugin gem name
+	Constraints []string // Version constraints
+	Source      *Source  // Git, path or rubygems source, or nil for the default source
+}
+
 // GemspecReference represents a gemspec directive in the Gemfile.
-// Ruby equivalent: gemspec path: "path", name: "name", development_group: :group
+// Ruby equivalent: gemspec path: "path", name: "name", development_group: :group, require: false
 type GemspecReference struct {
-	Path             string // Path to search for gemspec files (defaults to ".")
-	Name             string // Specific gemspec name to load (optional)
-	DevelopmentGroup string // Group for development dependencies (defaults to "development")
-	Glob             string // Glob pattern for finding gemspec files (defaults to "{,*,*/*}.gemspec")
+	Path             string  // Path to search for gemspec files (defaults to ".")
+	Name             string  // Specific gemspec name to load (optional)
+	DevelopmentGroup string  // Group for development dependencies (defaults to "development")
+	Glob             string  // Glob pattern for finding gemspec files (defaults to "{,*,*/*}.gemspec")
+	NameGroup        bool    // Also tag development dependencies with the gemspec name as a group
+	Require          *string // Require behavior for the gem itself (nil = normal, "" = no auto-require)
 }
 
 // GemspecFile represents a parsed .gemspec file
@@ -75,6 +101,8 @@ type GemspecFile struct {
 	Files                   []string          // Files included in the gem
 	Metadata                map[string]string // Additional metadata
 	PostInstallMessage      string            // Post-install message
+	Platform                string            // Platform from spec.platform (empty or "ruby" for pure Ruby)
+	Requirements            []string          // External requirements from spec.requirements, e.g. "libmagic"; informational only
 }
 
 // NewGemfileParser creates a new parser for the given Gemfile path
@@ -95,8 +123,21 @@ func (p *GemfileParser) Parse() (*ParsedGemfile, error) {
 	// Try tree-sitter first (handles complex Ruby constructs like nested blocks)
 	// Note: Currently experimental - falls back to regex for edge cases
 	tsParser := NewTreeSitterGemfileParser([]byte(p.content))
+	tsParser.Limits = p.Limits
+	tsParser.lint = p.Lint
 	gemfile, err := tsParser.ParseWithTreeSitter()
 
+	return p.preferTreeSitter(gemfile, err)
+}
+
+// preferTreeSitter returns the tree-sitter result when it is usable and
+// otherwise falls back to regex parsing of p.content
+func (p *GemfileParser) preferTreeSitter(gemfile *ParsedGemfile, err error) (*ParsedGemfile, error) {
+	// Limit violations are fatal; falling back would just parse the same input again
+	if errors.Is(err, ErrLimitExceeded) {
+		return nil, err
+	}
+
 	// Use tree-sitter result if it found content AND no gemspec directives
 	// (gemspec integration needs more work)
 	useTreeSitter := err == nil &&
@@ -113,6 +154,10 @@ func (p *GemfileParser) Parse() (*ParsedGemfile, error) {
 
 // parseContent parses the Gemfile content using regex patterns
 func (p *GemfileParser) parseContent() (*ParsedGemfile, error) {
+	if err := p.Limits.checkFileSize(len(p.content)); err != nil {
+		return nil, err
+	}
+
 	result := &ParsedGemfile{
 		Dependencies: []GemDependency{},
 		Sources:      []Source{},
@@ -124,17 +169,52 @@ func (p *GemfileParser) parseContent() (*ParsedGemfile, error) {
 	currentGroups := []string{"default"} // Default group
 	variables := make(map[string]string) // Track variables
 	var currentSource *Source            // Track current source block
-	blockDepth := 0                      // Track nesting depth for source blocks
+	var blocks []blockFrame              // Track open do...end blocks
+	skipDepth := 0                       // Track nesting inside skipped blocks: Dir[]/Dir.glob loops and gem blocks
+	heredocTerminator := ""              // Terminator of the heredoc being skipped
+	var gitSource *gitSourceBlock        // Multi-line git_source block being read
+	var primarySources []Source          // Top-level source lines without a block, for the multiple sources warning
 
 	for scanner.Scan() {
 		lineNum++
 		line := strings.TrimSpace(scanner.Text())
 
+		// Heredoc bodies are plain text, even when they look like gem declarations
+		if heredocTerminator != "" {
+			heredocTerminator = skipHeredocLine(line, heredocTerminator)
+			continue
+		}
+
 		// Skip empty lines and comments
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
+		// The line opening a heredoc is still parsed; its body is skipped
+		heredocTerminator = extractHeredocTerminator(line)
+
+		// A multi-line git_source block registers the URL string it ends with
+		if gitSource != nil {
+			if gitSource.processLine(line, result.GitSources) {
+				gitSource = nil
+			}
+			continue
+		}
+
+		// Gems declared inside Dir[]/Dir.glob loops can't be resolved statically,
+		// and the body of a gem block belongs to whatever plugin reads it
+		if skipDepth > 0 {
+			skipDepth = skippedBlockDepth(line, skipDepth)
+			continue
+		}
+		if dynamicGemLoopRe.MatchString(line) {
+			result.Warnings = append(result.Warnings, dynamicGemLoadingWarning(lineNum))
+			if opensBlock(line) {
+				skipDepth++
+			}
+			continue
+		}
+
 		// Parse variable assignments first
 		if varName, varValue := p.parseVariable(line); varName != "" {
 			variables[varName] = varValue
@@ -144,25 +224,191 @@ func (p *GemfileParser) parseContent() (*ParsedGemfile, error) {
 		// Expand variables in the line
 		expandedLine := p.expandVariables(line, variables)
 
+		p.lintLine(expandedLine, lineNum, result)
+
 		// Parse different types of lines
-		if err := p.parseLine(expandedLine, &currentGroups, &currentSource, &blockDepth, result); err != nil {
+		if err := p.parseLine(expandedLine, &currentGroups, &currentSource, &blocks, &primarySources, result); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		// Block bodies read or skipped separately must not close an enclosing block
+		gitSource = extractGitSourceBlock(expandedLine)
+		if gitSource == nil && opensSkippedBlock(expandedLine) {
+			skipDepth++
+		}
+
+		if err := p.checkLimits(len(blocks), result); err != nil {
 			return nil, fmt.Errorf("line %d: %w", lineNum, err)
 		}
 	}
 
+	// The gemspec directive may come before or after the Gemfile declares the same gem
+	if len(result.Gemspecs) > 0 {
+		reconcileGemspecGroups(result.Dependencies)
+	}
+
+	collectSourceWarnings(result)
+	collectPrimarySourcesWarning(result, primarySources)
+
 	return result, nil
 }
 
+// lintLine warns about likely mistakes on a line when p.Lint is set
+func (p *GemfileParser) lintLine(line string, lineNum int, result *ParsedGemfile) {
+	if !p.Lint {
+		return
+	}
+	if matches := gemTypoRe.FindStringSubmatch(line); matches != nil && gemTypos[matches[1]] {
+		result.Warnings = append(result.Warnings, gemTypoWarning(lineNum, matches[1]))
+	}
+}
+
+// heredocStartRe matches the opening of a squiggly or dash heredoc, e.g. <<~DOC or <<-'SQL'
+var heredocStartRe = regexp.MustCompile(`<<[~-](?:'([A-Za-z_]\w*)'|"([A-Za-z_]\w*)"|([A-Za-z_]\w*))`)
+
+// extractHeredocTerminator returns the terminator of the heredoc opened on line,
+// or an empty string when the line opens none
+func extractHeredocTerminator(line string) string {
+	if matches := heredocStartRe.FindStringSubmatch(line); matches != nil {
+		return matches[1] + matches[2] + matches[3]
+	}
+	return ""
+}
+
+// skipHeredocLine returns the terminator still awaited after reading a heredoc
+// body line, or an empty string once the terminator is reached
+func skipHeredocLine(line, terminator string) string {
+	if line == terminator {
+		return ""
+	}
+	return terminator
+}
+
+// opensBlock reports whether a line starts a do...end block
+func opensBlock(line string) bool {
+	return strings.HasSuffix(line, " do") || strings.Contains(line, " do |")
+}
+
+// skippedBlockDepth returns the nesting depth inside a skipped block after line
+func skippedBlockDepth(line string, depth int) int {
+	if line == endKeyword {
+		return depth - 1
+	}
+	if opensBlock(line) || conditionalBlockRe.MatchString(line) {
+		return depth + 1
+	}
+	return depth
+}
+
+// opensSkippedBlock reports whether a parsed line opens a block whose body is
+// skipped: gem 'x' do ... end keeps the gem but not its block, and a git_source
+// block that isn't read for its template is ignored
+func opensSkippedBlock(line string) bool {
+	if !opensBlock(line) {
+		return false
+	}
+	if strings.HasPrefix(line, "gem ") {
+		return true
+	}
+	return strings.HasPrefix(line, "git_source(") && !strings.HasSuffix(line, " end")
+}
+
+// gitSourceRe matches a git_source registration written on one line, capturing
+// its name, block parameter and double- or single-quoted URL template
+var gitSourceRe = regexp.MustCompile(`^git_source\(\s*:(\w+)\s*\)\s*(?:\{|do)\s*\|\s*(\w+)\s*\|\s*(?:"([^"]*)"|'([^']*)')\s*(?:\}|end)`)
+
+// gitSourceOpenRe matches the first line of a multi-line git_source block,
+// capturing its name and block parameter
+var gitSourceOpenRe = regexp.MustCompile(`^git_source\(\s*:(\w+)\s*\)\s*(?:\{|do)\s*\|\s*(\w+)\s*\|$`)
+
+// gitSourceBodyRe matches a body line made of a single quoted URL template
+var gitSourceBodyRe = regexp.MustCompile(`^(?:"([^"]*)"|'([^']*)')$`)
+
+// gitSourceBlock tracks a multi-line git_source block being read. Like the
+// tree-sitter parser, only a block ending with the URL string is registered.
+type gitSourceBlock struct {
+	name  string
+	param string
+	last  string // Last statement read from the body
+	depth int    // Nesting of blocks opened inside the body
+}
+
+// extractGitSourceBlock returns the block to read when line opens a multi-line
+// git_source block, or nil otherwise
+func extractGitSourceBlock(line string) *gitSourceBlock {
+	if matches := gitSourceOpenRe.FindStringSubmatch(line); matches != nil {
+		return &gitSourceBlock{name: matches[1], param: matches[2]}
+	}
+	return nil
+}
+
+// processLine reads a line of the block body, registering the URL template in
+// gitSources once the block's end is reached. It reports whether the block ended.
+func (b *gitSourceBlock) processLine(line string, gitSources map[string]string) bool {
+	switch {
+	case b.depth == 0 && (line == endKeyword || line == "}"):
+		if matches := gitSourceBodyRe.FindStringSubmatch(b.last); matches != nil {
+			gitSources[b.name] = gitSourceTemplate(matches[1]+matches[2], b.param)
+		}
+		return true
+	case line == endKeyword:
+		b.depth--
+	case opensBlock(line) || conditionalBlockRe.MatchString(line):
+		b.depth++
+	}
+	b.last = line
+	return false
+}
+
+// conditionalBlockRe matches statements that are closed by a matching end
+var conditionalBlockRe = regexp.MustCompile(`^(?:if|unless|case|while|until)\b|^begin$`)
+
+// blockFrame remembers the groups and source in effect before a block opened,
+// so they can be restored when its end is reached
+type blockFrame struct {
+	groups    []string
+	source    *Source
+	installIf string // Condition of an install_if block, empty for other blocks
+}
+
+// installConditions returns the conditions of the open install_if blocks, outermost first
+func installConditions(blocks []blockFrame) []string {
+	var conditions []string
+	for _, frame := range blocks {
+		if frame.installIf != "" {
+			conditions = append(conditions, frame.installIf)
+		}
+	}
+	return conditions
+}
+
+// installIfBlockRe matches an install_if block opening, capturing its condition,
+// e.g. install_if -> { RUBY_PLATFORM =~ /darwin/ } do
+var installIfBlockRe = regexp.MustCompile(`^install_if(?:\s+(.+?)|\((.+)\))\s+do$`)
+
+// checkLimits verifies the running block depth and dependency count against p.Limits
+func (p *GemfileParser) checkLimits(blockDepth int, result *ParsedGemfile) error {
+	if err := p.Limits.checkNestingDepth(blockDepth); err != nil {
+		return err
+	}
+	return p.Limits.checkDependencies(len(result.Dependencies))
+}
+
 // parseLine parses a single line of the Gemfile
 func (p *GemfileParser) parseLine(
 	line string,
 	currentGroups *[]string,
 	currentSource **Source,
-	blockDepth *int,
+	blocks *[]blockFrame,
+	primarySources *[]Source,
 	result *ParsedGemfile,
 ) error {
 	line = strings.TrimSpace(line)
 
+	openBlock := func() {
+		*blocks = append(*blocks, blockFrame{groups: *currentGroups, source: *currentSource})
+	}
+
 	// Parse source declarations
 	if strings.HasPrefix(line, "source ") {
 		source, isBlock, err := p.parseSource(line)
@@ -170,8 +416,10 @@ func (p *GemfileParser) parseLine(
 			result.Sources = append(result.Sources, source)
 			// If this is a source block (has 'do'), set it as current source
 			if isBlock {
+				openBlock()
 				*currentSource = &source
-				*blockDepth = 1 // Start tracking block depth
+			} else if len(*blocks) == 0 {
+				*primarySources = append(*primarySources, source)
 			}
 		}
 		return nil
@@ -179,30 +427,30 @@ func (p *GemfileParser) parseLine(
 
 	// Parse git_source declarations
 	if strings.HasPrefix(line, "git_source(") {
-		// git_source(:github) { |repo| "https://github.com/#{repo}.git" }
-		// Store for later use - simplified parsing for now
+		// git_source(:gitlab) { |repo| "https://gitlab.com/#{repo}.git" }
+		if matches := gitSourceRe.FindStringSubmatch(line); matches != nil {
+			result.GitSources[matches[1]] = gitSourceTemplate(matches[3]+matches[4], matches[2])
+		}
 		return nil
 	}
 
 	// Parse group blocks
 	if strings.HasPrefix(line, "group ") {
-		*currentGroups = p.parseGroups(line)
-		// Increment block depth if this is a group block
 		if strings.Contains(line, " do") {
-			*blockDepth++
+			openBlock()
 		}
+		*currentGroups = p.parseGroups(line)
 		return nil
 	}
 
-	// Parse end statements
+	// Parse install_if blocks; gems inside inherit the condition
+	if p.parseInstallIf(line, *currentGroups, *currentSource, blocks) {
+		return nil
+	}
+
+	// Parse end statements, restoring the groups and source of the enclosing block
 	if line == endKeyword {
-		*blockDepth--
-		// Reset current source when we exit a source block (depth returns to 0)
-		if *blockDepth == 0 {
-			*currentSource = nil
-		}
-		// Always reset groups when exiting any block
-		*currentGroups = []string{"default"}
+		p.closeBlock(currentGroups, currentSource, blocks)
 		return nil
 	}
 
@@ -213,43 +461,129 @@ func (p *GemfileParser) parseLine(
 
 	// Parse gem declarations
 	if strings.HasPrefix(line, "gem ") {
-		dep, err := p.parseGemLine(line, *currentGroups, *currentSource)
-		if err != nil {
-			return err
-		}
-		if dep != nil {
-			result.Dependencies = append(result.Dependencies, *dep)
-		}
-		return nil
+		return p.parseGem(line, *currentGroups, *currentSource, *blocks, result)
+	}
+
+	// Parse plugin declarations
+	if strings.HasPrefix(line, "plugin ") {
+		return p.parsePlugin(line, result)
 	}
 
 	// Parse ruby version
 	if strings.HasPrefix(line, "ruby ") {
-		result.RubyVersion = p.parseRubyVersion(line)
+		result.RubyVersionConstraints = p.parseRubyVersionConstraints(line)
+		if len(result.RubyVersionConstraints) > 0 {
+			result.RubyVersion = result.RubyVersionConstraints[0]
+		}
 		return nil
 	}
 
+	// Track other blocks (platforms, conditionals) so their end
+	// doesn't close an enclosing source or group block
+	if opensBlock(line) || conditionalBlockRe.MatchString(line) {
+		openBlock()
+	}
+
 	// Skip other lines (variables, etc.)
 	return nil
 }
 
+// parseInstallIf opens an install_if block, recording its condition so gems
+// inside inherit it. It reports whether line opened one.
+func (p *GemfileParser) parseInstallIf(line string, currentGroups []string, currentSource *Source, blocks *[]blockFrame) bool {
+	matches := installIfBlockRe.FindStringSubmatch(line)
+	if matches == nil {
+		return false
+	}
+	*blocks = append(*blocks, blockFrame{
+		groups:    currentGroups,
+		source:    currentSource,
+		installIf: matches[1] + matches[2],
+	})
+	return true
+}
+
+// closeBlock handles an end statement, restoring the groups and source in
+// effect before the innermost open block, or the defaults when none is open
+func (p *GemfileParser) closeBlock(currentGroups *[]string, currentSource **Source, blocks *[]blockFrame) {
+	n := len(*blocks)
+	if n == 0 {
+		*currentGroups = []string{"default"}
+		*currentSource = nil
+		return
+	}
+	frame := (*blocks)[n-1]
+	*blocks = (*blocks)[:n-1]
+	*currentGroups = frame.groups
+	*currentSource = frame.source
+}
+
+// parseGem parses a gem declaration, applying the conditions of the enclosing
+// install_if blocks and warning about options whose value is computed
+func (p *GemfileParser) parseGem(
+	line string,
+	currentGroups []string,
+	currentSource *Source,
+	blocks []blockFrame,
+	result *ParsedGemfile,
+) error {
+	dep, err := p.parseGemLine(line, currentGroups, currentSource, result.GitSources)
+	if err != nil || dep == nil {
+		return err
+	}
+	dep.InstallIf = installConditions(blocks)
+	result.Dependencies = append(result.Dependencies, *dep)
+	// Parenthesized option values never match the option patterns, so they are already skipped.
+	// install_if keeps its expression as written, parenthesized or not.
+	for _, match := range dynamicOptionRe.FindAllStringSubmatch(line, -1) {
+		if match[1] == installIfKey {
+			continue
+		}
+		result.Warnings = append(result.Warnings, dynamicOptionWarning(dep.Name, match[1]))
+	}
+	for _, match := range shorthandOptionRe.FindAllStringSubmatch(line, -1) {
+		result.Warnings = append(result.Warnings, dynamicOptionWarning(dep.Name, match[1]))
+	}
+	return nil
+}
+
+// parsePlugin parses a plugin declaration and records it
+func (p *GemfileParser) parsePlugin(line string, result *ParsedGemfile) error {
+	plugin, err := p.parsePluginLine(line, result.GitSources)
+	if err != nil {
+		return err
+	}
+	result.Plugins = append(result.Plugins, plugin)
+	return nil
+}
+
 // parseSource parses source declarations
 // Examples:
 //
 //	source 'https://rubygems.org'
 //	source 'https://gem.coop' do
+//	source :rubygems
 //
 // Returns the Source, a boolean indicating if it's a block (has 'do'), and an error
 func (p *GemfileParser) parseSource(line string) (Source, bool, error) {
-	re := regexp.MustCompile(`source\s+['"]([^'"]+)['"]`)
+	re := regexp.MustCompile(`source\s+(?:['"]([^'"]+)['"]|:(\w+))`)
 	matches := re.FindStringSubmatch(line)
-	if len(matches) < 2 {
+	if len(matches) < 3 {
 		return Source{}, false, fmt.Errorf("invalid source line: %s", line)
 	}
 
+	url := matches[1]
+	if matches[2] != "" {
+		legacyURL, ok := legacySourceURL(matches[2])
+		if !ok {
+			return Source{}, false, fmt.Errorf("unknown source alias: %s", line)
+		}
+		url = legacyURL
+	}
+
 	source := Source{
 		Type: "rubygems",
-		URL:  matches[1],
+		URL:  url,
 	}
 
 	// Check if this is a source block (has 'do' keyword)
@@ -258,9 +592,29 @@ func (p *GemfileParser) parseSource(line string) (Source, bool, error) {
 	return source, isBlock, nil
 }
 
+// legacySourceURL maps the symbol aliases accepted by old versions of Bundler,
+// e.g. source :rubygems, to the RubyGems URL
+func legacySourceURL(symbol string) (string, bool) {
+	switch symbol {
+	case "rubygems", "gemcutter", "rubyforge":
+		return rubygemsURL, true
+	}
+	return "", false
+}
+
+// groupTerminatorRe matches the keyword that ends the group names on a group line
+var groupTerminatorRe = regexp.MustCompile(`\s(?:do|if|unless)\b`)
+
 // parseGroups parses group declarations
 // Examples: group :development, :test do
+//
+//	group :production if ENV["DEPLOY"]
 func (p *GemfileParser) parseGroups(line string) []string {
+	// Ignore anything after do/if/unless so symbols in conditions aren't taken as groups
+	if loc := groupTerminatorRe.FindStringIndex(line); loc != nil {
+		line = line[:loc[0]]
+	}
+
 	// Extract group names using regex
 	re := regexp.MustCompile(`:(\w+)`)
 	matches := re.FindAllStringSubmatch(line, -1)
@@ -279,6 +633,30 @@ func (p *GemfileParser) parseGroups(line string) []string {
 	return groups
 }
 
+// pluginNameRe matches the name of a plugin declaration
+var pluginNameRe = regexp.MustCompile(`^plugin\s+['"]([^'"]+)['"],?\s*`)
+
+// parsePluginLine parses plugin declarations
+// Examples:
+//
+//	plugin 'bundler-graph'
+//	plugin 'bundler-private', '~> 1.0', git: 'https://github.com/example/bundler-private.git'
+func (p *GemfileParser) parsePluginLine(line string, gitSources map[string]string) (Plugin, error) {
+	matches := pluginNameRe.FindStringSubmatch(line)
+	if len(matches) < 2 {
+		return Plugin{}, fmt.Errorf("invalid plugin line: %s", line)
+	}
+
+	return Plugin{
+		Name:        matches[1],
+		Constraints: p.extractVersionConstraints(line[len(matches[0]):]),
+		Source:      p.extractSource(line, gitSources),
+	}, nil
+}
+
+// hashRocketOptionRe matches an old-style ":key =>" option, or one with a string key like "require" =>
+var hashRocketOptionRe = regexp.MustCompile(`(?::(\w+)|['"](\w+)['"])\s*=>\s*`)
+
 // parseGemLine parses gem declarations
 // Examples:
 //
@@ -287,7 +665,13 @@ func (p *GemfileParser) parseGroups(line string) []string {
 //	gem 'capybara', require: false
 //	gem 'state_machines', github: 'state-machines/state_machines', branch: 'master'
 //	gem 'commonshare_cms', path: 'components/cms'
-func (p *GemfileParser) parseGemLine(line string, currentGroups []string, currentSource *Source) (*GemDependency, error) {
+//	gem 'local_gem', '~> 1.0', :path => '../local_gem'
+func (p *GemfileParser) parseGemLine(
+	line string,
+	currentGroups []string,
+	currentSource *Source,
+	gitSources map[string]string,
+) (*GemDependency, error) {
 	// Basic gem pattern: gem 'name'
 	nameRe := regexp.MustCompile(`gem\s+['"]([^'"]+)['"]`)
 	nameMatches := nameRe.FindStringSubmatch(line)
@@ -295,6 +679,9 @@ func (p *GemfileParser) parseGemLine(line string, currentGroups []string, curren
 		return nil, fmt.Errorf("invalid gem line: %s", line)
 	}
 
+	// Rewrite ":path => '../foo'" as "path: '../foo'" so options aren't taken for version constraints
+	line = hashRocketOptionRe.ReplaceAllString(line, "$1$2: ")
+
 	dep := &GemDependency{
 		Name:   nameMatches[1],
 		Groups: make([]string, len(currentGroups)),
@@ -305,7 +692,7 @@ func (p *GemfileParser) parseGemLine(line string, currentGroups []string, curren
 	dep.Constraints = p.extractVersionConstraints(line)
 
 	// Extract special options (git, path, etc.)
-	dep.Source = p.extractSource(line)
+	dep.Source = p.extractSource(line, gitSources)
 
 	// If no explicit source was found but we're inside a source block, use currentSource
 	if dep.Source == nil && currentSource != nil {
@@ -324,6 +711,10 @@ func (p *GemfileParser) parseGemLine(line string, currentGroups []string, curren
 	// Extract platform restrictions
 	dep.Platforms = p.extractPlatforms(line)
 
+	dep.ForceRubyPlatform = p.extractForceRubyPlatform(line)
+
+	dep.InstallIfExpr = p.extractInstallIf(line)
+
 	return dep, nil
 }
 
@@ -345,6 +736,11 @@ func (p *GemfileParser) extractVersionConstraints(line string) []string {
 		"platforms:",
 		"platform:",
 		"source:",
+		"branch:",
+		"tag:",
+		"ref:",
+		"force_ruby_platform:",
+		"install_if:",
 	}
 
 	optionsStart := -1
@@ -373,8 +769,14 @@ func (p *GemfileParser) extractVersionConstraints(line string) []string {
 	return constraints
 }
 
-// extractSource extracts git/path source information
-func (p *GemfileParser) extractSource(line string) *Source {
+// extractSource extracts git/path source information, expanding the
+// git_source templates registered so far
+func (p *GemfileParser) extractSource(line string, gitSources map[string]string) *Source {
+	// Check for custom git sources first, so a registered github overrides the built-in
+	if source := p.extractCustomGitSource(line, gitSources); source != nil {
+		return source
+	}
+
 	// Check for github source: github: 'user/repo'
 	if githubRe := regexp.MustCompile(`github:\s*['"]([^'"]+)['"]`); githubRe.MatchString(line) {
 		matches := githubRe.FindStringSubmatch(line)
@@ -383,15 +785,7 @@ func (p *GemfileParser) extractSource(line string) *Source {
 				Type: "git",
 				URL:  fmt.Sprintf("https://github.com/%s.git", matches[1]),
 			}
-
-			// Extract branch/tag/ref
-			if branchRe := regexp.MustCompile(`branch:\s*['"]([^'"]+)['"]`); branchRe.MatchString(line) {
-				branchMatches := branchRe.FindStringSubmatch(line)
-				if len(branchMatches) > 1 {
-					source.Branch = branchMatches[1]
-				}
-			}
-
+			p.extractGitRefs(line, source)
 			return source
 		}
 	}
@@ -400,10 +794,12 @@ func (p *GemfileParser) extractSource(line string) *Source {
 	if gitRe := regexp.MustCompile(`git:\s*['"]([^'"]+)['"]`); gitRe.MatchString(line) {
 		matches := gitRe.FindStringSubmatch(line)
 		if len(matches) > 1 {
-			return &Source{
+			source := &Source{
 				Type: "git",
 				URL:  matches[1],
 			}
+			p.extractGitRefs(line, source)
+			return source
 		}
 	}
 
@@ -432,6 +828,46 @@ func (p *GemfileParser) extractSource(line string) *Source {
 	return nil
 }
 
+// extractCustomGitSource expands an option naming a registered git_source,
+// e.g. gitlab: 'org/repo'. Names are tried in sorted order.
+func (p *GemfileParser) extractCustomGitSource(line string, gitSources map[string]string) *Source {
+	names := make([]string, 0, len(gitSources))
+	for name := range gitSources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `:\s*['"]([^'"]+)['"]`)
+		if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+			source := &Source{
+				Type: "git",
+				URL:  expandGitSource(gitSources[name], matches[1]),
+			}
+			p.extractGitRefs(line, source)
+			return source
+		}
+	}
+	return nil
+}
+
+// extractGitRefs extracts branch/tag/ref options for git sources.
+// Values are taken verbatim, so branches like "feature/foo-bar" keep their slashes.
+func (p *GemfileParser) extractGitRefs(line string, source *Source) {
+	refs := map[string]*string{
+		"branch": &source.Branch,
+		"tag":    &source.Tag,
+		"ref":    &source.Ref,
+	}
+
+	for key, target := range refs {
+		re := regexp.MustCompile(`\b` + key + `:\s*['"]([^'"]+)['"]`)
+		if matches := re.FindStringSubmatch(line); len(matches) > 1 {
+			*target = matches[1]
+		}
+	}
+}
+
 // extractRequire extracts require option
 func (p *GemfileParser) extractRequire(line string) *string {
 	// require: false
@@ -452,46 +888,83 @@ func (p *GemfileParser) extractRequire(line string) *string {
 	return nil
 }
 
+// extractForceRubyPlatform extracts the force_ruby_platform option
+func (p *GemfileParser) extractForceRubyPlatform(line string) bool {
+	forceRe := regexp.MustCompile(`\bforce_ruby_platform:\s*true\b`)
+	return forceRe.MatchString(line)
+}
+
+// extractInstallIf extracts the raw install_if expression from gem line.
+// The expression runs until the next top-level comma or comment, so lambda
+// bodies and parenthesized expressions are captured whole.
+func (p *GemfileParser) extractInstallIf(line string) string {
+	idx := strings.Index(line, installIfKey+":")
+	if idx == -1 {
+		return ""
+	}
+	expr := strings.TrimSpace(line[idx+len(installIfKey)+1:])
+
+	depth := 0
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0 && (c == ',' || c == '#'):
+			return strings.TrimSpace(expr[:i])
+		}
+	}
+	return expr
+}
+
+// listElementRe matches a symbol or quoted string element of an array literal
+var listElementRe = regexp.MustCompile(`:(\w+)|['"]([^'"]+)['"]`)
+
+// listElements returns the symbols and strings of an array literal body, in order,
+// so mixed arrays like [:jruby, 'windows'] keep every element
+func listElements(list string) []string {
+	matches := listElementRe.FindAllStringSubmatch(list, -1)
+	elements := make([]string, 0, len(matches))
+	for _, match := range matches {
+		elements = append(elements, match[1]+match[2])
+	}
+	return elements
+}
+
 // extractGroupOverrides extracts group overrides from gem line
 func (p *GemfileParser) extractGroupOverrides(line string) []string {
 	// groups: [:development, :test]
 	if groupsRe := regexp.MustCompile(`groups?:\s*\[([^\]]+)\]`); groupsRe.MatchString(line) {
 		matches := groupsRe.FindStringSubmatch(line)
 		if len(matches) > 1 {
-			groupStr := matches[1]
-			groupRe := regexp.MustCompile(`:(\w+)`)
-			groupMatches := groupRe.FindAllStringSubmatch(groupStr, -1)
-
-			groups := make([]string, 0, len(groupMatches))
-			for _, match := range groupMatches {
-				if len(match) > 1 {
-					groups = append(groups, match[1])
-				}
-			}
-			return groups
+			return listElements(matches[1])
 		}
 	}
 
+	// group: :development (single group)
+	if matches := regexp.MustCompile(`groups?:\s*(?::(\w+)|['"](\w+)['"])`).FindStringSubmatch(line); matches != nil {
+		return []string{matches[1] + matches[2]}
+	}
+
 	return nil
 }
 
 // extractPlatforms extracts platform restrictions from gem line
 func (p *GemfileParser) extractPlatforms(line string) []string {
-	// platforms: [:windows_31, :jruby]
+	// platforms: [:windows_31, :jruby] or platforms: [:jruby, 'windows']
 	if platformsRe := regexp.MustCompile(`platforms?:\s*\[([^\]]+)\]`); platformsRe.MatchString(line) {
 		matches := platformsRe.FindStringSubmatch(line)
 		if len(matches) > 1 {
-			platformStr := matches[1]
-			platformRe := regexp.MustCompile(`:(\w+)`)
-			platformMatches := platformRe.FindAllStringSubmatch(platformStr, -1)
-
-			platforms := make([]string, 0, len(platformMatches))
-			for _, match := range platformMatches {
-				if len(match) > 1 {
-					platforms = append(platforms, match[1])
-				}
-			}
-			return platforms
+			return listElements(matches[1])
 		}
 	}
 
@@ -506,14 +979,27 @@ func (p *GemfileParser) extractPlatforms(line string) []string {
 	return nil
 }
 
-// parseRubyVersion extracts Ruby version requirement
-func (p *GemfileParser) parseRubyVersion(line string) string {
-	re := regexp.MustCompile(`ruby\s+['"]([^'"]+)['"]`)
-	matches := re.FindStringSubmatch(line)
-	if len(matches) > 1 {
-		return matches[1]
+// rubyOptionRe matches the first option of a ruby directive, e.g. engine: or patchlevel:
+var rubyOptionRe = regexp.MustCompile(`\b\w+:\s`)
+
+// parseRubyVersionConstraints extracts the Ruby version requirements of a ruby directive
+// Examples:
+//
+//	ruby '3.2.0'
+//	ruby '>= 3.0', '< 3.3'
+//	ruby '3.1.4', engine: 'jruby', engine_version: '9.4.5.0'
+func (p *GemfileParser) parseRubyVersionConstraints(line string) []string {
+	versionPart := strings.TrimPrefix(line, "ruby ")
+	if loc := rubyOptionRe.FindStringIndex(versionPart); loc != nil {
+		versionPart = versionPart[:loc[0]]
 	}
-	return ""
+
+	re := regexp.MustCompile(`['"]([^'"]+)['"]`)
+	var constraints []string
+	for _, match := range re.FindAllStringSubmatch(versionPart, -1) {
+		constraints = append(constraints, match[1])
+	}
+	return constraints
 }
 
 // parseGemspecDirective parses gemspec directive
@@ -568,6 +1054,9 @@ func (p *GemfileParser) parseGemspecDirective(line string) *GemspecReference {
 		}
 	}
 
+	// Parse require option, applied to the gem's own path dependency
+	gemspecRef.Require = p.extractRequire(line)
+
 	return gemspecRef
 }
 
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -269,6 +271,100 @@ gem 'outside_block'
 	})
 }
 
+func TestGitBranchWithSlashes(t *testing.T) {
+	gemfileContent := `gem 'state_machines', github: 'state-machines/state_machines', branch: 'feature/foo-bar'
+gem 'internal', branch: 'release/2024/q1', git: 'https://git.example.com/internal.git'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expected := map[string]struct{ url, branch string }{
+			"state_machines": {"https://github.com/state-machines/state_machines.git", "feature/foo-bar"},
+			"internal":       {"https://git.example.com/internal.git", "release/2024/q1"},
+		}
+
+		for name, want := range expected {
+			dep := findGem(parsed.Dependencies, name)
+			if dep == nil || dep.Source == nil {
+				t.Fatalf("expected %s to have a git source", name)
+			}
+			if dep.Source.URL != want.url {
+				t.Errorf("%s: expected URL %q, got %q", name, want.url, dep.Source.URL)
+			}
+			if dep.Source.Branch != want.branch {
+				t.Errorf("%s: expected branch %q, got %q", name, want.branch, dep.Source.Branch)
+			}
+			if len(dep.Constraints) != 0 {
+				t.Errorf("%s: expected no constraints, got %v", name, dep.Constraints)
+			}
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestVariableVersionConstraint(t *testing.T) {
+	gemfileContent := `rails_version = '~> 7.0'
+gem 'rails', rails_version
+gem 'puma', '>= 5.0', unknown_helper
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		rails := findGem(parsed.Dependencies, "rails")
+		if rails == nil {
+			t.Fatal("expected rails to be parsed")
+		}
+		if len(rails.Constraints) != 1 || rails.Constraints[0] != "~> 7.0" {
+			t.Errorf("expected rails constraint '~> 7.0', got %v", rails.Constraints)
+		}
+
+		puma := findGem(parsed.Dependencies, "puma")
+		if puma == nil {
+			t.Fatal("expected puma to be parsed")
+		}
+		if len(puma.Constraints) != 1 || puma.Constraints[0] != ">= 5.0" {
+			t.Errorf("expected puma constraint '>= 5.0', got %v", puma.Constraints)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
@@ -283,6 +379,28 @@ func findGem(deps []GemDependency, name string) *GemDependency {
 	return nil
 }
 
+// forEachBackend parses content with the regex and the tree-sitter parser,
+// running check on each result in its own subtest
+func forEachBackend(t *testing.T, content string, check func(t *testing.T, parsed *ParsedGemfile)) {
+	t.Helper()
+
+	t.Run("regex parser", func(t *testing.T) {
+		parsed, err := (&GemfileParser{content: content}).parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parsed, err := NewTreeSitterGemfileParser([]byte(content)).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
 func checkGemDependency(t *testing.T, dep *GemDependency, expectedGems map[string]struct {
 	constraints []string
 	groups      []string
@@ -469,6 +587,86 @@ end
 	}
 }
 
+func TestNestedSourceAndGroupBlocks(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+source 'https://gems.example.com' do
+  gem 'internal_api'
+  group :development do
+    gem 'internal_debugger'
+  end
+  platforms :jruby do
+    gem 'internal_jdbc'
+  end
+  gem 'internal_client'
+end
+
+group :test do
+  source 'https://gems.example.com' do
+    gem 'internal_fixtures'
+  end
+  gem 'rspec'
+end
+
+gem 'rack'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expected := map[string]struct {
+			source string
+			groups []string
+		}{
+			"internal_api":      {"https://gems.example.com", []string{"default"}},
+			"internal_debugger": {"https://gems.example.com", []string{"development"}},
+			"internal_jdbc":     {"https://gems.example.com", []string{"default"}},
+			"internal_client":   {"https://gems.example.com", []string{"default"}},
+			"internal_fixtures": {"https://gems.example.com", []string{"test"}},
+			"rspec":             {"", []string{"test"}},
+			"rack":              {"", []string{"default"}},
+		}
+
+		if len(parsed.Dependencies) != len(expected) {
+			t.Fatalf("expected %d gems, got %d", len(expected), len(parsed.Dependencies))
+		}
+		for name, want := range expected {
+			dep := findGem(parsed.Dependencies, name)
+			if dep == nil {
+				t.Fatalf("expected %s to be parsed", name)
+			}
+			source := ""
+			if dep.Source != nil {
+				source = dep.Source.URL
+			}
+			if source != want.source {
+				t.Errorf("%s: expected source %q, got %q", name, want.source, source)
+			}
+			if !reflect.DeepEqual(dep.Groups, want.groups) {
+				t.Errorf("%s: expected groups %v, got %v", name, want.groups, dep.Groups)
+			}
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
 func TestGemfileParserPlatforms(t *testing.T) {
 	// Create a test Gemfile with platform restrictions
 	testGemfile := `source 'https://rubygems.org'
@@ -554,3 +752,1055 @@ end
 		checkGemDependency(t, &dep, expectedGems)
 	}
 }
+
+func TestForceRubyPlatform(t *testing.T) {
+	gemfileContent := `gem 'nokogiri', '~> 1.15', force_ruby_platform: true
+gem 'ffi', force_ruby_platform: false
+gem 'rails'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expected := map[string]bool{"nokogiri": true, "ffi": false, "rails": false}
+		for name, want := range expected {
+			dep := findGem(parsed.Dependencies, name)
+			if dep == nil {
+				t.Fatalf("expected %s to be parsed", name)
+			}
+			if dep.ForceRubyPlatform != want {
+				t.Errorf("%s: expected ForceRubyPlatform %v, got %v", name, want, dep.ForceRubyPlatform)
+			}
+		}
+
+		nokogiri := findGem(parsed.Dependencies, "nokogiri")
+		if len(nokogiri.Constraints) != 1 || nokogiri.Constraints[0] != "~> 1.15" {
+			t.Errorf("nokogiri: expected constraints [~> 1.15], got %v", nokogiri.Constraints)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestGemOptionOrderIndependence(t *testing.T) {
+	variants := []string{
+		`gem 'x', platforms: :ruby, require: false`,
+		`gem 'x', require: false, platforms: :ruby`,
+	}
+
+	check := func(t *testing.T, deps []GemDependency) {
+		t.Helper()
+
+		for i := range deps {
+			dep := &deps[i]
+			if dep.Require == nil || *dep.Require != "" {
+				t.Errorf("variant %d: expected require: false, got %v", i, dep.Require)
+			}
+			if !reflect.DeepEqual(dep.Platforms, []string{"ruby"}) {
+				t.Errorf("variant %d: expected platforms [ruby], got %v", i, dep.Platforms)
+			}
+			if len(dep.Constraints) != 0 {
+				t.Errorf("variant %d: expected no constraints, got %v", i, dep.Constraints)
+			}
+		}
+
+		if !reflect.DeepEqual(deps[0], deps[1]) {
+			t.Errorf("option order changed the result:\n%+v\n%+v", deps[0], deps[1])
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		var deps []GemDependency
+		for _, content := range variants {
+			parser := &GemfileParser{content: content}
+			parsed, err := parser.parseContent()
+			if err != nil {
+				t.Fatalf("parseContent failed: %v", err)
+			}
+			if len(parsed.Dependencies) != 1 {
+				t.Fatalf("expected 1 dependency, got %d", len(parsed.Dependencies))
+			}
+			deps = append(deps, parsed.Dependencies[0])
+		}
+		check(t, deps)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		var deps []GemDependency
+		for _, content := range variants {
+			parser := NewTreeSitterGemfileParser([]byte(content))
+			parsed, err := parser.ParseWithTreeSitter()
+			if err != nil {
+				t.Fatalf("ParseWithTreeSitter failed: %v", err)
+			}
+			if len(parsed.Dependencies) != 1 {
+				t.Fatalf("expected 1 dependency, got %d", len(parsed.Dependencies))
+			}
+			deps = append(deps, parsed.Dependencies[0])
+		}
+		check(t, deps)
+	})
+}
+
+func TestInstallIfExpression(t *testing.T) {
+	gemfileContent := `gem 'rb-fsevent', '~> 0.11', install_if: -> { RUBY_PLATFORM =~ /darwin/ }
+gem 'wdm', install_if: -> { Gem.win_platform? }, require: false
+gem 'debug', install_if: (ENV['DEBUG'] == '1'), require: false # local only
+gem 'rails'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expected := map[string]string{
+			"rb-fsevent": "-> { RUBY_PLATFORM =~ /darwin/ }",
+			"wdm":        "-> { Gem.win_platform? }",
+			"debug":      "(ENV['DEBUG'] == '1')",
+			"rails":      "",
+		}
+		for name, want := range expected {
+			dep := findGem(parsed.Dependencies, name)
+			if dep == nil {
+				t.Fatalf("expected %s to be parsed", name)
+			}
+			if dep.InstallIfExpr != want {
+				t.Errorf("%s: expected install_if %q, got %q", name, want, dep.InstallIfExpr)
+			}
+		}
+
+		fsevent := findGem(parsed.Dependencies, "rb-fsevent")
+		if !reflect.DeepEqual(fsevent.Constraints, []string{"~> 0.11"}) {
+			t.Errorf("rb-fsevent: expected constraints [~> 0.11], got %v", fsevent.Constraints)
+		}
+		wdm := findGem(parsed.Dependencies, "wdm")
+		if wdm.Require == nil || *wdm.Require != "" {
+			t.Errorf("wdm: expected require: false, got %v", wdm.Require)
+		}
+		if len(parsed.Warnings) != 0 {
+			t.Errorf("expected no warnings, got %v", parsed.Warnings)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestInstallIfBlock(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+install_if -> { RUBY_PLATFORM =~ /darwin/ } do
+  gem 'rb-fsevent'
+
+  group :test do
+    gem 'terminal-notifier', require: false
+  end
+
+  install_if -> { ENV['CI'] } do
+    gem 'ci-reporter'
+  end
+end
+
+gem 'rails'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		darwin := "-> { RUBY_PLATFORM =~ /darwin/ }"
+		expected := map[string][]string{
+			"rb-fsevent":        {darwin},
+			"terminal-notifier": {darwin},
+			"ci-reporter":       {darwin, "-> { ENV['CI'] }"},
+			"rails":             nil,
+		}
+		for name, want := range expected {
+			dep := findGem(parsed.Dependencies, name)
+			if dep == nil {
+				t.Fatalf("expected %s to be parsed", name)
+			}
+			if !reflect.DeepEqual(dep.InstallIf, want) {
+				t.Errorf("%s: expected install_if block conditions %q, got %q", name, want, dep.InstallIf)
+			}
+			if dep.InstallIfExpr != "" {
+				t.Errorf("%s: expected no inline install_if, got %q", name, dep.InstallIfExpr)
+			}
+		}
+
+		notifier := findGem(parsed.Dependencies, "terminal-notifier")
+		if !reflect.DeepEqual(notifier.Groups, []string{"test"}) {
+			t.Errorf("terminal-notifier: expected groups [test], got %v", notifier.Groups)
+		}
+	}
+
+	forEachBackend(t, gemfileContent, check)
+}
+
+func TestParenthesizedOptionValue(t *testing.T) {
+	gemfileContent := `gem 'x', '~> 1.2', require: ('foo' if ENV['CI'])
+gem 'y', path: (ENV['Y_PATH'] || 'vendor/y')
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		x := findGem(parsed.Dependencies, "x")
+		if x == nil {
+			t.Fatal("expected x to be parsed")
+		}
+		if !reflect.DeepEqual(x.Constraints, []string{"~> 1.2"}) {
+			t.Errorf("x: expected constraints [~> 1.2], got %v", x.Constraints)
+		}
+		if x.Require != nil {
+			t.Errorf("x: expected dynamic require to be skipped, got %q", *x.Require)
+		}
+
+		y := findGem(parsed.Dependencies, "y")
+		if y == nil {
+			t.Fatal("expected y to be parsed")
+		}
+		if y.Source != nil {
+			t.Errorf("y: expected dynamic path to be skipped, got %+v", y.Source)
+		}
+
+		expected := []string{dynamicOptionWarning("x", "require"), dynamicOptionWarning("y", "path")}
+		if !reflect.DeepEqual(parsed.Warnings, expected) {
+			t.Errorf("expected warnings %v, got %v", expected, parsed.Warnings)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestParseGroupsStopsAtCondition(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{`group :development, :test do`, []string{"development", "test"}},
+		{`group :production if ENV.fetch("TARGET", :none) == :cloud`, []string{"production"}},
+		{`group :assets, :web unless ENV.key?(:SKIP_ASSETS)`, []string{"assets", "web"}},
+		{`group :ci if ENV[:CI] do`, []string{"ci"}},
+		{`group :dogfood do`, []string{"dogfood"}},
+	}
+
+	p := &GemfileParser{}
+	for _, tt := range tests {
+		if got := p.parseGroups(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseGroups(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestPluginDeclarations(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+plugin 'bundler-graph'
+plugin 'bundler-private', '~> 1.0', git: 'https://github.com/example/bundler-private.git', branch: 'main'
+plugin 'bundler-mirror', source: 'https://gems.example.com'
+
+gem 'rails'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		if len(parsed.Plugins) != 3 {
+			t.Fatalf("expected 3 plugins, got %+v", parsed.Plugins)
+		}
+		if len(parsed.Dependencies) != 1 || parsed.Dependencies[0].Name != "rails" {
+			t.Errorf("expected plugins not to be parsed as gems, got %+v", parsed.Dependencies)
+		}
+
+		graph := parsed.Plugins[0]
+		if graph.Name != "bundler-graph" || len(graph.Constraints) != 0 || graph.Source != nil {
+			t.Errorf("unexpected plain plugin: %+v", graph)
+		}
+
+		private := parsed.Plugins[1]
+		if private.Name != "bundler-private" || !reflect.DeepEqual(private.Constraints, []string{"~> 1.0"}) {
+			t.Errorf("unexpected git plugin: %+v", private)
+		}
+		if private.Source == nil || private.Source.Type != gitKey ||
+			private.Source.URL != "https://github.com/example/bundler-private.git" || private.Source.Branch != "main" {
+			t.Errorf("unexpected git plugin source: %+v", private.Source)
+		}
+
+		mirror := parsed.Plugins[2]
+		if mirror.Source == nil || mirror.Source.Type != rubygemsSource || mirror.Source.URL != "https://gems.example.com" {
+			t.Errorf("unexpected rubygems plugin source: %+v", mirror.Source)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestLegacySymbolSource(t *testing.T) {
+	gemfileContent := `source :rubygems
+
+gem 'rails'
+
+source :gemcutter do
+  gem 'rack'
+end
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		if len(parsed.Sources) != 2 {
+			t.Fatalf("expected 2 sources, got %+v", parsed.Sources)
+		}
+		for _, source := range parsed.Sources {
+			if source.Type != rubygemsSource || source.URL != rubygemsURL {
+				t.Errorf("expected legacy alias to map to %s, got %+v", rubygemsURL, source)
+			}
+		}
+
+		rack := findGem(parsed.Dependencies, "rack")
+		if rack == nil || rack.Source == nil || rack.Source.URL != rubygemsURL {
+			t.Errorf("expected rack to use the aliased source block, got %+v", rack)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestRegexParserSkipsHeredocs(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+NOTES = <<~DOC
+  Do not add these back:
+  gem 'paperclip'
+  group :legacy do
+end
+DOC
+
+gem 'rails', '~> 7.1'
+
+INSTALL_HINT = <<-'TXT'
+    gem 'sqlite3', '~> 1.4'
+    TXT
+
+gem 'pg'
+`
+
+	parser := &GemfileParser{content: gemfileContent}
+	parsed, err := parser.parseContent()
+	if err != nil {
+		t.Fatalf("parseContent failed: %v", err)
+	}
+
+	var names []string
+	for _, dep := range parsed.Dependencies {
+		names = append(names, dep.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"rails", "pg"}) {
+		t.Fatalf("expected only rails and pg, got %v", names)
+	}
+
+	for _, dep := range parsed.Dependencies {
+		if !reflect.DeepEqual(dep.Groups, []string{"default"}) {
+			t.Errorf("%s: expected default group, got %v", dep.Name, dep.Groups)
+		}
+	}
+}
+
+func TestInlinePlatformsInsidePlatformsBlock(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+platforms :mri, :jruby do
+  gem 'nokogiri'
+  gem 'byebug', platforms: [:mri, :windows]
+  gem 'ffi', platforms: :jruby
+end
+
+platforms :jruby do
+  gem 'pg', platforms: [:ruby]
+end
+`
+
+	parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+	parsed, err := parser.ParseWithTreeSitter()
+	if err != nil {
+		t.Fatalf("ParseWithTreeSitter failed: %v", err)
+	}
+
+	expected := map[string][]string{
+		"nokogiri": {"mri", "jruby"},
+		"byebug":   {"mri"},
+		"ffi":      {"jruby"},
+		"pg":       {"ruby"},
+	}
+	for name, want := range expected {
+		dep := findGem(parsed.Dependencies, name)
+		if dep == nil {
+			t.Fatalf("expected %s to be parsed", name)
+		}
+		if !reflect.DeepEqual(dep.Platforms, want) {
+			t.Errorf("%s: expected platforms %v, got %v", name, want, dep.Platforms)
+		}
+	}
+
+	if len(parsed.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %d: %v", len(parsed.Warnings), parsed.Warnings)
+	}
+	if !strings.Contains(parsed.Warnings[0], `"pg"`) || !strings.Contains(parsed.Warnings[0], "keeping the gem's own platforms") {
+		t.Errorf("unexpected warning: %q", parsed.Warnings[0])
+	}
+}
+
+func TestPathGemKeepsVersionConstraint(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'foo', '~> 1.0', path: '../foo'
+gem 'bar', '>= 2.1', '< 3', :path => '../bar', :require => false
+gem "baz", ">= 0.5", path: "vendor/baz" # local checkout
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expected := map[string]struct {
+			constraints []string
+			path        string
+		}{
+			"foo": {[]string{"~> 1.0"}, "../foo"},
+			"bar": {[]string{">= 2.1", "< 3"}, "../bar"},
+			"baz": {[]string{">= 0.5"}, "vendor/baz"},
+		}
+
+		for name, want := range expected {
+			dep := findGem(parsed.Dependencies, name)
+			if dep == nil {
+				t.Fatalf("expected %s to be parsed", name)
+			}
+			if !reflect.DeepEqual(dep.Constraints, want.constraints) {
+				t.Errorf("%s: expected constraints %v, got %v", name, want.constraints, dep.Constraints)
+			}
+			if dep.Source == nil || dep.Source.Type != pathSource || dep.Source.URL != want.path {
+				t.Errorf("%s: expected path source %q, got %+v", name, want.path, dep.Source)
+			}
+		}
+
+		if bar := findGem(parsed.Dependencies, "bar"); bar != nil && (bar.Require == nil || *bar.Require != "") {
+			t.Errorf("bar: expected require: false, got %v", bar.Require)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestMixedSymbolStringArrays(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'jdbc-postgres', platform: [:jruby, 'windows']
+gem 'rubocop', groups: ['development', :test]
+gem 'tzinfo-data', platforms: ["mingw", "mswin", :x64_mingw]
+gem 'rubocop-rails', groups: ['lint-ci', "ruby3.2", :test]
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		jdbc := findGem(parsed.Dependencies, "jdbc-postgres")
+		if jdbc == nil || !reflect.DeepEqual(jdbc.Platforms, []string{"jruby", "windows"}) {
+			t.Errorf("expected jdbc-postgres platforms [jruby windows], got %+v", jdbc)
+		}
+		rubocop := findGem(parsed.Dependencies, "rubocop")
+		if rubocop == nil || !reflect.DeepEqual(rubocop.Groups, []string{"development", "test"}) {
+			t.Errorf("expected rubocop groups [development test], got %+v", rubocop)
+		}
+		tzinfo := findGem(parsed.Dependencies, "tzinfo-data")
+		if tzinfo == nil || !reflect.DeepEqual(tzinfo.Platforms, []string{"mingw", "mswin", "x64_mingw"}) {
+			t.Errorf("expected tzinfo-data platforms [mingw mswin x64_mingw], got %+v", tzinfo)
+		}
+		// Quoted elements aren't limited to word characters
+		rubocopRails := findGem(parsed.Dependencies, "rubocop-rails")
+		if rubocopRails == nil || !reflect.DeepEqual(rubocopRails.Groups, []string{"lint-ci", "ruby3.2", "test"}) {
+			t.Errorf("expected rubocop-rails groups [lint-ci ruby3.2 test], got %+v", rubocopRails)
+		}
+	}
+
+	forEachBackend(t, gemfileContent, check)
+}
+
+func TestRubyVersionConstraints(t *testing.T) {
+	tests := []struct {
+		name        string
+		directive   string
+		version     string
+		constraints []string
+	}{
+		{"exact version", `ruby '3.2.0'`, "3.2.0", []string{"3.2.0"}},
+		{"requirement range", `ruby '>= 3.0', '< 3.3'`, ">= 3.0", []string{">= 3.0", "< 3.3"}},
+		{"engine options", `ruby "3.1.4", engine: "jruby", engine_version: "9.4.5.0"`, "3.1.4", []string{"3.1.4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gemfileContent := "source 'https://rubygems.org'\n\n" + tt.directive + "\n\ngem 'rack'\n"
+
+			check := func(t *testing.T, parsed *ParsedGemfile) {
+				t.Helper()
+
+				if parsed.RubyVersion != tt.version {
+					t.Errorf("expected ruby version %q, got %q", tt.version, parsed.RubyVersion)
+				}
+				if !reflect.DeepEqual(parsed.RubyVersionConstraints, tt.constraints) {
+					t.Errorf("expected ruby constraints %v, got %v", tt.constraints, parsed.RubyVersionConstraints)
+				}
+			}
+
+			t.Run("regex parser", func(t *testing.T) {
+				parser := &GemfileParser{content: gemfileContent}
+				parsed, err := parser.parseContent()
+				if err != nil {
+					t.Fatalf("parseContent failed: %v", err)
+				}
+				check(t, parsed)
+			})
+
+			t.Run("tree-sitter parser", func(t *testing.T) {
+				parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+				parsed, err := parser.ParseWithTreeSitter()
+				if err != nil {
+					t.Fatalf("ParseWithTreeSitter failed: %v", err)
+				}
+				check(t, parsed)
+			})
+		})
+	}
+}
+
+func TestGemWithBlock(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+group :test do
+  gem 'vcr-plugin', '~> 2.0', require: false do
+    cassette_dir 'spec/cassettes'
+    gem 'webmock'
+    if ENV['CI']
+      record :none
+    end
+  end
+
+  gem 'rspec'
+end
+
+gem 'rails'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		plugin := findGem(parsed.Dependencies, "vcr-plugin")
+		if plugin == nil {
+			t.Fatal("expected vcr-plugin to be parsed")
+		}
+		if !reflect.DeepEqual(plugin.Constraints, []string{"~> 2.0"}) || !reflect.DeepEqual(plugin.Groups, []string{"test"}) {
+			t.Errorf("vcr-plugin: unexpected constraints %v or groups %v", plugin.Constraints, plugin.Groups)
+		}
+		if plugin.Require == nil || *plugin.Require != "" {
+			t.Errorf("vcr-plugin: expected require: false, got %v", plugin.Require)
+		}
+
+		if findGem(parsed.Dependencies, "webmock") != nil {
+			t.Error("expected gems inside the gem block to be skipped")
+		}
+		// The block's end must not close the group, nor the group's end the file
+		if rspec := findGem(parsed.Dependencies, "rspec"); rspec == nil || !reflect.DeepEqual(rspec.Groups, []string{"test"}) {
+			t.Errorf("expected rspec in the test group, got %+v", rspec)
+		}
+		if rails := findGem(parsed.Dependencies, "rails"); rails == nil || !reflect.DeepEqual(rails.Groups, []string{"default"}) || rails.Source != nil {
+			t.Errorf("expected rails in the default group without a source, got %+v", rails)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parsed, err := NewTreeSitterGemfileParser([]byte(gemfileContent)).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestGemTrailingComma(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'rails',
+gem 'puma'
+
+group :test do
+  gem 'rspec', 
+end
+
+gem 'pg', '~> 1.5',
+`
+
+	parser := &GemfileParser{content: gemfileContent}
+	parsed, err := parser.parseContent()
+	if err != nil {
+		t.Fatalf("parseContent failed: %v", err)
+	}
+
+	// The dangling comma neither errors nor swallows the next line
+	expected := map[string]struct {
+		constraints []string
+		groups      []string
+	}{
+		"rails": {nil, []string{"default"}},
+		"puma":  {nil, []string{"default"}},
+		"rspec": {nil, []string{"test"}},
+		"pg":    {[]string{"~> 1.5"}, []string{"default"}},
+	}
+	if len(parsed.Dependencies) != len(expected) {
+		t.Fatalf("Expected %d dependencies, got %+v", len(expected), parsed.Dependencies)
+	}
+	for _, dep := range parsed.Dependencies {
+		want := expected[dep.Name]
+		if strings.Join(dep.Constraints, ",") != strings.Join(want.constraints, ",") || !reflect.DeepEqual(dep.Groups, want.groups) {
+			t.Errorf("%s: expected constraints %v and groups %v, got %v and %v", dep.Name, want.constraints, want.groups, dep.Constraints, dep.Groups)
+		}
+		if dep.Source != nil || dep.Require != nil || len(dep.Platforms) != 0 {
+			t.Errorf("%s: expected no options, got %+v", dep.Name, dep)
+		}
+	}
+}
+
+func TestStringKeyHashRocketOptions(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'bootsnap', '>= 1.4', "require" => false
+gem 'pry', 'group' => :development
+gem 'nokogiri', "platforms" => [:ruby, :jruby], "path" => "vendor/nokogiri"
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		bootsnap := findGem(parsed.Dependencies, "bootsnap")
+		if bootsnap == nil || !reflect.DeepEqual(bootsnap.Constraints, []string{">= 1.4"}) {
+			t.Fatalf("expected bootsnap with constraint >= 1.4, got %+v", bootsnap)
+		}
+		if bootsnap.Require == nil || *bootsnap.Require != "" {
+			t.Errorf("bootsnap: expected require: false, got %v", bootsnap.Require)
+		}
+
+		if pry := findGem(parsed.Dependencies, "pry"); pry == nil || !reflect.DeepEqual(pry.Groups, []string{"development"}) || len(pry.Constraints) != 0 {
+			t.Errorf("expected pry in the development group without constraints, got %+v", pry)
+		}
+
+		nokogiri := findGem(parsed.Dependencies, "nokogiri")
+		if nokogiri == nil || !reflect.DeepEqual(nokogiri.Platforms, []string{"ruby", "jruby"}) {
+			t.Fatalf("expected nokogiri platforms [ruby jruby], got %+v", nokogiri)
+		}
+		if nokogiri.Source == nil || nokogiri.Source.Type != pathSource || nokogiri.Source.URL != "vendor/nokogiri" {
+			t.Errorf("nokogiri: expected path source vendor/nokogiri, got %+v", nokogiri.Source)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parsed, err := NewTreeSitterGemfileParser([]byte(gemfileContent)).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestPlatformsBlockInsideGroup(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+group :test do
+  gem 'a'
+  platforms :ruby do
+    gem 'b'
+  end
+  gem 'c'
+end
+`
+
+	checkGroups := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		for _, name := range []string{"a", "b", "c"} {
+			dep := findGem(parsed.Dependencies, name)
+			if dep == nil || !reflect.DeepEqual(dep.Groups, []string{"test"}) {
+				t.Errorf("expected %s in the test group, got %+v", name, dep)
+			}
+		}
+		for _, name := range []string{"a", "c"} {
+			if dep := findGem(parsed.Dependencies, name); dep != nil && len(dep.Platforms) != 0 {
+				t.Errorf("expected %s without platforms, got %v", name, dep.Platforms)
+			}
+		}
+	}
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parsed, err := NewTreeSitterGemfileParser([]byte(gemfileContent)).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		checkGroups(t, parsed)
+
+		// The platforms context is popped at its end, so only b keeps it
+		if b := findGem(parsed.Dependencies, "b"); b == nil || !reflect.DeepEqual(b.Platforms, []string{"ruby"}) {
+			t.Errorf("expected b with platforms [ruby], got %+v", b)
+		}
+	})
+
+	// The regex parser doesn't assign platforms from blocks yet, but the
+	// block's end must still not close the enclosing group
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		checkGroups(t, parsed)
+	})
+}
+
+func TestInlineSourceWithGroup(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'x', source: 'https://gem.coop', group: :test
+gem 'y', group: :development, source: 'https://gem.coop'
+gem 'v', :source => 'https://gem.coop', :group => :test
+gem 'z', '~> 1.0', source: 'https://gitlab.example.com/api/v4/groups/7/-/packages/rubygems', groups: [:test, :ci]
+
+group :development do
+  gem 'w', source: 'https://gem.coop'
+end
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expected := []struct {
+			name   string
+			url    string
+			groups []string
+		}{
+			{"x", "https://gem.coop", []string{"test"}},
+			{"y", "https://gem.coop", []string{"development"}},
+			{"v", "https://gem.coop", []string{"test"}},
+			{"z", "https://gitlab.example.com/api/v4/groups/7/-/packages/rubygems", []string{"test", "ci"}},
+			{"w", "https://gem.coop", []string{"development"}},
+		}
+		for _, want := range expected {
+			dep := findGem(parsed.Dependencies, want.name)
+			if dep == nil {
+				t.Errorf("gem %s not found", want.name)
+				continue
+			}
+			if dep.Source == nil || dep.Source.Type != rubygemsSource || dep.Source.URL != want.url {
+				t.Errorf("%s: expected rubygems source %s, got %+v", want.name, want.url, dep.Source)
+			}
+			if !reflect.DeepEqual(dep.Groups, want.groups) {
+				t.Errorf("%s: expected groups %v, got %v", want.name, want.groups, dep.Groups)
+			}
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parsed, err := (&GemfileParser{content: gemfileContent}).parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parsed, err := NewTreeSitterGemfileParser([]byte(gemfileContent)).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestCustomGitSources(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+git_source(:gitlab) { |repo| "https://gitlab.com/#{repo}.git" }
+git_source(:bitbucket) do |repo_name| "https://bitbucket.org/#{repo_name}.git" end
+git_source(:github) { |name| "https://github.example.com/#{name}.git" }
+
+group :test do
+  gem 'x', gitlab: 'org/x', branch: 'main'
+  gem 'y', bitbucket: 'team/y', tag: 'v1.0'
+end
+
+gem 'z', github: 'rails/z'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expectedSources := map[string]string{
+			"gitlab":    "https://gitlab.com/#{repo}.git",
+			"bitbucket": "https://bitbucket.org/#{repo}.git",
+			"github":    "https://github.example.com/#{repo}.git",
+		}
+		if !reflect.DeepEqual(parsed.GitSources, expectedSources) {
+			t.Errorf("expected git sources %v, got %v", expectedSources, parsed.GitSources)
+		}
+
+		expected := []struct {
+			name   string
+			source Source
+			groups []string
+		}{
+			{"x", Source{Type: "git", URL: "https://gitlab.com/org/x.git", Branch: "main"}, []string{"test"}},
+			{"y", Source{Type: "git", URL: "https://bitbucket.org/team/y.git", Tag: "v1.0"}, []string{"test"}},
+			{"z", Source{Type: "git", URL: "https://github.example.com/rails/z.git"}, []string{"default"}},
+		}
+		for _, want := range expected {
+			dep := findGem(parsed.Dependencies, want.name)
+			if dep == nil {
+				t.Errorf("gem %s not found", want.name)
+				continue
+			}
+			if dep.Source == nil || *dep.Source != want.source {
+				t.Errorf("%s: expected source %+v, got %+v", want.name, want.source, dep.Source)
+			}
+			if !reflect.DeepEqual(dep.Groups, want.groups) {
+				t.Errorf("%s: expected groups %v, got %v", want.name, want.groups, dep.Groups)
+			}
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parsed, err := (&GemfileParser{content: gemfileContent}).parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parsed, err := NewTreeSitterGemfileParser([]byte(gemfileContent)).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestCustomGitSourcesMultiLine(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+git_source(:internal) do |repo|
+  "https://git.example.com/#{repo}.git"
+end
+
+git_source(:mirror) { |repo|
+  'https://mirror.example.com/repo.git'
+}
+
+git_source(:computed) do |repo|
+  url = "https://example.com/#{repo}.git"
+  puts url
+end
+
+group :test do
+  gem 'x', internal: 'org/x'
+end
+gem 'y', mirror: 'team/y'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expectedSources := map[string]string{
+			"internal": "https://git.example.com/#{repo}.git",
+			"mirror":   "https://mirror.example.com/repo.git",
+		}
+		if !reflect.DeepEqual(parsed.GitSources, expectedSources) {
+			t.Errorf("expected git sources %v, got %v", expectedSources, parsed.GitSources)
+		}
+
+		x := findGem(parsed.Dependencies, "x")
+		if x == nil || x.Source == nil || x.Source.URL != "https://git.example.com/org/x.git" {
+			t.Errorf("x: expected internal git source, got %+v", x)
+		} else if !reflect.DeepEqual(x.Groups, []string{"test"}) {
+			t.Errorf("x: expected groups [test], got %v", x.Groups)
+		}
+
+		y := findGem(parsed.Dependencies, "y")
+		if y == nil || y.Source == nil || y.Source.URL != "https://mirror.example.com/repo.git" {
+			t.Errorf("y: expected mirror git source, got %+v", y)
+		} else if !reflect.DeepEqual(y.Groups, []string{"default"}) {
+			t.Errorf("y: expected default group, got %v", y.Groups)
+		}
+	}
+
+	forEachBackend(t, gemfileContent, check)
+}
+
+func TestShorthandOptionValue(t *testing.T) {
+	gemfileContent := `require = false
+gem 'x', '~> 1.0', require:, platforms: :ruby
+gem 'y', require:, group: :test
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		x := findGem(parsed.Dependencies, "x")
+		if x == nil {
+			t.Fatal("expected x to be parsed")
+		}
+		if !reflect.DeepEqual(x.Constraints, []string{"~> 1.0"}) {
+			t.Errorf("x: expected constraints [~> 1.0], got %v", x.Constraints)
+		}
+		if x.Require != nil {
+			t.Errorf("x: expected shorthand require to be skipped, got %q", *x.Require)
+		}
+
+		y := findGem(parsed.Dependencies, "y")
+		if y == nil {
+			t.Fatal("expected y to be parsed")
+		}
+		if y.Require != nil {
+			t.Errorf("y: expected shorthand require to be skipped, got %q", *y.Require)
+		}
+		if !reflect.DeepEqual(y.Groups, []string{"test"}) {
+			t.Errorf("y: expected groups [test], got %v", y.Groups)
+		}
+
+		expected := []string{dynamicOptionWarning("x", "require"), dynamicOptionWarning("y", "require")}
+		if !reflect.DeepEqual(parsed.Warnings, expected) {
+			t.Errorf("expected warnings %v, got %v", expected, parsed.Warnings)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parsed, err := (&GemfileParser{content: gemfileContent}).parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parsed, err := NewTreeSitterGemfileParser([]byte(gemfileContent)).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
@@ -0,0 +1,80 @@
+package gemfile
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Platform families a lockfile platform string falls into
+const (
+	rubyFamily    = "ruby"
+	javaFamily    = "java"
+	mswinFamily   = "mswin"
+	mingwFamily   = "mingw"
+	unknownFamily = ""
+)
+
+// rubyVersionSuffixRe matches the Ruby version suffix of platform symbols like :mri_31 or :windows_27
+var rubyVersionSuffixRe = regexp.MustCompile(`_\d+$`)
+
+// platformFamilies maps Gemfile platform symbols (without version suffix) to
+// the lockfile platform families they install on
+var platformFamilies = map[string][]string{
+	"ruby":        {rubyFamily},
+	"mri":         {rubyFamily},
+	"rbx":         {rubyFamily},
+	"truffleruby": {rubyFamily},
+	"jruby":       {javaFamily},
+	"windows":     {mswinFamily, mingwFamily},
+	"mswin":       {mswinFamily},
+	"mswin64":     {mswinFamily},
+	"mingw":       {mingwFamily},
+	"x64_mingw":   {mingwFamily},
+}
+
+// PlatformMatches reports whether a gem restricted to gemPlatforms (Gemfile
+// symbols such as "ruby", "jruby" or "windows_31") is installed on the
+// lockfile platform target (such as "ruby", "java" or "x86_64-linux").
+// A gem without platform restrictions matches every target.
+//
+// As in Bundler, :ruby and :mri mean C Ruby on anything but Windows, so they
+// match "ruby" and native platforms like "arm64-darwin" but not "java" or
+// "x64-mingw-ucrt". Unknown symbols only match a target spelled the same way.
+func PlatformMatches(gemPlatforms []string, target string) bool {
+	if len(gemPlatforms) == 0 {
+		return true
+	}
+
+	family := platformFamily(target)
+	for _, platform := range gemPlatforms {
+		platform = strings.TrimPrefix(platform, ":")
+		families, known := platformFamilies[rubyVersionSuffixRe.ReplaceAllString(platform, "")]
+		if !known {
+			if platform == target {
+				return true
+			}
+			continue
+		}
+		if slices.Contains(families, family) {
+			return true
+		}
+	}
+	return false
+}
+
+// platformFamily classifies a lockfile platform string
+func platformFamily(target string) string {
+	switch {
+	case target == "":
+		return unknownFamily
+	case strings.Contains(target, "java") || target == "jruby":
+		return javaFamily
+	case strings.Contains(target, "mswin"):
+		return mswinFamily
+	case strings.Contains(target, "mingw"):
+		return mingwFamily
+	default:
+		return rubyFamily
+	}
+}
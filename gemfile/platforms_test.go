@@ -0,0 +1,35 @@
+package gemfile
+
+import "testing"
+
+func TestPlatformMatches(t *testing.T) {
+	tests := []struct {
+		platforms []string
+		target    string
+		expected  bool
+	}{
+		{nil, "java", true},
+		{[]string{"ruby"}, "java", false},
+		{[]string{"ruby"}, "universal-java-11", false},
+		{[]string{"ruby"}, "x86_64-linux", true},
+		{[]string{"ruby"}, "ruby", true},
+		{[]string{"ruby"}, "arm64-darwin-23", true},
+		{[]string{"ruby"}, "x64-mingw-ucrt", false},
+		{[]string{":mri"}, "x86_64-linux-musl", true},
+		{[]string{"mri_31"}, "x86_64-linux", true},
+		{[]string{"jruby"}, "java", true},
+		{[]string{"jruby"}, "x86_64-linux", false},
+		{[]string{"windows"}, "x64-mingw-ucrt", true},
+		{[]string{"windows_31"}, "x86-mswin32", true},
+		{[]string{"mingw"}, "x86-mswin32", false},
+		{[]string{"mri", "jruby"}, "java", true},
+		{[]string{"x86_64-linux"}, "x86_64-linux", true},
+		{[]string{"ruby"}, "", false},
+	}
+
+	for _, tt := range tests {
+		if got := PlatformMatches(tt.platforms, tt.target); got != tt.expected {
+			t.Errorf("PlatformMatches(%v, %q) = %v, expected %v", tt.platforms, tt.target, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package gemfile
+
+import (
+	"slices"
+
+	"github.com/contriboss/gemfile-go/internal/gemversion"
+)
+
+// UnpinnedGitGems returns the names of git-sourced gems that are not pinned to
+// a tag or ref. Gems tracking a branch (or the default branch) resolve to
+// whatever commit is current at install time, which breaks reproducible builds.
+// Names are returned in Gemfile order.
+func (p *ParsedGemfile) UnpinnedGitGems() []string {
+	var unpinned []string
+	for i := range p.Dependencies {
+		dep := &p.Dependencies[i]
+		if dep.Source == nil || dep.Source.Type != gitKey {
+			continue
+		}
+		if dep.Source.Tag != "" || dep.Source.Ref != "" {
+			continue
+		}
+		if !slices.Contains(unpinned, dep.Name) {
+			unpinned = append(unpinned, dep.Name)
+		}
+	}
+
+	return unpinned
+}
+
+// ConstraintConflicts returns the names of gems declared in the Gemfile whose
+// version constraints can't be satisfied together with the constraints their
+// gemspec (loaded through a gemspec directive) puts on the same gem, e.g.
+// gem 'rails', '~> 8.0' next to a gemspec requiring rails ~> 7.0.
+// Names are returned in Gemfile order. Unparseable constraints are ignored.
+func (p *ParsedGemfile) ConstraintConflicts() []string {
+	fromGemspec := make(map[string][]string)
+	for i := range p.Dependencies {
+		if dep := &p.Dependencies[i]; dep.FromGemspec {
+			fromGemspec[dep.Name] = append(fromGemspec[dep.Name], dep.Constraints...)
+		}
+	}
+
+	var conflicts []string
+	for i := range p.Dependencies {
+		dep := &p.Dependencies[i]
+		gemspecConstraints, found := fromGemspec[dep.Name]
+		if dep.FromGemspec || !found || slices.Contains(conflicts, dep.Name) {
+			continue
+		}
+		ok, err := gemversion.Intersects(append(slices.Clone(dep.Constraints), gemspecConstraints...))
+		if err == nil && !ok {
+			conflicts = append(conflicts, dep.Name)
+		}
+	}
+
+	return conflicts
+}
+
+// AllGroups returns the sorted, unique names of the groups the dependencies
+// belong to, including default for gems declared outside any group.
+func (p *ParsedGemfile) AllGroups() []string {
+	var groups []string
+	for i := range p.Dependencies {
+		depGroups := p.Dependencies[i].Groups
+		if len(depGroups) == 0 {
+			depGroups = []string{defaultGroup}
+		}
+		for _, group := range depGroups {
+			if !slices.Contains(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	slices.Sort(groups)
+	return groups
+}
+
+// RequirePath returns the file Bundler requires for the gem: the require option
+// when one is given, the gem name otherwise, and "" for require: false.
+func (d *GemDependency) RequirePath() string {
+	if d.Require == nil {
+		return d.Name
+	}
+	return *d.Require
+}
@@ -0,0 +1,169 @@
+package gemfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnpinnedGitGems(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'rails', '~> 7.0'
+gem 'state_machines', github: 'state-machines/state_machines', branch: 'master'
+gem 'no_fly_list', github: 'seuros/no_fly_list', tag: 'v0.6.0'
+gem 'internal', git: 'https://git.example.com/internal.git', ref: 'abc123'
+gem 'floating', git: 'https://git.example.com/floating.git'
+gem 'local', path: 'vendor/local'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expected := []string{"state_machines", "floating"}
+		if got := parsed.UnpinnedGitGems(); strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected unpinned git gems %v, got %v", expected, got)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestAllGroups(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'rails', '~> 7.0'
+gem 'bootsnap', require: false
+
+group :development, :test do
+  gem 'debug'
+end
+
+group :test do
+  gem 'capybara'
+end
+
+gem 'rubocop', groups: [:lint, :development]
+gem 'simplecov', group: [:coverage], require: false
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		expected := []string{"coverage", "default", "development", "lint", "test"}
+		if got := parsed.AllGroups(); strings.Join(got, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected groups %v, got %v", expected, got)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	if groups := (&ParsedGemfile{}).AllGroups(); len(groups) != 0 {
+		t.Errorf("Expected no groups for an empty Gemfile, got %v", groups)
+	}
+}
+
+func TestConstraintConflicts(t *testing.T) {
+	dir := t.TempDir()
+	gemspecContent := `Gem::Specification.new do |spec|
+  spec.name = "engine"
+  spec.version = "0.1.0"
+  spec.add_dependency "rails", "~> 7.0"
+  spec.add_dependency "rack", ">= 2.2"
+  spec.add_development_dependency "rspec", "~> 3.12"
+end
+`
+	gemfileContent := `source 'https://rubygems.org'
+
+gemspec
+
+gem 'rails', '~> 8.0'
+gem 'rack', '< 3'
+gem 'rspec', '>= 3.13'
+gem 'puma', '~> 6.0'
+`
+	gemfilePath := filepath.Join(dir, "Gemfile")
+	if err := os.WriteFile(filepath.Join(dir, "engine.gemspec"), []byte(gemspecContent), 0600); err != nil {
+		t.Fatalf("Failed to write gemspec: %v", err)
+	}
+	if err := os.WriteFile(gemfilePath, []byte(gemfileContent), 0600); err != nil {
+		t.Fatalf("Failed to write Gemfile: %v", err)
+	}
+
+	parsed, err := NewGemfileParser(gemfilePath).Parse()
+	if err != nil {
+		t.Fatalf("Failed to parse Gemfile: %v", err)
+	}
+
+	expected := []string{"rails"}
+	if got := parsed.ConstraintConflicts(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected constraint conflicts %v, got %v", expected, got)
+	}
+
+	// Without a gemspec there is nothing to conflict with
+	plain := &ParsedGemfile{Dependencies: []GemDependency{{Name: "rails", Constraints: []string{"~> 8.0"}}}}
+	if got := plain.ConstraintConflicts(); len(got) != 0 {
+		t.Errorf("Expected no conflicts, got %v", got)
+	}
+}
+
+func TestRequirePath(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'rails'
+gem 'rspec-rails', require: 'rspec/rails'
+gem 'bootsnap', require: false
+`
+	parsed, err := (&GemfileParser{content: gemfileContent}).parseContent()
+	if err != nil {
+		t.Fatalf("parseContent failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"rails":       "rails",
+		"rspec-rails": "rspec/rails",
+		"bootsnap":    "",
+	}
+	for name, want := range expected {
+		dep := findGem(parsed.Dependencies, name)
+		if dep == nil {
+			t.Fatalf("gem %s not found", name)
+		}
+		if got := dep.RequirePath(); got != want {
+			t.Errorf("%s: expected require path %q, got %q", name, want, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package gemfile
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gitHubURLRe matches the URL forms of a GitHub repository: https, git and ssh
+// URLs and the scp-like git@github.com:owner/repo, with or without .git
+var gitHubURLRe = regexp.MustCompile(
+	`(?i)^(?:(?:https?|git|ssh)://(?:[^@/]+@)?(?:www\.)?github\.com/|git@github\.com:)([^/]+/[^/]+?)(?:\.git)?/?$`)
+
+// Canonical returns a copy of the source with its URL normalized, so that
+// different spellings of the same location compare equal:
+//
+//	github: 'rails/rails'                     => https://github.com/rails/rails
+//	git: 'git@github.com:rails/rails.git'     => https://github.com/rails/rails
+//	git: 'https://gitlab.com/org/repo.git/'   => https://gitlab.com/org/repo
+//	source 'https://rubygems.org/'            => https://rubygems.org
+//
+// GitHub owner and repository names are case-insensitive and lowercased.
+// Branch, tag and ref are kept as is.
+func (s *Source) Canonical() Source {
+	canonical := *s
+	url := strings.TrimSpace(s.URL)
+
+	switch s.Type {
+	case gitKey:
+		if matches := gitHubURLRe.FindStringSubmatch(url); matches != nil {
+			url = "https://github.com/" + strings.ToLower(matches[1])
+		} else {
+			url = strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+		}
+	case pathSource:
+		if url != "" {
+			url = filepath.Clean(url)
+		}
+	default:
+		url = strings.TrimRight(url, "/")
+	}
+
+	canonical.URL = url
+	return canonical
+}
+
+// ResolvePath returns the directory of a path source, resolved against baseDir
+// (usually the Gemfile's directory) unless it is already absolute. The URL itself
+// is left as written; only the result uses the separators of the current OS.
+// Other source types resolve to "".
+func (s *Source) ResolvePath(baseDir string) string {
+	if s.Type != pathSource || s.URL == "" {
+		return ""
+	}
+	return resolvePath(baseDir, s.URL)
+}
+
+// resolvePath joins path onto baseDir unless it is absolute, accepting forward
+// slashes on every OS
+func resolvePath(baseDir, path string) string {
+	path = filepath.FromSlash(path)
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(baseDir, path)
+}
+
+// Equal reports whether two sources point at the same location once
+// canonicalized, e.g. github: 'user/repo' and git: 'https://github.com/user/repo'.
+// Two nil sources are equal.
+func (s *Source) Equal(other *Source) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	return s.Canonical() == other.Canonical()
+}
+
+// gitSourceTemplate turns the string a git_source block returns into a URL
+// template, writing the block parameter as #{repo} whatever it is called
+func gitSourceTemplate(body, param string) string {
+	if param == "" {
+		return body
+	}
+	return strings.ReplaceAll(body, "#{"+param+"}", gitSourceRepoVar)
+}
+
+// expandGitSource substitutes an option value, e.g. "org/repo", into a git_source template
+func expandGitSource(template, repo string) string {
+	return strings.ReplaceAll(template, gitSourceRepoVar, repo)
+}
@@ -0,0 +1,144 @@
+package gemfile
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceEqual(t *testing.T) {
+	github := &Source{Type: gitKey, URL: "https://github.com/rails/rails.git"}
+
+	equivalent := []string{
+		"https://github.com/rails/rails",
+		"https://github.com/rails/rails/",
+		"http://www.github.com/rails/rails.git",
+		"git://github.com/rails/rails.git",
+		"ssh://git@github.com/rails/rails.git",
+		"git@github.com:rails/rails.git",
+		"git@github.com:Rails/Rails",
+	}
+	for _, url := range equivalent {
+		if other := (&Source{Type: gitKey, URL: url}); !github.Equal(other) {
+			t.Errorf("Expected %s to equal %s", url, github.URL)
+		}
+	}
+
+	different := []*Source{
+		{Type: gitKey, URL: "https://github.com/rails/rails-html-sanitizer.git"},
+		{Type: gitKey, URL: "https://gitlab.com/rails/rails.git"},
+		{Type: gitKey, URL: "https://github.com/rails/rails.git", Branch: "main"},
+		{Type: pathSource, URL: "https://github.com/rails/rails.git"},
+		nil,
+	}
+	for _, other := range different {
+		if github.Equal(other) {
+			t.Errorf("Expected %+v to differ from %s", other, github.URL)
+		}
+	}
+
+	var none *Source
+	if !none.Equal(nil) {
+		t.Error("Expected two nil sources to be equal")
+	}
+	if !(&Source{Type: rubygemsSource, URL: "https://rubygems.org/"}).Equal(&Source{Type: rubygemsSource, URL: "https://rubygems.org"}) {
+		t.Error("Expected rubygems sources to ignore a trailing slash")
+	}
+	if !(&Source{Type: pathSource, URL: "./vendor/gems/../engine"}).Equal(&Source{Type: pathSource, URL: "vendor/engine"}) {
+		t.Error("Expected path sources to compare cleaned paths")
+	}
+}
+
+func TestSourceCanonical(t *testing.T) {
+	tests := []struct {
+		source   Source
+		expected string
+	}{
+		{Source{Type: gitKey, URL: "git@github.com:rails/rails.git"}, "https://github.com/rails/rails"},
+		{Source{Type: gitKey, URL: "https://gitlab.com/org/repo.git/"}, "https://gitlab.com/org/repo"},
+		{Source{Type: gitKey, URL: "git@gitlab.com:org/repo.git"}, "git@gitlab.com:org/repo"},
+		{Source{Type: rubygemsSource, URL: "https://gems.example.com/private/"}, "https://gems.example.com/private"},
+		{Source{Type: pathSource, URL: "../engines/billing/"}, "../engines/billing"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.source.Canonical(); got.URL != tt.expected || got.Type != tt.source.Type {
+			t.Errorf("Canonical(%+v) = %+v, expected URL %s", tt.source, got, tt.expected)
+		}
+	}
+}
+
+func TestSourceEqualAcrossSyntax(t *testing.T) {
+	content := `gem 'rails', github: 'rails/rails'
+gem 'rack', git: 'https://github.com/rack/rack'
+`
+	parsed, err := (&GemfileParser{content: content}).parseContent()
+	if err != nil {
+		t.Fatalf("parseContent failed: %v", err)
+	}
+
+	expected := map[string]*Source{
+		"rails": {Type: gitKey, URL: "https://github.com/rails/rails"},
+		"rack":  {Type: gitKey, URL: "git@github.com:rack/rack.git"},
+	}
+	for _, dep := range parsed.Dependencies {
+		if !dep.Source.Equal(expected[dep.Name]) {
+			t.Errorf("Expected %s source %+v to equal %+v", dep.Name, dep.Source, expected[dep.Name])
+		}
+	}
+}
+
+func TestWindowsPathSource(t *testing.T) {
+	gemfileContent := `gem 'local_gem', path: 'C:\gems\local_gem'
+gem 'engine', path: '../engines/engine'
+`
+	baseDir := filepath.Join("projects", "app")
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+		local := findGem(parsed.Dependencies, "local_gem")
+		if local == nil || local.Source == nil {
+			t.Fatalf("expected local_gem with a path source, got %+v", local)
+		}
+		// The path is kept exactly as written, backslashes and drive letter included
+		if local.Source.Type != pathSource || local.Source.URL != `C:\gems\local_gem` {
+			t.Errorf("expected raw path source, got %+v", local.Source)
+		}
+
+		// C:\ is only absolute on Windows; elsewhere it resolves like any relative path
+		expected := filepath.Join(baseDir, `C:\gems\local_gem`)
+		if filepath.IsAbs(`C:\gems\local_gem`) {
+			expected = `C:\gems\local_gem`
+		}
+		if got := local.Source.ResolvePath(baseDir); got != expected {
+			t.Errorf("expected local_gem to resolve to %s, got %s", expected, got)
+		}
+
+		engine := findGem(parsed.Dependencies, "engine")
+		if engine == nil || engine.Source == nil {
+			t.Fatalf("expected engine with a path source, got %+v", engine)
+		}
+		if got, want := engine.Source.ResolvePath(baseDir), filepath.Join("projects", "engines", "engine"); got != want {
+			t.Errorf("expected engine to resolve to %s, got %s", want, got)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parsed, err := (&GemfileParser{content: gemfileContent}).parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parsed, err := NewTreeSitterGemfileParser([]byte(gemfileContent)).ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	if got := (&Source{Type: gitKey, URL: "https://github.com/rails/rails"}).ResolvePath(baseDir); got != "" {
+		t.Errorf("expected git sources to resolve to an empty path, got %q", got)
+	}
+}
@@ -2,6 +2,8 @@
 package gemfile
 
 import (
+	"strings"
+
 	tree_sitter "github.com/tree-sitter/go-tree-sitter"
 	tree_sitter_ruby "github.com/tree-sitter/tree-sitter-ruby/bindings/go"
 )
@@ -13,28 +15,33 @@ var (
 
 // Tree-sitter node type constants for Ruby AST
 const (
-	nodeCall             = "call"
-	nodeBlock            = "block"
-	nodeDoBlock          = "do_block"
-	nodeScopeResolution  = "scope_resolution"
-	nodeIdentifier       = "identifier"
-	nodeElementReference = "element_reference"
-	nodeArray            = "array"
-	nodeString           = "string"
-	nodeStringContent    = "string_content"
-	nodeConstant         = "constant"
-	nodeSymbol           = "symbol"
-	nodeSimpleSymbol     = "simple_symbol"
-	nodeInteger          = "integer"
-	nodeBodyStatement    = "body_statement"
-	nodeAssignment       = "assignment"
-	nodeArgumentList     = "argument_list"
-	nodeMethod           = "method"
-	nodeIf               = "if"
-	nodeUnless           = "unless"
-	nodeMethodCall       = "method_call"
-	nodePair             = "pair"
-	nodeHashKeySymbol    = "hash_key_symbol"
+	nodeCall                    = "call"
+	nodeBlock                   = "block"
+	nodeDoBlock                 = "do_block"
+	nodeScopeResolution         = "scope_resolution"
+	nodeIdentifier              = "identifier"
+	nodeElementReference        = "element_reference"
+	nodeArray                   = "array"
+	nodeString                  = "string"
+	nodeStringContent           = "string_content"
+	nodeEscapeSequence          = "escape_sequence"
+	nodeInterpolation           = "interpolation"
+	nodeConstant                = "constant"
+	nodeSymbol                  = "symbol"
+	nodeSimpleSymbol            = "simple_symbol"
+	nodeInteger                 = "integer"
+	nodeBodyStatement           = "body_statement"
+	nodeBlockBody               = "block_body"
+	nodeAssignment              = "assignment"
+	nodeArgumentList            = "argument_list"
+	nodeMethod                  = "method"
+	nodeIf                      = "if"
+	nodeUnless                  = "unless"
+	nodeMethodCall              = "method_call"
+	nodePair                    = "pair"
+	nodeHashKeySymbol           = "hash_key_symbol"
+	nodeParenthesizedStatements = "parenthesized_statements"
+	nodeBinary                  = "binary"
 )
 
 // Ruby keyword and method name constants
@@ -43,9 +50,12 @@ const (
 	groupMethod      = "group"
 	platformMethod   = "platform"
 	platformsMethod  = "platforms"
+	pluginMethod     = "plugin"
 	gitKey           = "git"
 	githubKey        = "github"
 	groupsKey        = "groups"
+	installIfKey     = "install_if"
+	forceRubyKey     = "force_ruby_platform"
 	sourceKey        = "source"
 	trueValue        = "true"
 	falseValue       = "false"
@@ -72,14 +82,27 @@ func (h *RubyASTHelper) ExtractStringValue(node *tree_sitter.Node) string {
 		return ""
 	}
 
-	// For string nodes, look for string_content child
+	// For string nodes, join the string_content and escape_sequence children up to
+	// the first interpolation. Escapes are kept verbatim, so a Windows path such as
+	// "C:\gems\x" reads the same as in the regex parser.
 	if node.Kind() == nodeString {
+		var value strings.Builder
+		found := false
 		for i := uint(0); i < node.ChildCount(); i++ {
 			child := node.Child(i)
-			if child.Kind() == nodeStringContent {
-				return h.GetNodeText(child)
+			switch child.Kind() {
+			case nodeStringContent, nodeEscapeSequence:
+				value.WriteString(h.GetNodeText(child))
+				found = true
+			case nodeInterpolation:
+				if found {
+					return value.String()
+				}
 			}
 		}
+		if found {
+			return value.String()
+		}
 		// Fallback to full text and strip quotes
 		text := h.GetNodeText(node)
 		if len(text) >= 2 {
@@ -0,0 +1,141 @@
+package gemfile
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// dynamicGemLoopRe matches loops over Dir[] or Dir.glob results, e.g.
+// Dir['engines/*'].each { |path| gem File.basename(path), path: path }
+var dynamicGemLoopRe = regexp.MustCompile(`^Dir(?:\[|\.glob\b).*\.each`)
+
+// dynamicOptionRe matches gem options whose value is a parenthesized expression,
+// e.g. require: ('foo' if cond)
+var dynamicOptionRe = regexp.MustCompile(`\b(\w+):\s*\(`)
+
+// shorthandOptionRe matches Ruby 3.1 shorthand options, which take their value
+// from a local variable of the same name, e.g. gem 'x', require:, group: :test.
+// At the end of a line Ruby reads the next line as the value, so only options
+// followed by a comma are shorthand.
+var shorthandOptionRe = regexp.MustCompile(`\b(\w+):\s*,`)
+
+// gemTypos lists misspellings of the gem directive reported in lint mode
+var gemTypos = map[string]bool{"gems": true, "Gem": true, "Gems": true, "gemm": true}
+
+// gemTypoRe matches a directive called with a string argument, e.g. gems 'rails' or gems('rails')
+var gemTypoRe = regexp.MustCompile(`^(\w+)(?:\s+|\s*\(\s*)['"]`)
+
+// gemTypoWarning describes a line that looks like a misspelled gem declaration
+func gemTypoWarning(line int, directive string) string {
+	return fmt.Sprintf("line %d: unknown directive %q, did you mean gem?", line, directive)
+}
+
+// dynamicOptionWarning describes a gem option that was skipped because its value can't be resolved statically
+func dynamicOptionWarning(gemName, key string) string {
+	return fmt.Sprintf("gem %q: %s: option uses a dynamic expression and was skipped", gemName, key)
+}
+
+// disjointPlatformsWarning describes a gem whose platforms don't overlap those of its platforms block.
+// Only the tree-sitter parser applies platforms blocks, so only it reports this.
+func disjointPlatformsWarning(gemName string, inline, block []string) string {
+	return fmt.Sprintf("gem %q: platforms %v don't overlap the enclosing platforms block %v, keeping the gem's own platforms",
+		gemName, inline, block)
+}
+
+// dynamicGemLoadingWarning describes a loop whose gems can't be resolved statically
+func dynamicGemLoadingWarning(line int) string {
+	return fmt.Sprintf("line %d: dynamic gem loading via Dir[]/Dir.glob detected, gems declared in the loop are skipped", line)
+}
+
+// collectSourceWarnings appends a warning for every source that would be fetched
+// over plain http:// instead of https://. Local hosts are exempt.
+// Ruby equivalent: Bundler's "insecure source" warning
+//
+// Git tags that look like patterns are flagged too, since Bundler only accepts exact tag names.
+func collectSourceWarnings(result *ParsedGemfile) {
+	seen := make(map[string]bool)
+
+	warn := func(kind, rawURL string) {
+		if seen[rawURL] || !isInsecureURL(rawURL) {
+			return
+		}
+		seen[rawURL] = true
+		result.Warnings = append(result.Warnings,
+			fmt.Sprintf("insecure %s %s uses http://, consider https:// instead", kind, rawURL))
+	}
+
+	for _, source := range result.Sources {
+		warn(sourceKey, source.URL)
+	}
+
+	for i := range result.Dependencies {
+		dep := &result.Dependencies[i]
+		if dep.Source == nil {
+			continue
+		}
+		switch dep.Source.Type {
+		case gitKey:
+			warn("git source", dep.Source.URL)
+			if isSuspiciousTag(dep.Source.Tag) {
+				result.Warnings = append(result.Warnings,
+					fmt.Sprintf("gem %q: tag %q looks like a pattern, git tags must be exact names", dep.Name, dep.Source.Tag))
+			}
+		case pathSource:
+			warn("path", dep.Source.URL)
+		default:
+			warn(sourceKey, dep.Source.URL)
+		}
+	}
+}
+
+// collectPrimarySourcesWarning appends a warning when more than one distinct source
+// is declared without a block, since gems may then come from either of them.
+// Ruby equivalent: Bundler's "multiple primary sources" warning
+func collectPrimarySourcesWarning(result *ParsedGemfile, primarySources []Source) {
+	var distinct []Source
+	for i := range primarySources {
+		source := primarySources[i].Canonical()
+		if !slices.ContainsFunc(distinct, func(seen Source) bool { return seen.Equal(&source) }) {
+			distinct = append(distinct, source)
+		}
+	}
+	if len(distinct) < 2 {
+		return
+	}
+
+	urls := make([]string, len(distinct))
+	for i := range distinct {
+		urls[i] = distinct[i].URL
+	}
+	result.Warnings = append(result.Warnings, fmt.Sprintf(
+		"Your Gemfile contains multiple primary sources (%s). Using `source` more than once without a block is a security risk, "+
+			"and may result in installing unexpected gems. Use a block to indicate which gems should come from the secondary source.",
+		strings.Join(urls, ", ")))
+}
+
+// isSuspiciousTag reports whether a git tag contains wildcards or whitespace
+func isSuspiciousTag(tag string) bool {
+	return strings.ContainsAny(tag, "*? \t")
+}
+
+// isInsecureURL reports whether a URL uses http:// against a non-local host
+func isInsecureURL(rawURL string) bool {
+	if !strings.HasPrefix(strings.ToLower(rawURL), "http://") {
+		return false
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return true
+	}
+
+	switch parsed.Hostname() {
+	case "localhost", "127.0.0.1", "::1":
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,264 @@
+package gemfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInsecureSourceWarnings(t *testing.T) {
+	gemfileContent := `source 'http://rubygems.org'
+
+gem 'rails'
+gem 'internal', git: 'http://git.example.com/internal.git'
+gem 'local_mirror', source: 'http://localhost:9292'
+gem 'secure', git: 'https://github.com/user/secure.git'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+		if len(parsed.Warnings) != 2 {
+			t.Fatalf("expected 2 warnings, got %d: %v", len(parsed.Warnings), parsed.Warnings)
+		}
+		if !strings.Contains(parsed.Warnings[0], "http://rubygems.org") {
+			t.Errorf("expected warning for http://rubygems.org, got %q", parsed.Warnings[0])
+		}
+		if !strings.Contains(parsed.Warnings[1], "http://git.example.com/internal.git") {
+			t.Errorf("expected warning for insecure git source, got %q", parsed.Warnings[1])
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestDynamicGemLoadingWarning(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'rails', '~> 7.0'
+
+Dir['engines/*'].each { |path| gem File.basename(path), path: path }
+
+Dir.glob('components/*').sort.each do |path|
+  if File.directory?(path)
+    gem File.basename(path), path: path
+  end
+end
+
+gem 'puma'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		var names []string
+		for _, dep := range parsed.Dependencies {
+			names = append(names, dep.Name)
+		}
+		if strings.Join(names, ",") != "rails,puma" {
+			t.Errorf("Expected dependencies [rails puma], got %v", names)
+		}
+
+		expected := []string{dynamicGemLoadingWarning(5), dynamicGemLoadingWarning(7)}
+		if strings.Join(parsed.Warnings, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Expected warnings %v, got %v", expected, parsed.Warnings)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestGitTagValues(t *testing.T) {
+	gemfileContent := `gem 'semver_tagged', git: 'https://git.example.com/semver_tagged.git', tag: 'v1.2.3'
+gem 'release_branch', github: 'example/release_branch', tag: 'release/1.0'
+gem 'wildcard', git: 'https://git.example.com/wildcard.git', tag: 'v1.*'
+gem 'spaced', git: 'https://git.example.com/spaced.git', tag: 'v2 final'
+`
+
+	expectedTags := map[string]string{
+		"semver_tagged":  "v1.2.3",
+		"release_branch": "release/1.0",
+		"wildcard":       "v1.*",
+		"spaced":         "v2 final",
+	}
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+
+		for name, tag := range expectedTags {
+			dep := findGem(parsed.Dependencies, name)
+			if dep == nil || dep.Source == nil {
+				t.Fatalf("expected %s to have a git source", name)
+			}
+			if dep.Source.Tag != tag {
+				t.Errorf("%s: expected tag %q, got %q", name, tag, dep.Source.Tag)
+			}
+		}
+
+		if len(parsed.Warnings) != 2 {
+			t.Fatalf("expected 2 warnings, got %d: %v", len(parsed.Warnings), parsed.Warnings)
+		}
+		if !strings.Contains(parsed.Warnings[0], `"wildcard"`) || !strings.Contains(parsed.Warnings[1], `"spaced"`) {
+			t.Errorf("expected tag warnings for wildcard and spaced, got %v", parsed.Warnings)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+
+		// Tags must survive a write and re-parse unchanged
+		writer := &GemfileWriter{}
+		var lines []string
+		for i := range parsed.Dependencies {
+			lines = append(lines, writer.formatGemLine(&parsed.Dependencies[i]))
+		}
+		reparsed, err := (&GemfileParser{content: strings.Join(lines, "\n")}).parseContent()
+		if err != nil {
+			t.Fatalf("re-parse failed: %v", err)
+		}
+		check(t, reparsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+}
+
+func TestMultiplePrimarySourcesWarning(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+source 'https://gems.example.com'
+source 'https://rubygems.org/'
+
+source 'https://gems.contribsys.com' do
+  gem 'sidekiq-pro'
+end
+
+gem 'rails'
+`
+
+	check := func(t *testing.T, parsed *ParsedGemfile) {
+		t.Helper()
+		if len(parsed.Warnings) != 1 {
+			t.Fatalf("expected 1 warning, got %d: %v", len(parsed.Warnings), parsed.Warnings)
+		}
+		warning := parsed.Warnings[0]
+		if !strings.HasPrefix(warning, "Your Gemfile contains multiple primary sources") {
+			t.Errorf("unexpected warning: %q", warning)
+		}
+		if !strings.Contains(warning, "https://rubygems.org, https://gems.example.com)") {
+			t.Errorf("expected both primary sources in the warning, got %q", warning)
+		}
+		if strings.Contains(warning, "contribsys") {
+			t.Errorf("block sources are scoped and shouldn't be listed, got %q", warning)
+		}
+	}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		check(t, parsed)
+	})
+
+	t.Run("single source with blocks", func(t *testing.T) {
+		content := "source 'https://rubygems.org'\n\nsource 'https://gems.example.com' do\n  gem 'private'\nend\n"
+		parser := &GemfileParser{content: content}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		if len(parsed.Warnings) != 0 {
+			t.Errorf("expected no warnings, got %v", parsed.Warnings)
+		}
+	})
+}
+
+func TestGemTypoWarning(t *testing.T) {
+	gemfileContent := `source 'https://rubygems.org'
+
+gem 'rails'
+gems 'puma'
+Gem 'pg'
+gems.each { |name| gem name }
+`
+	expected := []string{gemTypoWarning(4, "gems"), gemTypoWarning(5, "Gem")}
+
+	t.Run("regex parser", func(t *testing.T) {
+		parser := &GemfileParser{content: gemfileContent, Lint: true}
+		parsed, err := parser.parseContent()
+		if err != nil {
+			t.Fatalf("parseContent failed: %v", err)
+		}
+		if strings.Join(parsed.Warnings, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Expected warnings %v, got %v", expected, parsed.Warnings)
+		}
+
+		parser.Lint = false
+		if parsed, _ = parser.parseContent(); len(parsed.Warnings) != 0 {
+			t.Errorf("Expected no warnings outside lint mode, got %v", parsed.Warnings)
+		}
+	})
+
+	t.Run("tree-sitter parser", func(t *testing.T) {
+		parser := NewTreeSitterGemfileParser([]byte(gemfileContent))
+		parser.lint = true
+		parsed, err := parser.ParseWithTreeSitter()
+		if err != nil {
+			t.Fatalf("ParseWithTreeSitter failed: %v", err)
+		}
+		if strings.Join(parsed.Warnings, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("Expected warnings %v, got %v", expected, parsed.Warnings)
+		}
+	})
+}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
+
+	"github.com/contriboss/gemfile-go/internal/atomicfile"
 )
 
 const (
@@ -13,12 +16,35 @@ const (
 	rubygemsURL    = "https://rubygems.org"
 	pathSource     = "path"
 	defaultGroup   = "default"
+	// gitSourceRepoVar is the placeholder for the option value in git_source templates
+	gitSourceRepoVar = "#{repo}"
 )
 
 // GemfileWriter handles writing and modifying Gemfiles
 type GemfileWriter struct {
-	filepath string
-	content  []string
+	filepath   string
+	content    []string
+	gitSources map[string]string // Custom git_source templates used to shorten git URLs
+	QuoteStyle QuoteStyle        // Quotes around gem names, constraints and option values (zero value: single)
+}
+
+// QuoteStyle selects the quotes GemfileWriter puts around the strings it writes
+type QuoteStyle int
+
+const (
+	SingleQuotes QuoteStyle = iota // gem 'rails', '~> 7.0'
+	DoubleQuotes                   // gem "rails", "~> 7.0", RuboCop's default string style
+)
+
+// doubleQuoteEscaper escapes what Ruby would otherwise read specially inside double quotes
+var doubleQuoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "#{", `\#{`)
+
+// quote wraps s in the writer's quote style
+func (w *GemfileWriter) quote(s string) string {
+	if w.QuoteStyle == DoubleQuotes {
+		return `"` + doubleQuoteEscaper.Replace(s) + `"`
+	}
+	return "'" + s + "'"
 }
 
 // NewGemfileWriter creates a new writer for the given Gemfile path
@@ -42,7 +68,14 @@ func (w *GemfileWriter) AddGem(dep *GemDependency) error {
 	if err := w.Load(); err != nil {
 		return err
 	}
+	if err := w.addGem(dep); err != nil {
+		return err
+	}
+	return w.save()
+}
 
+// addGem inserts a gem into the loaded content
+func (w *GemfileWriter) addGem(dep *GemDependency) error {
 	// Check if gem already exists
 	if w.hasGem(dep.Name) {
 		return fmt.Errorf("gem %q already exists in Gemfile", dep.Name)
@@ -56,7 +89,7 @@ func (w *GemfileWriter) AddGem(dep *GemDependency) error {
 	// Insert the gem line
 	w.content = append(w.content[:insertIndex], append([]string{gemLine}, w.content[insertIndex:]...)...)
 
-	return w.save()
+	return nil
 }
 
 // RemoveGem removes a gem from the Gemfile
@@ -64,7 +97,14 @@ func (w *GemfileWriter) RemoveGem(gemName string) error {
 	if err := w.Load(); err != nil {
 		return err
 	}
+	if err := w.removeGem(gemName); err != nil {
+		return err
+	}
+	return w.save()
+}
 
+// removeGem drops every declaration of a gem from the loaded content
+func (w *GemfileWriter) removeGem(gemName string) error {
 	found := false
 	newContent := make([]string, 0, len(w.content))
 
@@ -82,6 +122,40 @@ func (w *GemfileWriter) RemoveGem(gemName string) error {
 	}
 
 	w.content = newContent
+	return nil
+}
+
+// RenameGem renames every declaration of a gem, e.g. when switching to a fork
+// published under a different name. Only the name is rewritten; constraints,
+// options and comments on the line are preserved.
+func (w *GemfileWriter) RenameGem(oldName, newName string) error {
+	if err := w.Load(); err != nil {
+		return err
+	}
+
+	if oldName == newName {
+		return nil
+	}
+
+	if w.hasGem(newName) {
+		return fmt.Errorf("gem %q already exists in Gemfile", newName)
+	}
+
+	nameRe := regexp.MustCompile(fmt.Sprintf(`^(\s*gem\s+['"])%s(['"])`, regexp.QuoteMeta(oldName)))
+
+	found := false
+	for i, line := range w.content {
+		// The name sits between the opening quote (group 1) and the closing quote (group 2)
+		if loc := nameRe.FindStringSubmatchIndex(line); loc != nil {
+			found = true
+			w.content[i] = line[:loc[3]] + newName + line[loc[4]:]
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("gem %q not found in Gemfile", oldName)
+	}
+
 	return w.save()
 }
 
@@ -105,7 +179,7 @@ func (w *GemfileWriter) isGemLine(line, gemName string) bool {
 
 // formatGemLine formats a gem dependency into a Gemfile line string.
 func (w *GemfileWriter) formatGemLine(dep *GemDependency) string {
-	parts := []string{fmt.Sprintf("gem '%s'", dep.Name)}
+	parts := []string{"gem " + w.quote(dep.Name)}
 	parts = append(parts, w.formatConstraints(dep)...)
 
 	if source := w.formatSource(dep); source != "" {
@@ -120,14 +194,40 @@ func (w *GemfileWriter) formatGemLine(dep *GemDependency) string {
 		parts = append(parts, require)
 	}
 
+	if dep.ForceRubyPlatform {
+		parts = append(parts, "force_ruby_platform: true")
+	}
+
+	if condition := installCondition(dep); condition != "" {
+		parts = append(parts, "install_if: "+condition)
+	}
+
 	return strings.Join(parts, ", ")
 }
 
+// installCondition returns the gem's install_if condition. Gems from
+// install_if blocks are written with the blocks' conditions as an inline
+// option, as an array when more than one applies.
+func installCondition(dep *GemDependency) string {
+	conditions := slices.Clone(dep.InstallIf)
+	if dep.InstallIfExpr != "" {
+		conditions = append(conditions, dep.InstallIfExpr)
+	}
+	switch len(conditions) {
+	case 0:
+		return ""
+	case 1:
+		return conditions[0]
+	default:
+		return "[" + strings.Join(conditions, ", ") + "]"
+	}
+}
+
 // formatConstraints formats the version constraints for a gem.
 func (w *GemfileWriter) formatConstraints(dep *GemDependency) []string {
 	var parts []string
 	for _, constraint := range dep.Constraints {
-		parts = append(parts, fmt.Sprintf("'%s'", constraint))
+		parts = append(parts, w.quote(constraint))
 	}
 	return parts
 }
@@ -141,36 +241,63 @@ func (w *GemfileWriter) formatSource(dep *GemDependency) string {
 	var parts []string
 	switch dep.Source.Type {
 	case "git":
-		if strings.Contains(dep.Source.URL, "github.com") {
+		if key, repo := w.matchGitSource(dep.Source.URL); key != "" {
+			parts = append(parts, key+": "+w.quote(repo))
+		} else if strings.Contains(dep.Source.URL, "github.com") {
 			githubPath := extractGitHubPath(dep.Source.URL)
 			if githubPath != "" {
-				parts = append(parts, fmt.Sprintf("github: '%s'", githubPath))
+				parts = append(parts, "github: "+w.quote(githubPath))
 			} else {
-				parts = append(parts, fmt.Sprintf("git: '%s'", dep.Source.URL))
+				parts = append(parts, "git: "+w.quote(dep.Source.URL))
 			}
 		} else {
-			parts = append(parts, fmt.Sprintf("git: '%s'", dep.Source.URL))
+			parts = append(parts, "git: "+w.quote(dep.Source.URL))
 		}
 
 		if dep.Source.Branch != "" {
-			parts = append(parts, fmt.Sprintf("branch: '%s'", dep.Source.Branch))
+			parts = append(parts, "branch: "+w.quote(dep.Source.Branch))
 		}
 		if dep.Source.Tag != "" {
-			parts = append(parts, fmt.Sprintf("tag: '%s'", dep.Source.Tag))
+			parts = append(parts, "tag: "+w.quote(dep.Source.Tag))
 		}
 		if dep.Source.Ref != "" {
-			parts = append(parts, fmt.Sprintf("ref: '%s'", dep.Source.Ref))
+			parts = append(parts, "ref: "+w.quote(dep.Source.Ref))
 		}
 	case pathSource:
-		parts = append(parts, fmt.Sprintf("path: '%s'", dep.Source.URL))
+		parts = append(parts, "path: "+w.quote(dep.Source.URL))
 	case rubygemsSource:
 		if dep.Source.URL != rubygemsURL {
-			parts = append(parts, fmt.Sprintf("source: '%s'", dep.Source.URL))
+			parts = append(parts, "source: "+w.quote(dep.Source.URL))
 		}
 	}
 	return strings.Join(parts, ", ")
 }
 
+// matchGitSource finds the custom git_source whose template expands to url and
+// returns its name with the repo value. Names are tried in sorted order.
+func (w *GemfileWriter) matchGitSource(url string) (key, repo string) {
+	names := make([]string, 0, len(w.gitSources))
+	for name := range w.gitSources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		prefix, suffix, ok := strings.Cut(w.gitSources[name], gitSourceRepoVar)
+		if !ok || len(url) <= len(prefix)+len(suffix) ||
+			!strings.HasPrefix(url, prefix) || !strings.HasSuffix(url, suffix) {
+			continue
+		}
+		return name, url[len(prefix) : len(url)-len(suffix)]
+	}
+	return "", ""
+}
+
+// formatGitSource formats a git_source registration
+func formatGitSource(name, template string) string {
+	return fmt.Sprintf("git_source(:%s) { |repo| \"%s\" }", name, template)
+}
+
 // formatGroups formats the group information for a gem.
 func (w *GemfileWriter) formatGroups(dep *GemDependency) string {
 	if len(dep.Groups) > 0 && !isDefaultGroup(dep.Groups) {
@@ -192,7 +319,7 @@ func (w *GemfileWriter) formatRequire(dep *GemDependency) string {
 		if *dep.Require == "" || *dep.Require == falseValue {
 			return "require: false"
 		}
-		return fmt.Sprintf("require: '%s'", *dep.Require)
+		return "require: " + w.quote(*dep.Require)
 	}
 	return ""
 }
@@ -248,10 +375,14 @@ func (w *GemfileWriter) findInsertionPoint(groups []string) int {
 	return len(w.content)
 }
 
+// render returns the modified content as it would be written
+func (w *GemfileWriter) render() string {
+	return strings.Join(w.content, "\n")
+}
+
 // save writes the modified content back to the Gemfile
 func (w *GemfileWriter) save() error {
-	content := strings.Join(w.content, "\n")
-	return os.WriteFile(w.filepath, []byte(content), 0600)
+	return atomicfile.WriteBytes(w.filepath, []byte(w.render()), 0600)
 }
 
 // AddGemToFile is a convenience function to add a gem to a Gemfile
@@ -266,6 +397,12 @@ func RemoveGemFromFile(filepath, gemName string) error {
 	return writer.RemoveGem(gemName)
 }
 
+// RenameGem is a convenience function to rename a gem in a Gemfile
+func RenameGem(gemfilePath, oldName, newName string) error {
+	writer := NewGemfileWriter(gemfilePath)
+	return writer.RenameGem(oldName, newName)
+}
+
 // AddGemspec adds a gemspec directive to the Gemfile
 func (w *GemfileWriter) AddGemspec(gemspecRef *GemspecReference) error {
 	if err := w.Load(); err != nil {
@@ -305,20 +442,20 @@ func (w *GemfileWriter) formatGemspecDirective(gemspecRef *GemspecReference) str
 	if gemspecRef.Path == "." &&
 		gemspecRef.Name == "" &&
 		gemspecRef.DevelopmentGroup == developmentGroup &&
-		gemspecRef.Glob == defaultGlobPattern {
+		gemspecRef.Glob == defaultGlobPattern &&
+		gemspecRef.Require == nil {
 		return "gemspec"
 	}
 
 	var parts []string
-	parts = append(parts, "gemspec")
 
 	// Add non-default options
 	if gemspecRef.Path != "." && gemspecRef.Path != "" {
-		parts = append(parts, fmt.Sprintf("path: '%s'", gemspecRef.Path))
+		parts = append(parts, "path: "+w.quote(gemspecRef.Path))
 	}
 
 	if gemspecRef.Name != "" {
-		parts = append(parts, fmt.Sprintf("name: '%s'", gemspecRef.Name))
+		parts = append(parts, "name: "+w.quote(gemspecRef.Name))
 	}
 
 	if gemspecRef.DevelopmentGroup != developmentGroup && gemspecRef.DevelopmentGroup != "" {
@@ -326,10 +463,17 @@ func (w *GemfileWriter) formatGemspecDirective(gemspecRef *GemspecReference) str
 	}
 
 	if gemspecRef.Glob != defaultGlobPattern && gemspecRef.Glob != "" {
-		parts = append(parts, fmt.Sprintf("glob: '%s'", gemspecRef.Glob))
+		parts = append(parts, "glob: "+w.quote(gemspecRef.Glob))
 	}
 
-	return strings.Join(parts, ", ")
+	if require := w.formatRequire(&GemDependency{Require: gemspecRef.Require}); require != "" {
+		parts = append(parts, require)
+	}
+
+	if len(parts) == 0 {
+		return "gemspec"
+	}
+	return "gemspec " + strings.Join(parts, ", ")
 }
 
 // findGemspecInsertionPoint finds the best place to insert a gemspec directive
@@ -380,44 +524,31 @@ func (w *GemfileWriter) findGemspecInsertionPoint() int {
 
 // WriteGemfile writes a complete Gemfile from a ParsedGemfile structure
 func WriteGemfile(filepath string, parsed *ParsedGemfile) error {
-	var lines []string
-
-	// Add header comment if needed
-	lines = append(lines, "# Generated by gemfile-go", "")
+	return NewGemfileWriter(filepath).WriteGemfile(parsed)
+}
 
-	// Add sources
-	for _, source := range parsed.Sources {
-		if source.Type == rubygemsSource {
-			lines = append(lines, fmt.Sprintf("source '%s'", source.URL))
-		}
-	}
+// WriteGemfilePreservingOrder writes a complete Gemfile like WriteGemfile, but
+// keeps the dependencies in the order they were declared (the order of
+// parsed.Dependencies) instead of regrouping them into group blocks.
+// Groups are written as inline group options.
+func WriteGemfilePreservingOrder(filepath string, parsed *ParsedGemfile) error {
+	return NewGemfileWriter(filepath).WriteGemfilePreservingOrder(parsed)
+}
 
-	// Add Ruby version if specified
-	if parsed.RubyVersion != "" {
-		if len(lines) > 2 { // After header and blank line
-			lines = append(lines, "")
-		}
-		lines = append(lines, fmt.Sprintf("ruby '%s'", parsed.RubyVersion))
-	}
-
-	// Add gemspec directives
-	for _, gemspecRef := range parsed.Gemspecs {
-		if len(lines) > 2 {
-			lines = append(lines, "")
-		}
-		writer := &GemfileWriter{}
-		lines = append(lines, writer.formatGemspecDirective(&gemspecRef))
-	}
+// WriteGemfile writes a complete Gemfile from a ParsedGemfile structure to the
+// writer's path, in the writer's QuoteStyle
+func (w *GemfileWriter) WriteGemfile(parsed *ParsedGemfile) error {
+	writer := w.forParsed(parsed)
+	lines := writer.gemfilePreamble(parsed)
 
 	// Group dependencies by their groups
-	defaultGems, groupedGems := groupDependencies(parsed.Dependencies)
+	defaultGems, groupedGems := groupDependencies(writableDependencies(parsed))
 
 	// Write default gems
 	if len(defaultGems) > 0 {
 		if len(lines) > 2 {
 			lines = append(lines, "")
 		}
-		writer := &GemfileWriter{}
 		for _, dep := range defaultGems {
 			lines = append(lines, writer.formatGemLine(&dep))
 		}
@@ -429,7 +560,6 @@ func WriteGemfile(filepath string, parsed *ParsedGemfile) error {
 			lines = append(lines, "")
 		}
 		lines = append(lines, fmt.Sprintf("group :%s do", group))
-		writer := &GemfileWriter{}
 		for _, dep := range gems {
 			// Clear groups for formatting since they're in a group block
 			tempDep := dep
@@ -439,12 +569,103 @@ func WriteGemfile(filepath string, parsed *ParsedGemfile) error {
 		lines = append(lines, endKeyword)
 	}
 
-	// Write the file
+	return writeGemfileLines(w.filepath, lines)
+}
+
+// WriteGemfilePreservingOrder writes a complete Gemfile in declaration order
+// to the writer's path, in the writer's QuoteStyle
+func (w *GemfileWriter) WriteGemfilePreservingOrder(parsed *ParsedGemfile) error {
+	writer := w.forParsed(parsed)
+	lines := writer.gemfilePreamble(parsed)
+
+	if dependencies := writableDependencies(parsed); len(dependencies) > 0 {
+		if len(lines) > 2 {
+			lines = append(lines, "")
+		}
+		for _, dep := range dependencies {
+			lines = append(lines, writer.formatGemLine(&dep))
+		}
+	}
+
+	return writeGemfileLines(w.filepath, lines)
+}
+
+// forParsed returns a writer with w's settings that shortens git URLs using
+// the git_source templates registered in parsed
+func (w *GemfileWriter) forParsed(parsed *ParsedGemfile) *GemfileWriter {
+	return &GemfileWriter{filepath: w.filepath, gitSources: parsed.GitSources, QuoteStyle: w.QuoteStyle}
+}
+
+// gemfilePreamble returns the lines written before the gems: the header
+// comment, sources, git_source registrations, ruby version and gemspec directives
+func (w *GemfileWriter) gemfilePreamble(parsed *ParsedGemfile) []string {
+	var lines []string
+
+	// Add header comment if needed
+	lines = append(lines, "# Generated by gemfile-go", "")
+
+	// Add sources
+	for _, source := range parsed.Sources {
+		if source.Type == rubygemsSource {
+			lines = append(lines, "source "+w.quote(source.URL))
+		}
+	}
+
+	// Add git_source registrations, so gems using them keep their shorthand
+	gitSourceNames := make([]string, 0, len(parsed.GitSources))
+	for name := range parsed.GitSources {
+		gitSourceNames = append(gitSourceNames, name)
+	}
+	slices.Sort(gitSourceNames)
+	for _, name := range gitSourceNames {
+		lines = append(lines, formatGitSource(name, parsed.GitSources[name]))
+	}
+
+	// Add Ruby version if specified
+	if parsed.RubyVersion != "" {
+		if len(lines) > 2 { // After header and blank line
+			lines = append(lines, "")
+		}
+		constraints := parsed.RubyVersionConstraints
+		if len(constraints) == 0 {
+			constraints = []string{parsed.RubyVersion}
+		}
+		quoted := make([]string, len(constraints))
+		for i, constraint := range constraints {
+			quoted[i] = w.quote(constraint)
+		}
+		lines = append(lines, "ruby "+strings.Join(quoted, ", "))
+	}
+
+	// Add gemspec directives
+	for _, gemspecRef := range parsed.Gemspecs {
+		if len(lines) > 2 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, w.formatGemspecDirective(&gemspecRef))
+	}
+
+	return lines
+}
+
+// writableDependencies returns the dependencies to write as gem lines.
+// Gems loaded from a gemspec come back through its directive.
+func writableDependencies(parsed *ParsedGemfile) []GemDependency {
+	if len(parsed.Gemspecs) == 0 {
+		return parsed.Dependencies
+	}
+	return slices.DeleteFunc(slices.Clone(parsed.Dependencies), func(dep GemDependency) bool {
+		return dep.FromGemspec
+	})
+}
+
+// writeGemfileLines writes the lines as a Gemfile, ending in a newline
+func writeGemfileLines(filepath string, lines []string) error {
 	content := strings.Join(lines, "\n")
 	if !strings.HasSuffix(content, "\n") {
 		content += "\n"
 	}
-	return os.WriteFile(filepath, []byte(content), 0600)
+	return atomicfile.WriteBytes(filepath, []byte(content), 0600)
 }
 
 // groupDependencies separates dependencies into default and grouped gems
@@ -296,6 +296,101 @@ gem 'rails'`,
 	}
 }
 
+func TestRenameGem(t *testing.T) {
+	tests := []struct {
+		name            string
+		initialGemfile  string
+		oldName         string
+		newName         string
+		expectedErr     string
+		expectedContent string
+	}{
+		{
+			name: "rename gem with git source",
+			initialGemfile: `source 'https://rubygems.org'
+
+gem 'rails'
+gem "state_machines", github: 'state-machines/state_machines', branch: 'feature/foo' # pinned for fix`,
+			oldName: "state_machines",
+			newName: "state_machines-fork",
+			expectedContent: `source 'https://rubygems.org'
+
+gem 'rails'
+gem "state_machines-fork", github: 'state-machines/state_machines', branch: 'feature/foo' # pinned for fix`,
+		},
+		{
+			name: "rename gem inside group",
+			initialGemfile: `source 'https://rubygems.org'
+
+group :development, :test do
+  gem 'rspec', '~> 3.0', require: false
+  gem 'rspec-rails'
+end`,
+			oldName: "rspec",
+			newName: "rspec-fork",
+			expectedContent: `source 'https://rubygems.org'
+
+group :development, :test do
+  gem 'rspec-fork', '~> 3.0', require: false
+  gem 'rspec-rails'
+end`,
+		},
+		{
+			name: "rename nonexistent gem",
+			initialGemfile: `source 'https://rubygems.org'
+
+gem 'rails'`,
+			oldName:     "rspec",
+			newName:     "rspec-fork",
+			expectedErr: `gem "rspec" not found in Gemfile`,
+		},
+		{
+			name: "rename to existing gem",
+			initialGemfile: `source 'https://rubygems.org'
+
+gem 'rails'
+gem 'puma'`,
+			oldName:     "rails",
+			newName:     "puma",
+			expectedErr: `gem "puma" already exists in Gemfile`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+			if err := os.WriteFile(gemfilePath, []byte(tt.initialGemfile), 0600); err != nil {
+				t.Fatalf("Failed to write initial Gemfile: %v", err)
+			}
+
+			err := RenameGem(gemfilePath, tt.oldName, tt.newName)
+
+			if tt.expectedErr != "" {
+				if err == nil {
+					t.Fatalf("Expected error %q but got none", tt.expectedErr)
+				}
+				if !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("Expected error containing %q but got %q", tt.expectedErr, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			content, err := os.ReadFile(gemfilePath)
+			if err != nil {
+				t.Fatalf("Failed to read Gemfile: %v", err)
+			}
+
+			if string(content) != tt.expectedContent {
+				t.Fatalf("Expected content:\n%s\n\nActual content:\n%s", tt.expectedContent, string(content))
+			}
+		})
+	}
+}
+
 // TestExtractGitHubPath tests GitHub URL parsing
 func TestExtractGitHubPath(t *testing.T) {
 	tests := []struct {
@@ -320,6 +415,161 @@ func TestExtractGitHubPath(t *testing.T) {
 	}
 }
 
+// TestFormatGemLineBranchWithSlashes tests that slash-containing branches are written verbatim
+func TestFormatGemLineBranchWithSlashes(t *testing.T) {
+	writer := &GemfileWriter{}
+
+	dep := &GemDependency{
+		Name:   "state_machines",
+		Groups: []string{"default"},
+		Source: &Source{
+			Type:   "git",
+			URL:    "https://github.com/state-machines/state_machines.git",
+			Branch: "feature/foo-bar",
+		},
+	}
+
+	expected := "gem 'state_machines', github: 'state-machines/state_machines', branch: 'feature/foo-bar'"
+	if line := writer.formatGemLine(dep); line != expected {
+		t.Fatalf("Expected %q but got %q", expected, line)
+	}
+
+	parser := &GemfileParser{content: expected}
+	parsed, err := parser.parseContent()
+	if err != nil {
+		t.Fatalf("parseContent failed: %v", err)
+	}
+	if len(parsed.Dependencies) != 1 || parsed.Dependencies[0].Source.Branch != "feature/foo-bar" {
+		t.Fatalf("Expected branch to survive round-trip, got %+v", parsed.Dependencies)
+	}
+}
+
+func TestFormatGemLineForceRubyPlatform(t *testing.T) {
+	writer := &GemfileWriter{}
+
+	dep := &GemDependency{
+		Name:              "nokogiri",
+		Constraints:       []string{"~> 1.15"},
+		Groups:            []string{"default"},
+		ForceRubyPlatform: true,
+	}
+
+	expected := "gem 'nokogiri', '~> 1.15', force_ruby_platform: true"
+	if line := writer.formatGemLine(dep); line != expected {
+		t.Fatalf("Expected %q but got %q", expected, line)
+	}
+}
+
+func TestFormatGemLineInstallIf(t *testing.T) {
+	writer := &GemfileWriter{}
+
+	dep := &GemDependency{
+		Name:          "rb-fsevent",
+		Groups:        []string{"default"},
+		InstallIfExpr: "-> { RUBY_PLATFORM =~ /darwin/ }",
+	}
+
+	expected := "gem 'rb-fsevent', install_if: -> { RUBY_PLATFORM =~ /darwin/ }"
+	if line := writer.formatGemLine(dep); line != expected {
+		t.Fatalf("Expected %q but got %q", expected, line)
+	}
+}
+
+func TestFormatGemLineInstallIfBlock(t *testing.T) {
+	writer := &GemfileWriter{}
+
+	// A gem from an install_if block keeps its condition as an inline option
+	dep := &GemDependency{
+		Name:      "wdm",
+		Groups:    []string{"default"},
+		InstallIf: []string{"-> { Gem.win_platform? }"},
+	}
+
+	expected := "gem 'wdm', install_if: -> { Gem.win_platform? }"
+	if line := writer.formatGemLine(dep); line != expected {
+		t.Fatalf("Expected %q but got %q", expected, line)
+	}
+
+	// Nested blocks keep every condition
+	dep.InstallIf = append(dep.InstallIf, "-> { ENV['CI'] }")
+	expected = "gem 'wdm', install_if: [-> { Gem.win_platform? }, -> { ENV['CI'] }]"
+	if line := writer.formatGemLine(dep); line != expected {
+		t.Fatalf("Expected %q but got %q", expected, line)
+	}
+}
+
+func TestFormatGemspecDirectiveWithoutOptions(t *testing.T) {
+	writer := &GemfileWriter{}
+
+	if line := writer.formatGemspecDirective(&GemspecReference{}); line != "gemspec" {
+		t.Fatalf("Expected %q but got %q", "gemspec", line)
+	}
+}
+
+func TestFormatGemLineDoubleQuotes(t *testing.T) {
+	require := "rack/test"
+	tests := []struct {
+		dep      GemDependency
+		expected string
+	}{
+		{
+			dep:      GemDependency{Name: "rails", Constraints: []string{"~> 7.0", ">= 7.0.4"}},
+			expected: `gem "rails", "~> 7.0", ">= 7.0.4"`,
+		},
+		{
+			dep: GemDependency{
+				Name:    "rack-test",
+				Source:  &Source{Type: "git", URL: "https://github.com/rack/rack-test.git", Branch: "main"},
+				Groups:  []string{"test"},
+				Require: &require,
+			},
+			expected: `gem "rack-test", github: "rack/rack-test", branch: "main", group: :test, require: "rack/test"`,
+		},
+		{
+			dep:      GemDependency{Name: "local", Source: &Source{Type: "path", URL: `C:\gems\"local"`}},
+			expected: `gem "local", path: "C:\\gems\\\"local\""`,
+		},
+		{
+			dep:      GemDependency{Name: "odd", Constraints: []string{"#{version}"}},
+			expected: `gem "odd", "\#{version}"`,
+		},
+	}
+
+	writer := &GemfileWriter{QuoteStyle: DoubleQuotes}
+	for _, tt := range tests {
+		if line := writer.formatGemLine(&tt.dep); line != tt.expected {
+			t.Errorf("Expected %s but got %s", tt.expected, line)
+		}
+	}
+
+	gemspecLine := writer.formatGemspecDirective(&GemspecReference{Path: "engines/core", Name: "core", DevelopmentGroup: developmentGroup, Glob: defaultGlobPattern})
+	if expected := `gemspec path: "engines/core", name: "core"`; gemspecLine != expected {
+		t.Errorf("Expected %s but got %s", expected, gemspecLine)
+	}
+}
+
+func TestAddGemDoubleQuotes(t *testing.T) {
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	if err := os.WriteFile(gemfilePath, []byte("source \"https://rubygems.org\"\n\ngem \"rails\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write Gemfile: %v", err)
+	}
+
+	writer := NewGemfileWriter(gemfilePath)
+	writer.QuoteStyle = DoubleQuotes
+	if err := writer.AddGem(&GemDependency{Name: "puma", Constraints: []string{"~> 6.0"}, Groups: []string{"default"}}); err != nil {
+		t.Fatalf("AddGem failed: %v", err)
+	}
+
+	content, err := os.ReadFile(gemfilePath)
+	if err != nil {
+		t.Fatalf("Failed to read Gemfile: %v", err)
+	}
+	expected := "source \"https://rubygems.org\"\n\ngem \"rails\"\ngem \"puma\", \"~> 6.0\"\n"
+	if string(content) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, content)
+	}
+}
+
 // TestIsDefaultGroup tests default group detection
 func TestIsDefaultGroup(t *testing.T) {
 	tests := []struct {
@@ -342,3 +592,176 @@ func TestIsDefaultGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteGemfileGitSources(t *testing.T) {
+	parsed := &ParsedGemfile{
+		Sources: []Source{{Type: rubygemsSource, URL: rubygemsURL}},
+		GitSources: map[string]string{
+			"gitlab": "https://gitlab.com/#{repo}.git",
+		},
+		Dependencies: []GemDependency{
+			{
+				Name:   "internal",
+				Groups: []string{defaultGroup},
+				Source: &Source{Type: gitKey, URL: "https://gitlab.com/acme/internal.git", Branch: "main"},
+			},
+			{
+				Name:   "other",
+				Groups: []string{defaultGroup},
+				Source: &Source{Type: gitKey, URL: "https://git.example.com/other.git"},
+			},
+		},
+	}
+
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	if err := WriteGemfile(gemfilePath, parsed); err != nil {
+		t.Fatalf("WriteGemfile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(gemfilePath)
+	if err != nil {
+		t.Fatalf("Failed to read Gemfile: %v", err)
+	}
+	content := string(data)
+
+	expectedLines := []string{
+		`git_source(:gitlab) { |repo| "https://gitlab.com/#{repo}.git" }`,
+		`gem 'internal', gitlab: 'acme/internal', branch: 'main'`,
+		`gem 'other', git: 'https://git.example.com/other.git'`,
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(content, line+"\n") {
+			t.Errorf("Expected Gemfile to contain %q, got:\n%s", line, content)
+		}
+	}
+}
+
+func TestWriteGemfileRubyRequirement(t *testing.T) {
+	tests := []struct {
+		name     string
+		parsed   *ParsedGemfile
+		expected string
+	}{
+		{
+			name:     "constraints",
+			parsed:   &ParsedGemfile{RubyVersion: ">= 3.0", RubyVersionConstraints: []string{">= 3.0", "< 3.3"}},
+			expected: "ruby '>= 3.0', '< 3.3'\n",
+		},
+		{
+			name:     "version only",
+			parsed:   &ParsedGemfile{RubyVersion: "3.2.0"},
+			expected: "ruby '3.2.0'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+			if err := WriteGemfile(gemfilePath, tt.parsed); err != nil {
+				t.Fatalf("WriteGemfile failed: %v", err)
+			}
+
+			data, err := os.ReadFile(gemfilePath)
+			if err != nil {
+				t.Fatalf("Failed to read Gemfile: %v", err)
+			}
+			if !strings.Contains(string(data), tt.expected) {
+				t.Errorf("Expected Gemfile to contain %q, got:\n%s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestWriteGemfileDoubleQuotes(t *testing.T) {
+	require := "rack/test"
+	parsed := &ParsedGemfile{
+		Sources:     []Source{{Type: rubygemsSource, URL: rubygemsURL}},
+		RubyVersion: "3.2.0",
+		Gemspecs:    []GemspecReference{{Path: "engines/core", DevelopmentGroup: developmentGroup, Glob: defaultGlobPattern}},
+		Dependencies: []GemDependency{
+			{Name: "rails", Constraints: []string{"~> 7.1"}, Groups: []string{defaultGroup}},
+			{Name: "rack-test", Groups: []string{"test"}, Require: &require},
+		},
+	}
+
+	writer := NewGemfileWriter(filepath.Join(t.TempDir(), "Gemfile"))
+	writer.QuoteStyle = DoubleQuotes
+	if err := writer.WriteGemfile(parsed); err != nil {
+		t.Fatalf("WriteGemfile failed: %v", err)
+	}
+	data, err := os.ReadFile(writer.filepath)
+	if err != nil {
+		t.Fatalf("Failed to read Gemfile: %v", err)
+	}
+
+	expected := `# Generated by gemfile-go
+
+source "https://rubygems.org"
+
+ruby "3.2.0"
+
+gemspec path: "engines/core"
+
+gem "rails", "~> 7.1"
+
+group :test do
+  gem "rack-test", require: "rack/test"
+end
+`
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestWriteGemfilePreservingOrder(t *testing.T) {
+	original := `source 'https://rubygems.org'
+
+gem 'rails', '~> 7.1'
+group :development, :test do
+  gem 'rspec-rails'
+end
+gem 'puma'
+gem 'debug', group: :development
+gem 'bootsnap', require: false
+`
+	parsed, err := (&GemfileParser{content: original}).parseContent()
+	if err != nil {
+		t.Fatalf("parseContent failed: %v", err)
+	}
+
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	if err := WriteGemfilePreservingOrder(gemfilePath, parsed); err != nil {
+		t.Fatalf("WriteGemfilePreservingOrder failed: %v", err)
+	}
+	data, err := os.ReadFile(gemfilePath)
+	if err != nil {
+		t.Fatalf("Failed to read Gemfile: %v", err)
+	}
+
+	expected := `# Generated by gemfile-go
+
+source 'https://rubygems.org'
+
+gem 'rails', '~> 7.1'
+gem 'rspec-rails', groups: [:development, :test]
+gem 'puma'
+gem 'debug', group: :development
+gem 'bootsnap', require: false
+`
+	if string(data) != expected {
+		t.Errorf("Unexpected Gemfile:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	reparsed, err := (&GemfileParser{content: string(data)}).parseContent()
+	if err != nil {
+		t.Fatalf("parseContent of written Gemfile failed: %v", err)
+	}
+	if len(reparsed.Dependencies) != len(parsed.Dependencies) {
+		t.Fatalf("expected %d dependencies after round trip, got %d", len(parsed.Dependencies), len(reparsed.Dependencies))
+	}
+	for i, dep := range reparsed.Dependencies {
+		if dep.Name != parsed.Dependencies[i].Name || strings.Join(dep.Groups, ",") != strings.Join(parsed.Dependencies[i].Groups, ",") {
+			t.Errorf("dependency %d: got %s %v, expected %s %v", i, dep.Name, dep.Groups, parsed.Dependencies[i].Name, parsed.Dependencies[i].Groups)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Package atomicfile replaces files in one step, so a failed write never
+// leaves a truncated Gemfile or lockfile behind.
+package atomicfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// WriteFile writes the output of write to a temporary file next to path and
+// renames it into place once everything was written and synced. An existing
+// file keeps its permissions; a new one is created with perm, less the umask.
+// When path is a symlink, the file it points to is replaced and the link is
+// kept. On error the temporary file is removed and path is left untouched.
+func WriteFile(path string, perm os.FileMode, write func(io.Writer) error) (err error) {
+	if info, statErr := os.Lstat(path); statErr == nil && info.Mode()&os.ModeSymlink != 0 {
+		if path, err = filepath.EvalSymlinks(path); err != nil {
+			return fmt.Errorf("failed to resolve symlink: %w", err)
+		}
+	}
+
+	existing, statErr := os.Stat(path)
+	tmp, err := createTemp(path, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	buf := bufio.NewWriter(tmp)
+	if err := write(buf); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if statErr == nil {
+		if err := tmp.Chmod(existing.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// createTemp creates a new hidden file next to path. Unlike os.CreateTemp it
+// takes the permissions, so the umask applies to them as it does for os.WriteFile.
+func createTemp(path string, perm os.FileMode) (*os.File, error) {
+	prefix := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	for range 10000 {
+		tmp, err := os.OpenFile(prefix+strconv.FormatUint(uint64(rand.Uint32()), 10), os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return tmp, err
+	}
+	return nil, fmt.Errorf("no unused temporary name for %s", path)
+}
+
+// WriteBytes atomically replaces path with data, like os.WriteFile
+func WriteBytes(path string, data []byte, perm os.FileMode) error {
+	return WriteFile(path, perm, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
@@ -0,0 +1,141 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Gemfile.lock")
+	if err := os.WriteFile(path, []byte("original\n"), 0640); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if err := WriteBytes(path, []byte("replaced\n"), 0600); err != nil {
+		t.Fatalf("WriteBytes failed: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "replaced\n" {
+		t.Errorf("Expected replaced content, got %q", content)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected existing permissions 0640 to be kept, got %o", info.Mode().Perm())
+	}
+
+	newPath := filepath.Join(dir, "Gemfile")
+	if err := WriteBytes(newPath, []byte("source 'https://rubygems.org'\n"), 0600); err != nil {
+		t.Fatalf("WriteBytes failed: %v", err)
+	}
+	if info, err := os.Stat(newPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected new file with permissions 0600, got %v, %v", info, err)
+	}
+}
+
+func TestWriteFileNewFileUmask(t *testing.T) {
+	dir := t.TempDir()
+
+	// os.WriteFile applies the umask, so it shows what a new file should get
+	reference := filepath.Join(dir, "reference")
+	if err := os.WriteFile(reference, nil, 0666); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	want, err := os.Stat(reference)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+
+	path := filepath.Join(dir, "Gemfile")
+	if err := WriteBytes(path, []byte("source 'https://rubygems.org'\n"), 0666); err != nil {
+		t.Fatalf("WriteBytes failed: %v", err)
+	}
+	got, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if got.Mode().Perm() != want.Mode().Perm() {
+		t.Errorf("Expected permissions %o after umask, got %o", want.Mode().Perm(), got.Mode().Perm())
+	}
+}
+
+func TestWriteFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "shared", "Gemfile.lock")
+	if err := os.Mkdir(filepath.Dir(target), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(target, []byte("original\n"), 0640); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	link := filepath.Join(dir, "Gemfile.lock")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symlinks unavailable: %v", err)
+	}
+
+	if err := WriteBytes(link, []byte("replaced\n"), 0600); err != nil {
+		t.Fatalf("WriteBytes failed: %v", err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatalf("Failed to stat link: %v", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("Expected the symlink to be kept")
+	}
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("Failed to read target: %v", err)
+	}
+	if string(content) != "replaced\n" {
+		t.Errorf("Expected the link target to be replaced, got %q", content)
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("Expected target permissions 0640 to be kept, got %v, %v", info, err)
+	}
+}
+
+func TestWriteFileFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Gemfile.lock")
+	if err := os.WriteFile(path, []byte("original\n"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	errWrite := errors.New("disk full")
+	err := WriteFile(path, 0600, func(w io.Writer) error {
+		if _, err := io.WriteString(w, "GEM\n  remote: "); err != nil {
+			return err
+		}
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("Expected the write error, got %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(content) != "original\n" {
+		t.Errorf("Expected original content to be intact, got %q", content)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected the temporary file to be removed, found %d entries", len(entries))
+	}
+}
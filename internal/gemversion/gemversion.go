@@ -0,0 +1,217 @@
+// Package gemversion compares RubyGems versions and evaluates version
+// requirements, shared by the gemfile and lockfile packages.
+package gemversion
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// segmentRegex splits a RubyGems version into numeric and alphabetic
+// segments, e.g. "8.1.0.rc1" => 8, 1, 0, rc, 1
+var segmentRegex = regexp.MustCompile(`[0-9]+|[a-zA-Z]+`)
+
+// releaseRegex matches the version strings RubyGems accepts, without the
+// "-" form it rewrites to ".pre."
+var releaseRegex = regexp.MustCompile(`^[0-9]+(?:\.[0-9a-zA-Z]+)*$`)
+
+// constraintRegex splits a single constraint into its operator and version
+var constraintRegex = regexp.MustCompile(`^(~>|>=|<=|!=|=|>|<)?\s*(\S+)$`)
+
+// Compare compares two RubyGems versions segment by segment.
+// Missing segments count as 0 and alphabetic (prerelease) segments sort
+// before numeric ones, so 1.0 == 1.0.0 and 1.0.rc1 < 1.0.
+func Compare(a, b string) int {
+	segsA := segmentRegex.FindAllString(a, -1)
+	segsB := segmentRegex.FindAllString(b, -1)
+
+	for i := 0; i < max(len(segsA), len(segsB)); i++ {
+		segA, segB := "0", "0"
+		if i < len(segsA) {
+			segA = segsA[i]
+		}
+		if i < len(segsB) {
+			segB = segsB[i]
+		}
+
+		numA, errA := strconv.Atoi(segA)
+		numB, errB := strconv.Atoi(segB)
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				return cmp.Compare(numA, numB)
+			}
+		case errA == nil:
+			return 1
+		case errB == nil:
+			return -1
+		default:
+			if c := strings.Compare(segA, segB); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// IsPrerelease reports whether version is a prerelease, i.e. has an
+// alphabetic segment such as 8.1.0.rc1 or 1.0.0.pre. A platform suffix as
+// written in lockfiles, e.g. 1.16.0-x64-mingw-ucrt, is ignored, and strings
+// that aren't versions are never prereleases.
+// Ruby equivalent: Gem::Version#prerelease?
+func IsPrerelease(version string) bool {
+	version, _, _ = strings.Cut(strings.TrimSpace(version), "-")
+	if !releaseRegex.MatchString(version) {
+		return false
+	}
+	return slices.ContainsFunc(segmentRegex.FindAllString(version, -1), func(seg string) bool {
+		_, err := strconv.Atoi(seg)
+		return err != nil
+	})
+}
+
+// Bump returns the exclusive upper bound of a ~> constraint:
+// the last release segment is dropped and the one before it incremented,
+// e.g. 2.7.1 => 2.8 and 3.0 => 4
+// Ruby equivalent: Gem::Version#bump
+func Bump(version string) string {
+	var segments []int
+	for _, seg := range segmentRegex.FindAllString(version, -1) {
+		n, err := strconv.Atoi(seg)
+		if err != nil {
+			break // Prerelease segments are ignored
+		}
+		segments = append(segments, n)
+	}
+	if len(segments) > 1 {
+		segments = segments[:len(segments)-1]
+	}
+	if len(segments) == 0 {
+		return version
+	}
+	segments[len(segments)-1]++
+
+	parts := make([]string, len(segments))
+	for i, n := range segments {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// parseConstraint splits a constraint such as "~> 7.0" into operator and
+// version. A bare version means "=".
+func parseConstraint(constraint string) (op, version string, err error) {
+	matches := constraintRegex.FindStringSubmatch(strings.TrimSpace(constraint))
+	if matches == nil || !segmentRegex.MatchString(matches[2]) {
+		return "", "", fmt.Errorf("invalid requirement %q", constraint)
+	}
+	op = matches[1]
+	if op == "" {
+		op = "="
+	}
+	return op, matches[2], nil
+}
+
+// Satisfies reports whether version meets every constraint
+// Ruby equivalent: Gem::Requirement.new(*constraints).satisfied_by?(Gem::Version.new(version))
+func Satisfies(version string, constraints []string) (bool, error) {
+	for _, constraint := range constraints {
+		op, target, err := parseConstraint(constraint)
+		if err != nil {
+			return false, err
+		}
+
+		c := Compare(version, target)
+		var ok bool
+		switch op {
+		case "=":
+			ok = c == 0
+		case "!=":
+			ok = c != 0
+		case ">":
+			ok = c > 0
+		case "<":
+			ok = c < 0
+		case ">=":
+			ok = c >= 0
+		case "<=":
+			ok = c <= 0
+		case "~>":
+			ok = c >= 0 && Compare(version, Bump(target)) < 0
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// bound is one end of the version range allowed by a set of constraints
+type bound struct {
+	version   string
+	inclusive bool
+	set       bool
+}
+
+// Intersects reports whether some version satisfies all constraints at once,
+// e.g. "~> 7.0" and ">= 7.1" intersect while "~> 7.0" and "~> 8.0" don't.
+func Intersects(constraints []string) (bool, error) {
+	var lower, upper bound
+	var excluded []string
+
+	raiseLower := func(version string, inclusive bool) {
+		c := Compare(version, lower.version)
+		if !lower.set || c > 0 || c == 0 && !inclusive {
+			lower = bound{version: version, inclusive: inclusive, set: true}
+		}
+	}
+	lowerUpper := func(version string, inclusive bool) {
+		c := Compare(version, upper.version)
+		if !upper.set || c < 0 || c == 0 && !inclusive {
+			upper = bound{version: version, inclusive: inclusive, set: true}
+		}
+	}
+
+	for _, constraint := range constraints {
+		op, version, err := parseConstraint(constraint)
+		if err != nil {
+			return false, err
+		}
+		switch op {
+		case "=":
+			raiseLower(version, true)
+			lowerUpper(version, true)
+		case "!=":
+			excluded = append(excluded, version)
+		case ">":
+			raiseLower(version, false)
+		case ">=":
+			raiseLower(version, true)
+		case "<":
+			lowerUpper(version, false)
+		case "<=":
+			lowerUpper(version, true)
+		case "~>":
+			raiseLower(version, true)
+			lowerUpper(Bump(version), false)
+		}
+	}
+
+	if !lower.set || !upper.set {
+		return true, nil
+	}
+	switch c := Compare(lower.version, upper.version); {
+	case c > 0:
+		return false, nil
+	case c == 0:
+		// Only a single version is left; it must be allowed by both ends and not excluded
+		return lower.inclusive && upper.inclusive && !slices.ContainsFunc(excluded, func(v string) bool {
+			return Compare(v, lower.version) == 0
+		}), nil
+	}
+	return true, nil
+}
@@ -0,0 +1,108 @@
+package gemversion
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"3.2.0", "3.2", 0},
+		{"3.10.0", "3.9.1", 1},
+		{"3.3.0.rc1", "3.3.0", -1},
+		{"3.3.0.preview1", "3.3.0.rc1", -1},
+		{"2.7.8", "3.0", -1},
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := map[string]string{
+		"2.7.1":     "2.8",
+		"3.0":       "4",
+		"3":         "4",
+		"1.0.0.rc1": "1.1",
+	}
+	for version, want := range tests {
+		if got := Bump(version); got != want {
+			t.Errorf("Bump(%s) = %s, want %s", version, got, want)
+		}
+	}
+}
+
+func TestIsPrerelease(t *testing.T) {
+	tests := map[string]bool{
+		"8.1.0.rc1":             true,
+		"0.5.11.pre.1":          true,
+		"2.0.0.beta2":           true,
+		"3.0.9":                 false,
+		"1.16.0-x86_64-linux":   false,
+		"1.16.0-x64-mingw-ucrt": false,
+		"2.0.0.rc1-java":        true,
+		"latest":                false,
+		"":                      false,
+	}
+	for version, want := range tests {
+		if got := IsPrerelease(version); got != want {
+			t.Errorf("IsPrerelease(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version     string
+		constraints []string
+		want        bool
+	}{
+		{"7.0.8", []string{"~> 7.0"}, true},
+		{"8.0.0", []string{"~> 7.0"}, false},
+		{"7.1.0", []string{">= 7.0", "< 7.2"}, true},
+		{"7.1.0", []string{"!= 7.1.0"}, false},
+		{"7.1.0", []string{"7.1"}, true},
+	}
+	for _, tt := range tests {
+		got, err := Satisfies(tt.version, tt.constraints)
+		if err != nil {
+			t.Fatalf("Satisfies(%s, %v) failed: %v", tt.version, tt.constraints, err)
+		}
+		if got != tt.want {
+			t.Errorf("Satisfies(%s, %v) = %v, want %v", tt.version, tt.constraints, got, tt.want)
+		}
+	}
+
+	if _, err := Satisfies("1.0", []string{">= ..."}); err == nil {
+		t.Error("expected an error for an invalid requirement")
+	}
+}
+
+func TestIntersects(t *testing.T) {
+	tests := []struct {
+		constraints []string
+		want        bool
+	}{
+		{nil, true},
+		{[]string{"~> 7.0", "~> 8.0"}, false},
+		{[]string{"~> 7.0", ">= 7.1"}, true},
+		{[]string{"~> 7.0.4", "~> 7.1"}, false},
+		{[]string{">= 2.0", "< 2.0"}, false},
+		{[]string{">= 2.0", "<= 2.0"}, true},
+		{[]string{"= 2.0", "!= 2.0.0"}, false},
+		{[]string{"= 2.0", "> 2.0"}, false},
+		{[]string{"> 1.0", "< 3"}, true},
+		{[]string{"!= 1.0"}, true},
+	}
+	for _, tt := range tests {
+		got, err := Intersects(tt.constraints)
+		if err != nil {
+			t.Fatalf("Intersects(%v) failed: %v", tt.constraints, err)
+		}
+		if got != tt.want {
+			t.Errorf("Intersects(%v) = %v, want %v", tt.constraints, got, tt.want)
+		}
+	}
+}
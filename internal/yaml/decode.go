@@ -0,0 +1,228 @@
+package yaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// line is a significant input line with its indentation split off
+type line struct {
+	number int
+	indent int
+	text   string
+}
+
+// decoder walks the lines of a document
+type decoder struct {
+	lines []line
+	pos   int
+}
+
+// Unmarshal reads a document written by Marshal into v. Block mappings and
+// sequences, plain and quoted scalars, null and the empty [] and {} flow
+// collections are understood; anchors, tags and multi-line scalars are not.
+func Unmarshal(data []byte, v any) error {
+	d := &decoder{}
+	for i, text := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(text, "\t") {
+			return fmt.Errorf("yaml: line %d: tabs are not allowed for indentation", i+1)
+		}
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		d.lines = append(d.lines, line{number: i + 1, indent: indent, text: strings.TrimRight(text[indent:], " ")})
+	}
+
+	var value any
+	if len(d.lines) > 0 {
+		var err error
+		if value, err = d.parseBlock(d.lines[0].indent); err != nil {
+			return err
+		}
+		if d.pos < len(d.lines) {
+			return fmt.Errorf("yaml: line %d: unexpected indentation", d.lines[d.pos].number)
+		}
+	}
+
+	// Route through encoding/json so field names and types follow the same rules as Marshal
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// parseBlock parses the mapping, sequence or scalar starting at the current line
+func (d *decoder) parseBlock(indent int) (any, error) {
+	l := d.lines[d.pos]
+	if isSequenceItem(l.text) {
+		return d.parseSequence(indent)
+	}
+	if _, _, ok, err := splitKey(l); err != nil {
+		return nil, err
+	} else if ok {
+		return d.parseMapping(indent)
+	}
+	d.pos++
+	return parseScalar(l.text, l.number)
+}
+
+// parseMapping parses consecutive "key: value" lines at indent
+func (d *decoder) parseMapping(indent int) (map[string]any, error) {
+	result := make(map[string]any)
+	for d.pos < len(d.lines) && d.lines[d.pos].indent == indent && !isSequenceItem(d.lines[d.pos].text) {
+		l := d.lines[d.pos]
+		key, rest, ok, err := splitKey(l)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("yaml: line %d: expected a mapping key", l.number)
+		}
+		if _, exists := result[key]; exists {
+			return nil, fmt.Errorf("yaml: line %d: duplicate key %q", l.number, key)
+		}
+		d.pos++
+
+		var value any
+		switch {
+		case rest != "":
+			value, err = parseScalar(rest, l.number)
+		case d.pos < len(d.lines) && d.lines[d.pos].indent > indent:
+			value, err = d.parseBlock(d.lines[d.pos].indent)
+		case d.pos < len(d.lines) && d.lines[d.pos].indent == indent && isSequenceItem(d.lines[d.pos].text):
+			// A sequence may sit at the same indentation as its key
+			value, err = d.parseSequence(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// parseSequence parses consecutive "- item" lines at indent
+func (d *decoder) parseSequence(indent int) ([]any, error) {
+	result := []any{}
+	for d.pos < len(d.lines) && d.lines[d.pos].indent == indent && isSequenceItem(d.lines[d.pos].text) {
+		l := d.lines[d.pos]
+		rest := strings.TrimLeft(l.text[1:], " ")
+
+		var value any
+		var err error
+		if rest == "" {
+			d.pos++
+			if d.pos < len(d.lines) && d.lines[d.pos].indent > indent {
+				value, err = d.parseBlock(d.lines[d.pos].indent)
+			}
+		} else {
+			// Treat the text after the dash as a line of its own, indented past the dash
+			d.lines[d.pos] = line{number: l.number, indent: indent + len(l.text) - len(rest), text: rest}
+			value, err = d.parseBlock(d.lines[d.pos].indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// isSequenceItem reports whether text starts a "- " sequence entry
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitKey splits a "key: value" line, reporting ok=false when the line isn't a mapping entry
+func splitKey(l line) (key, rest string, ok bool, err error) {
+	text := l.text
+	if strings.HasPrefix(text, `"`) {
+		end := closingQuote(text)
+		if end < 0 {
+			return "", "", false, fmt.Errorf("yaml: line %d: unterminated quoted string", l.number)
+		}
+		after := text[end+1:]
+		if after != ":" && !strings.HasPrefix(after, ": ") {
+			return "", "", false, nil
+		}
+		key, err = strconv.Unquote(text[:end+1])
+		if err != nil {
+			return "", "", false, fmt.Errorf("yaml: line %d: %w", l.number, err)
+		}
+		return key, strings.TrimSpace(after[1:]), true, nil
+	}
+
+	if strings.HasSuffix(text, ":") && !strings.Contains(text, ": ") {
+		return text[:len(text)-1], "", true, nil
+	}
+	key, rest, found := strings.Cut(text, ": ")
+	if !found || strings.HasPrefix(text, "'") {
+		return "", "", false, nil
+	}
+	return key, strings.TrimSpace(rest), true, nil
+}
+
+// closingQuote returns the index of the quote ending the double-quoted string at the start of s
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseScalar converts an inline value into a string, bool, number, nil or empty collection
+func parseScalar(text string, number int) (any, error) {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		end := closingQuote(text)
+		if end < 0 || strings.TrimSpace(stripComment(text[end+1:])) != "" {
+			return nil, fmt.Errorf("yaml: line %d: malformed quoted string", number)
+		}
+		s, err := strconv.Unquote(text[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("yaml: line %d: %w", number, err)
+		}
+		return s, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("yaml: line %d: malformed quoted string", number)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+
+	text = strings.TrimSpace(stripComment(text))
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "[]":
+		return []any{}, nil
+	case "{}":
+		return map[string]any{}, nil
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil && json.Valid([]byte(text)) {
+		return json.Number(text), nil
+	}
+	return text, nil
+}
+
+// stripComment drops a trailing " #" comment from a plain scalar
+func stripComment(text string) string {
+	if i := strings.Index(text, " #"); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
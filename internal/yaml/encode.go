@@ -0,0 +1,297 @@
+// Package yaml emits and reads the small block-style YAML subset used to
+// export parsed Gemfiles and lockfiles, without pulling in a YAML dependency.
+//
+// Field names follow encoding/json rules (json tags, omitempty and "-"), so a
+// value exported as YAML and as JSON uses the same keys.
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nodeKind identifies what a node holds
+type nodeKind int
+
+const (
+	nullNode nodeKind = iota
+	scalarNode
+	mappingNode
+	sequenceNode
+)
+
+// node is an ordered, already-quoted representation of a value
+type node struct {
+	kind   nodeKind
+	scalar string // Rendered scalar, quoted when needed
+	keys   []string
+	values []node // Mapping values (parallel to keys) or sequence items
+}
+
+// Marshal renders v as a block-style YAML document
+func Marshal(v any) ([]byte, error) {
+	n, err := toNode(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	switch {
+	case n.kind == mappingNode && len(n.keys) > 0:
+		writeMapping(&b, n, 0, false)
+	case n.kind == sequenceNode && len(n.values) > 0:
+		writeSequence(&b, n, 0)
+	default:
+		b.WriteString(inline(n))
+		b.WriteByte('\n')
+	}
+	return []byte(b.String()), nil
+}
+
+// toNode converts a Go value into a node
+func toNode(v reflect.Value) (node, error) {
+	if !v.IsValid() {
+		return node{kind: nullNode}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return node{kind: nullNode}, nil
+		}
+		return toNode(v.Elem())
+	case reflect.Struct:
+		n := node{kind: mappingNode}
+		if err := appendFields(&n, v); err != nil {
+			return node{}, err
+		}
+		return n, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return node{kind: nullNode}, nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return node{}, fmt.Errorf("yaml: unsupported map key type %s", v.Type().Key())
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+		n := node{kind: mappingNode}
+		for _, key := range keys {
+			value, err := toNode(v.MapIndex(key))
+			if err != nil {
+				return node{}, err
+			}
+			n.keys = append(n.keys, key.String())
+			n.values = append(n.values, value)
+		}
+		return n, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return node{kind: nullNode}, nil
+		}
+		n := node{kind: sequenceNode}
+		for i := 0; i < v.Len(); i++ {
+			item, err := toNode(v.Index(i))
+			if err != nil {
+				return node{}, err
+			}
+			n.values = append(n.values, item)
+		}
+		return n, nil
+	case reflect.String:
+		return node{kind: scalarNode, scalar: quote(v.String())}, nil
+	case reflect.Bool:
+		return node{kind: scalarNode, scalar: strconv.FormatBool(v.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return node{kind: scalarNode, scalar: strconv.FormatInt(v.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return node{kind: scalarNode, scalar: strconv.FormatUint(v.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return node{kind: scalarNode, scalar: strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())}, nil
+	default:
+		return node{}, fmt.Errorf("yaml: unsupported type %s", v.Type())
+	}
+}
+
+// appendFields adds the exported fields of a struct to a mapping node,
+// inlining untagged embedded structs like encoding/json does
+func appendFields(n *node, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty, skip := fieldName(field)
+		if skip {
+			continue
+		}
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" && value.Kind() == reflect.Struct {
+			if err := appendFields(n, value); err != nil {
+				return err
+			}
+			continue
+		}
+		if omitEmpty && isEmptyValue(value) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		child, err := toNode(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		n.keys = append(n.keys, name)
+		n.values = append(n.values, child)
+	}
+	return nil
+}
+
+// fieldName reads the json tag of a struct field
+func fieldName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false, true
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	return name, slices.Contains(strings.Split(options, ","), "omitempty"), false
+}
+
+// isEmptyValue mirrors the values encoding/json drops for omitempty
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return v.IsZero()
+	}
+	return false
+}
+
+// writeMapping writes a non-empty mapping, one key per line.
+// afterDash means the first key continues a "- " sequence line.
+func writeMapping(b *strings.Builder, n node, indent int, afterDash bool) {
+	for i, key := range n.keys {
+		if i > 0 || !afterDash {
+			b.WriteString(strings.Repeat(" ", indent))
+		}
+		b.WriteString(quote(key))
+		b.WriteByte(':')
+		writeChild(b, n.values[i], indent+2)
+	}
+}
+
+// writeSequence writes a non-empty sequence, one "- " item per line
+func writeSequence(b *strings.Builder, n node, indent int) {
+	for _, item := range n.values {
+		b.WriteString(strings.Repeat(" ", indent))
+		b.WriteByte('-')
+		switch {
+		case item.kind == mappingNode && len(item.keys) > 0:
+			// The first key shares the dash line
+			b.WriteByte(' ')
+			writeMapping(b, item, indent+2, true)
+		default:
+			writeChild(b, item, indent+2)
+		}
+	}
+}
+
+// writeChild writes the value following a "key:" or "-", inline when it fits on the line
+func writeChild(b *strings.Builder, n node, indent int) {
+	switch {
+	case n.kind == mappingNode && len(n.keys) > 0:
+		b.WriteByte('\n')
+		writeMapping(b, n, indent, false)
+	case n.kind == sequenceNode && len(n.values) > 0:
+		b.WriteByte('\n')
+		writeSequence(b, n, indent)
+	default:
+		b.WriteByte(' ')
+		b.WriteString(inline(n))
+		b.WriteByte('\n')
+	}
+}
+
+// inline renders a scalar, null or empty collection
+func inline(n node) string {
+	switch n.kind {
+	case scalarNode:
+		return n.scalar
+	case mappingNode:
+		return "{}"
+	case sequenceNode:
+		return "[]"
+	default:
+		return "null"
+	}
+}
+
+// quote returns s as a plain scalar when that reads back as the same string,
+// and double-quoted otherwise
+func quote(s string) string {
+	if needsQuotes(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// needsQuotes reports whether a plain scalar would be read back as something else
+func needsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "null", "~", "true", "false", "yes", "no", "on", "off", "y", "n", "=", "<<":
+		return true
+	case ".inf", "-.inf", "+.inf", ".nan":
+		return true
+	}
+	if strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return true
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f || !strconv.IsPrint(r) && r != ' ' {
+			return true
+		}
+	}
+	return looksNumeric(s) || looksLikeDate(s) || sexagesimalRegex.MatchString(s)
+}
+
+// sexagesimalRegex matches YAML 1.1 base 60 numbers, e.g. 1:30 reads as 90
+var sexagesimalRegex = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+(?:\.[0-9_]*)?$`)
+
+// looksNumeric reports whether s would be read as a number, e.g. a "1.0" version
+func looksNumeric(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseInt(s, 0, 64)
+	return err == nil
+}
+
+// looksLikeDate reports whether s starts like a YAML timestamp
+func looksLikeDate(s string) bool {
+	if len(s) < len(time.DateOnly) {
+		return false
+	}
+	_, err := time.Parse(time.DateOnly, s[:len(time.DateOnly)])
+	return err == nil
+}
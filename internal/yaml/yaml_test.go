@@ -0,0 +1,180 @@
+package yaml
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type testInner struct {
+	Kind  string
+	Count int `json:"count,omitempty"`
+}
+
+type testDoc struct {
+	Name     string            `json:"name"`
+	Version  string            `json:"version"`
+	Enabled  bool              `json:"enabled"`
+	Items    []testInner       `json:"items"`
+	Tags     []string          `json:"tags"`
+	Nested   [][]string        `json:"nested"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Optional *string           `json:"optional"`
+	Missing  []string          `json:"missing"`
+	Empty    []string          `json:"empty"`
+	Skipped  string            `json:"-"`
+	Untagged string
+}
+
+func TestMarshal(t *testing.T) {
+	doc := testDoc{
+		Name:     "rails",
+		Version:  "7.0",
+		Enabled:  true,
+		Items:    []testInner{{Kind: "gem", Count: 2}, {Kind: "- dash"}},
+		Tags:     []string{"web", ""},
+		Labels:   map[string]string{"b": "two", "a": "one: 1"},
+		Empty:    []string{},
+		Skipped:  "hidden",
+		Untagged: "plain text",
+	}
+
+	got, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	expected := `name: rails
+version: "7.0"
+enabled: true
+items:
+  - Kind: gem
+    count: 2
+  - Kind: "- dash"
+tags:
+  - web
+  - ""
+nested: null
+labels:
+  a: "one: 1"
+  b: two
+optional: null
+missing: null
+empty: []
+Untagged: plain text
+`
+	if string(got) != expected {
+		t.Errorf("unexpected output:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	optional := ""
+	doc := testDoc{
+		Name:     "weird: name # not a comment",
+		Version:  "2024-01-01",
+		Items:    []testInner{{Kind: "true"}, {Kind: "line\nbreak", Count: -3}},
+		Tags:     []string{"null", "~", "1e3", "0x10", " padded "},
+		Nested:   [][]string{{"a", "b"}, {}, nil},
+		Labels:   map[string]string{"1.0": "x", "key:": "[y]"},
+		Optional: &optional,
+		Empty:    []string{},
+		Untagged: "tab\there",
+	}
+
+	data, err := Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded testDoc
+	if err := Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(decoded, doc) {
+		t.Errorf("round trip mismatch:\n got: %#v\nwant: %#v\nyaml:\n%s", decoded, doc, data)
+	}
+}
+
+func TestMarshalSpecialScalars(t *testing.T) {
+	// Unquoted, each of these reads back as a float, null, bool, merge key or base 60 int
+	special := []string{".inf", "-.inf", "+.INF", ".NaN", "~", "Null", "NULL", "YES", "Off", "=", "<<", "1:30", "-1:20:05.5", "1_000", "0o17", "inf", "NaN"}
+
+	for _, s := range special {
+		data, err := Marshal(map[string]string{"v": s})
+		if err != nil {
+			t.Fatalf("Marshal(%q) failed: %v", s, err)
+		}
+		if want := "v: " + strconv.Quote(s) + "\n"; string(data) != want {
+			t.Errorf("Marshal(%q) = %q, want %q", s, data, want)
+		}
+
+		var decoded map[string]string
+		if err := Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal(%q) failed: %v", data, err)
+		}
+		if decoded["v"] != s {
+			t.Errorf("round trip of %q gave %q", s, decoded["v"])
+		}
+	}
+
+	// Plain strings that merely resemble them stay unquoted
+	for _, s := range []string{"~> 7.0", "infinity-gem", "1.2.3", "12:00 noon"} {
+		data, err := Marshal(map[string]string{"v": s})
+		if err != nil {
+			t.Fatalf("Marshal(%q) failed: %v", s, err)
+		}
+		if want := "v: " + s + "\n"; string(data) != want {
+			t.Errorf("Marshal(%q) = %q, want %q", s, data, want)
+		}
+	}
+}
+
+func TestUnmarshalHandwritten(t *testing.T) {
+	input := `# exported by hand
+---
+name: 'it''s'
+version: 1.2.3 # trailing comment
+items:
+- Kind: gem
+  count: 4
+tags: [ ]
+`
+	var decoded testDoc
+	err := Unmarshal([]byte(input), &decoded)
+	if err == nil {
+		t.Fatal("expected an error for the unsupported flow sequence")
+	}
+
+	input = strings.Replace(input, "[ ]", "[]", 1)
+	if err := Unmarshal([]byte(input), &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Name != "it's" || decoded.Version != "1.2.3" {
+		t.Errorf("unexpected scalars: %q, %q", decoded.Name, decoded.Version)
+	}
+	if len(decoded.Items) != 1 || decoded.Items[0].Count != 4 {
+		t.Errorf("unexpected items: %+v", decoded.Items)
+	}
+	if decoded.Tags == nil || len(decoded.Tags) != 0 {
+		t.Errorf("expected an empty, non-nil tags slice, got %#v", decoded.Tags)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := map[string]string{
+		"bad indentation": "name: a\n  version: b\n",
+		"duplicate key":   "name: a\nname: b\n",
+		"unterminated":    "name: \"a\n",
+		"tab indentation": "items:\n\t- a\n",
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			var decoded testDoc
+			if err := Unmarshal([]byte(input), &decoded); err == nil {
+				t.Errorf("expected an error for %q", input)
+			}
+		})
+	}
+}
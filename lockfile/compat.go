@@ -0,0 +1,75 @@
+package lockfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/gemfile-go/internal/gemversion"
+)
+
+// CheckRubyCompat returns the sorted names of locked gems whose
+// RequiredRubyVersion excludes rubyVersion. Gems without a recorded
+// requirement, or with one that can't be parsed, are not reported.
+// Ruby equivalent: spec.required_ruby_version.satisfied_by?(Gem::Version.new(ruby_version))
+//
+// Bundler doesn't write required_ruby_version to the lockfile, so it has to
+// be filled in first, e.g. with ApplyGemspec or LoadRequiredRubyVersions.
+func CheckRubyCompat(l *Lockfile, rubyVersion string) []string {
+	seen := make(map[string]bool)
+	var incompatible []string
+
+	check := func(name, requirement string) {
+		if requirement == "" || seen[name] {
+			return
+		}
+		if ok, err := requirementSatisfied(requirement, rubyVersion); err == nil && !ok {
+			seen[name] = true
+			incompatible = append(incompatible, name)
+		}
+	}
+
+	for i := range l.GemSpecs {
+		check(l.GemSpecs[i].Name, l.GemSpecs[i].RequiredRubyVersion)
+	}
+	for i := range l.GitSpecs {
+		check(l.GitSpecs[i].Name, l.GitSpecs[i].RequiredRubyVersion)
+	}
+	for i := range l.PathSpecs {
+		check(l.PathSpecs[i].Name, l.PathSpecs[i].RequiredRubyVersion)
+	}
+
+	slices.Sort(incompatible)
+	return incompatible
+}
+
+// LoadRequiredRubyVersions fills in RequiredRubyVersion for GEM section specs
+// from installed gem specifications, such as the specifications directory of
+// a GEM_HOME. Each spec is looked up as <dir>/<name>-<version>[-<platform>].gemspec;
+// missing files are skipped.
+func (l *Lockfile) LoadRequiredRubyVersions(specificationsDir string) error {
+	for i := range l.GemSpecs {
+		spec := &l.GemSpecs[i]
+		path := filepath.Join(specificationsDir, spec.FullName()+".gemspec")
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		gemspec, err := gemfile.NewGemspecParser(path).Parse()
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if gemspec.RequiredRubyVersion != "" {
+			spec.RequiredRubyVersion = gemspec.RequiredRubyVersion
+		}
+	}
+	return nil
+}
+
+// requirementSatisfied reports whether version meets every comma-separated
+// constraint in requirement, using RubyGems comparison rules
+func requirementSatisfied(requirement, version string) (bool, error) {
+	return gemversion.Satisfies(version, parseConstraints(requirement))
+}
@@ -0,0 +1,71 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckRubyCompat(t *testing.T) {
+	lockfileContent := `GEM
+  remote: https://rubygems.org/
+  specs:
+    legacy_gem (1.0.0)
+    modern_gem (2.0.0)
+    pessimistic_gem (0.5.0)
+    plain_gem (1.2.0)
+
+DEPENDENCIES
+  legacy_gem
+  modern_gem
+  pessimistic_gem
+  plain_gem
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	specDir := t.TempDir()
+	writeSpec := func(fullName, requirement string) {
+		content := `Gem::Specification.new do |s|
+  s.name = "` + strings.SplitN(fullName, "-", 2)[0] + `"
+  s.version = "` + strings.SplitN(fullName, "-", 2)[1] + `"
+  s.required_ruby_version = Gem::Requirement.new(` + requirement + `)
+end
+`
+		if err := os.WriteFile(filepath.Join(specDir, fullName+".gemspec"), []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write gemspec: %v", err)
+		}
+	}
+	writeSpec("legacy_gem-1.0.0", `">= 2.0", "< 3.0"`)
+	writeSpec("modern_gem-2.0.0", `">= 3.3"`)
+	writeSpec("pessimistic_gem-0.5.0", `"~> 3.1"`)
+
+	if err := lockfile.LoadRequiredRubyVersions(specDir); err != nil {
+		t.Fatalf("LoadRequiredRubyVersions failed: %v", err)
+	}
+	if legacy := lockfile.FindGem("legacy_gem"); legacy.RequiredRubyVersion != ">= 2.0, < 3.0" {
+		t.Errorf("Expected legacy_gem required ruby version '>= 2.0, < 3.0', got %q", legacy.RequiredRubyVersion)
+	}
+	if plain := lockfile.FindGem("plain_gem"); plain.RequiredRubyVersion != "" {
+		t.Errorf("Expected plain_gem without a specification to stay empty, got %q", plain.RequiredRubyVersion)
+	}
+
+	tests := []struct {
+		ruby     string
+		expected []string
+	}{
+		{"2.7.8", []string{"modern_gem", "pessimistic_gem"}},
+		{"3.2.2", []string{"legacy_gem", "modern_gem"}},
+		{"3.3.0", []string{"legacy_gem"}},
+		{"4.0.0", []string{"legacy_gem", "pessimistic_gem"}},
+	}
+	for _, tt := range tests {
+		if got := CheckRubyCompat(lockfile, tt.ruby); strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("CheckRubyCompat(%s) = %v, want %v", tt.ruby, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package lockfile
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+)
+
+// FromGemDependency converts a Gemfile dependency into a DEPENDENCIES entry.
+// Gems with a git or path source get Bundler's "!" marker, and their repository
+// or path is kept in Source.
+func FromGemDependency(dep *gemfile.GemDependency) Dependency {
+	lockDep := Dependency{
+		Name:        dep.Name,
+		Constraints: slices.Clone(dep.Constraints),
+	}
+	if dep.Source != nil && (dep.Source.Type == sourceTypeGit || dep.Source.Type == sourceTypePath) {
+		lockDep.Name += "!"
+		lockDep.Source = dep.Source.URL
+	}
+	return lockDep
+}
+
+// ToGemDependency converts a DEPENDENCIES entry back into a Gemfile dependency
+// in the default group. An entry with the "!" marker and a Source becomes a git
+// dependency when Source looks like a repository URL and a path dependency
+// otherwise; entries without the marker get no source, since they come from
+// the GEM section.
+func (d *Dependency) ToGemDependency() gemfile.GemDependency {
+	name, explicitSource := strings.CutSuffix(d.Name, "!")
+	dep := gemfile.GemDependency{
+		Name:        name,
+		Constraints: slices.Clone(d.Constraints),
+		Groups:      []string{"default"},
+	}
+	if explicitSource && d.Source != "" {
+		sourceType := sourceTypePath
+		if strings.Contains(d.Source, "://") || strings.HasPrefix(d.Source, "git@") {
+			sourceType = sourceTypeGit
+		}
+		dep.Source = &gemfile.Source{Type: sourceType, URL: d.Source}
+	}
+	return dep
+}
@@ -0,0 +1,52 @@
+package lockfile
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+)
+
+func TestDependencyConversion(t *testing.T) {
+	tests := []struct {
+		name    string
+		gemDep  gemfile.GemDependency
+		lockDep Dependency
+	}{
+		{
+			name:    "plain gem",
+			gemDep:  gemfile.GemDependency{Name: "rails", Constraints: []string{"~> 7.1", ">= 7.1.3"}, Groups: []string{"default"}},
+			lockDep: Dependency{Name: "rails", Constraints: []string{"~> 7.1", ">= 7.1.3"}},
+		},
+		{
+			name: "git gem",
+			gemDep: gemfile.GemDependency{
+				Name:        "state_machines",
+				Constraints: []string{">= 0.6"},
+				Source:      &gemfile.Source{Type: "git", URL: "https://github.com/seuros/state_machines.git"},
+				Groups:      []string{"default"},
+			},
+			lockDep: Dependency{Name: "state_machines!", Constraints: []string{">= 0.6"}, Source: "https://github.com/seuros/state_machines.git"},
+		},
+		{
+			name: "path gem",
+			gemDep: gemfile.GemDependency{
+				Name:   "billing",
+				Source: &gemfile.Source{Type: "path", URL: "engines/billing"},
+				Groups: []string{"default"},
+			},
+			lockDep: Dependency{Name: "billing!", Source: "engines/billing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromGemDependency(&tt.gemDep); !reflect.DeepEqual(got, tt.lockDep) {
+				t.Errorf("FromGemDependency() = %+v, want %+v", got, tt.lockDep)
+			}
+			if got := tt.lockDep.ToGemDependency(); !reflect.DeepEqual(got, tt.gemDep) {
+				t.Errorf("ToGemDependency() = %+v, want %+v", got, tt.gemDep)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package lockfile
+
+import (
+	"strings"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+)
+
+// Source types compared by SourceDrift
+const (
+	sourceTypeRubygems = "rubygems"
+	sourceTypeGit      = "git"
+	sourceTypePath     = "path"
+)
+
+// SourceDrift returns the names of Gemfile dependencies whose declared source
+// type (git, path or rubygems) differs from the section the lockfile resolved
+// them in, in Gemfile order. A gem declared with github: but locked under GEM,
+// for example, means the lockfile is stale. Gems missing from the lockfile are
+// not reported.
+func SourceDrift(g *gemfile.ParsedGemfile, l *Lockfile) []string {
+	locked := make(map[string]string)
+	for i := range l.GemSpecs {
+		locked[l.GemSpecs[i].Name] = sourceTypeRubygems
+	}
+	// A gem moved to git or path may still have a stale GEM entry; the explicit source wins
+	for i := range l.GitSpecs {
+		locked[l.GitSpecs[i].Name] = sourceTypeGit
+	}
+	for i := range l.PathSpecs {
+		locked[l.PathSpecs[i].Name] = sourceTypePath
+	}
+
+	var drifted []string
+	seen := make(map[string]bool)
+	for i := range g.Dependencies {
+		dep := &g.Dependencies[i]
+		lockedType, ok := locked[dep.Name]
+		if !ok || seen[dep.Name] {
+			continue
+		}
+		seen[dep.Name] = true
+
+		declaredType := sourceTypeRubygems
+		if dep.Source != nil && (dep.Source.Type == sourceTypeGit || dep.Source.Type == sourceTypePath) {
+			declaredType = dep.Source.Type
+		}
+		if declaredType != lockedType {
+			drifted = append(drifted, dep.Name)
+		}
+	}
+
+	return drifted
+}
+
+// MissingFromLockfile returns the names of Gemfile dependencies the lockfile
+// doesn't know about at all, in Gemfile order: no GEM, GIT or PATH spec and no
+// DEPENDENCIES entry (the "!" marker is ignored). A gem declared after the last
+// bundle install shows up here, which means the lockfile is out of date.
+// DEPENDENCIES entries count because Bundler lists gems restricted to platforms
+// the lockfile doesn't cover there without locking a spec for them.
+func MissingFromLockfile(g *gemfile.ParsedGemfile, l *Lockfile) []string {
+	known := make(map[string]bool)
+	for i := range l.GemSpecs {
+		known[l.GemSpecs[i].Name] = true
+	}
+	for i := range l.GitSpecs {
+		known[l.GitSpecs[i].Name] = true
+	}
+	for i := range l.PathSpecs {
+		known[l.PathSpecs[i].Name] = true
+	}
+	for i := range l.Dependencies {
+		known[strings.TrimSuffix(l.Dependencies[i].Name, "!")] = true
+	}
+
+	var missing []string
+	for i := range g.Dependencies {
+		name := g.Dependencies[i].Name
+		if !known[name] {
+			missing = append(missing, name)
+			// Report gems declared more than once a single time
+			known[name] = true
+		}
+	}
+
+	return missing
+}
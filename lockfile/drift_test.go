@@ -0,0 +1,110 @@
+package lockfile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+)
+
+func TestSourceDrift(t *testing.T) {
+	lockfileContent := `GIT
+  remote: https://github.com/seuros/state_machines.git
+  revision: def456abc789
+  specs:
+    state_machines (0.6.0)
+
+PATH
+  remote: engines/billing
+  specs:
+    billing (0.1.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    no_fly_list (0.6.0)
+    rack (3.0.9)
+    rails (7.1.3)
+
+DEPENDENCIES
+  billing!
+  no_fly_list
+  rack
+  rails
+  state_machines!
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	parsed := &gemfile.ParsedGemfile{
+		Dependencies: []gemfile.GemDependency{
+			{Name: "rails"},
+			{Name: "rack", Source: &gemfile.Source{Type: "rubygems", URL: "https://rubygems.org"}},
+			{Name: "no_fly_list", Source: &gemfile.Source{Type: "git", URL: "https://github.com/seuros/no_fly_list.git"}},
+			{Name: "state_machines", Source: &gemfile.Source{Type: "git", URL: "https://github.com/seuros/state_machines.git"}},
+			{Name: "billing", Source: &gemfile.Source{Type: "git", URL: "https://github.com/example/billing.git"}},
+			{Name: "not_locked_yet", Source: &gemfile.Source{Type: "path", URL: "vendor/not_locked_yet"}},
+		},
+	}
+
+	expected := []string{"no_fly_list", "billing"}
+	if got := SourceDrift(parsed, lockfile); !reflect.DeepEqual(got, expected) {
+		t.Errorf("SourceDrift() = %v, want %v", got, expected)
+	}
+}
+
+func TestMissingFromLockfile(t *testing.T) {
+	lockfileContent := `GIT
+  remote: https://github.com/seuros/state_machines.git
+  revision: def456abc789
+  specs:
+    state_machines (0.6.0)
+
+PATH
+  remote: engines/billing
+  specs:
+    billing (0.1.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (3.0.9)
+    rails (7.1.3)
+
+PLATFORMS
+  x86_64-linux
+
+DEPENDENCIES
+  billing!
+  rails
+  state_machines!
+  tzinfo-data
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	parsed := &gemfile.ParsedGemfile{
+		Dependencies: []gemfile.GemDependency{
+			{Name: "rails"},
+			{Name: "sidekiq"},
+			{Name: "state_machines", Source: &gemfile.Source{Type: "git", URL: "https://github.com/seuros/state_machines.git"}},
+			{Name: "billing", Source: &gemfile.Source{Type: "path", URL: "engines/billing"}},
+			{Name: "tzinfo-data", Platforms: []string{"windows"}},
+			{Name: "rack"},
+			{Name: "pry", Groups: []string{"development"}},
+			{Name: "sidekiq", Platforms: []string{"ruby"}},
+		},
+	}
+
+	expected := []string{"sidekiq", "pry"}
+	if got := MissingFromLockfile(parsed, lockfile); !reflect.DeepEqual(got, expected) {
+		t.Errorf("MissingFromLockfile() = %v, want %v", got, expected)
+	}
+}
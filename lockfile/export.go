@@ -0,0 +1,233 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/contriboss/gemfile-go/internal/yaml"
+)
+
+const (
+	cycloneDXFormat      = "CycloneDX"
+	cycloneDXSpecVersion = "1.5"
+)
+
+// Source types used in a Manifest
+const (
+	ManifestSourceRegistry = "registry"
+	ManifestSourceGit      = "git"
+	ManifestSourcePath     = "path"
+)
+
+// Manifest is a language-agnostic list of the packages resolved in a lockfile,
+// meant as a stable interchange format for tooling that handles several
+// ecosystems. Its field names are part of the format and won't change.
+type Manifest struct {
+	Ecosystem string            `json:"ecosystem" yaml:"ecosystem"` // Always "rubygems"
+	Packages  []ManifestPackage `json:"packages" yaml:"packages"`
+}
+
+// ManifestPackage is a single resolved package in a Manifest.
+type ManifestPackage struct {
+	Name           string `json:"name" yaml:"name"`
+	Version        string `json:"version" yaml:"version"`
+	Platform       string `json:"platform,omitempty" yaml:"platform,omitempty"`
+	SourceType     string `json:"source_type" yaml:"source_type"`                             // registry, git or path
+	SourceLocation string `json:"source_location,omitempty" yaml:"source_location,omitempty"` // Registry URL, git remote or local path
+	Revision       string `json:"revision,omitempty" yaml:"revision,omitempty"`               // Git commit, for git packages
+	Direct         bool   `json:"direct" yaml:"direct"`                                       // Listed in DEPENDENCIES
+}
+
+// cycloneDXBOM is the minimal CycloneDX document emitted by ToCycloneDX
+type cycloneDXBOM struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Components  []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type               string                 `json:"type"`
+	BOMRef             string                 `json:"bom-ref"`
+	Name               string                 `json:"name"`
+	Version            string                 `json:"version"`
+	PURL               string                 `json:"purl"`
+	ExternalReferences []cycloneDXExternalRef `json:"externalReferences,omitempty"`
+	Properties         []cycloneDXProperty    `json:"properties,omitempty"`
+}
+
+type cycloneDXExternalRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// FlatList returns one "name version" entry per resolved gem across the GEM,
+// GIT and PATH sections, sorted and deduplicated.
+// Platform variants of the same gem collapse into a single entry.
+func (l *Lockfile) FlatList() []string {
+	seen := make(map[string]bool)
+	var entries []string
+
+	add := func(name, version string) {
+		entry := name + " " + version
+		if !seen[entry] {
+			seen[entry] = true
+			entries = append(entries, entry)
+		}
+	}
+
+	for i := range l.GemSpecs {
+		add(l.GemSpecs[i].Name, l.GemSpecs[i].Version)
+	}
+	for i := range l.GitSpecs {
+		add(l.GitSpecs[i].Name, l.GitSpecs[i].Version)
+	}
+	for i := range l.PathSpecs {
+		add(l.PathSpecs[i].Name, l.PathSpecs[i].Version)
+	}
+
+	slices.Sort(entries)
+	return entries
+}
+
+// ToCycloneDX writes a minimal CycloneDX JSON SBOM with one component per gem.
+// Each component carries a pkg:gem purl; git and path gems also record where
+// they were sourced from.
+func (l *Lockfile) ToCycloneDX(w io.Writer) error {
+	bom := cycloneDXBOM{
+		BOMFormat:   cycloneDXFormat,
+		SpecVersion: cycloneDXSpecVersion,
+		Version:     1,
+		Components:  []cycloneDXComponent{},
+	}
+
+	for i := range l.GemSpecs {
+		spec := &l.GemSpecs[i]
+		component := newCycloneDXComponent("gem", spec.Name, spec.Version, spec.Platform)
+		if spec.SourceURL != "" {
+			component.ExternalReferences = []cycloneDXExternalRef{{Type: "distribution", URL: spec.SourceURL}}
+		}
+		bom.Components = append(bom.Components, component)
+	}
+
+	for i := range l.GitSpecs {
+		spec := &l.GitSpecs[i]
+		component := newCycloneDXComponent("git", spec.Name, spec.Version, "")
+		component.ExternalReferences = []cycloneDXExternalRef{{Type: "vcs", URL: spec.Remote}}
+		component.Properties = []cycloneDXProperty{
+			{Name: "bundler:source", Value: "git"},
+			{Name: "bundler:revision", Value: spec.Revision},
+		}
+		bom.Components = append(bom.Components, component)
+	}
+
+	for i := range l.PathSpecs {
+		spec := &l.PathSpecs[i]
+		component := newCycloneDXComponent("path", spec.Name, spec.Version, "")
+		component.Properties = []cycloneDXProperty{
+			{Name: "bundler:source", Value: "path"},
+			{Name: "bundler:path", Value: spec.Remote},
+		}
+		bom.Components = append(bom.Components, component)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(bom); err != nil {
+		return fmt.Errorf("failed to encode CycloneDX SBOM: %w", err)
+	}
+	return nil
+}
+
+// newCycloneDXComponent builds a library component with a pkg:gem purl.
+// Platform-specific gems get a platform qualifier, and the bom-ref carries
+// the source kind so a gem locked in both GEM and GIT/PATH stays unique.
+func newCycloneDXComponent(source, name, version, platform string) cycloneDXComponent {
+	purl := fmt.Sprintf("pkg:gem/%s@%s", name, version)
+	if platform != "" {
+		purl += "?platform=" + url.QueryEscape(platform)
+	}
+
+	return cycloneDXComponent{
+		Type:    "library",
+		BOMRef:  source + ":" + purl,
+		Name:    name,
+		Version: version,
+		PURL:    purl,
+	}
+}
+
+// ToGenericManifest converts the lockfile into a Manifest with one package per
+// GEM, GIT and PATH spec, in that order.
+func (l *Lockfile) ToGenericManifest() *Manifest {
+	direct := make(map[string]bool, len(l.Dependencies))
+	for _, dep := range l.Dependencies {
+		// Gems from git/path sources are marked with a trailing "!"
+		direct[strings.TrimSuffix(dep.Name, "!")] = true
+	}
+
+	manifest := &Manifest{
+		Ecosystem: "rubygems",
+		Packages:  []ManifestPackage{},
+	}
+
+	for i := range l.GemSpecs {
+		spec := &l.GemSpecs[i]
+		manifest.Packages = append(manifest.Packages, ManifestPackage{
+			Name:           spec.Name,
+			Version:        spec.Version,
+			Platform:       spec.Platform,
+			SourceType:     ManifestSourceRegistry,
+			SourceLocation: spec.remote(),
+			Direct:         direct[spec.Name],
+		})
+	}
+
+	for i := range l.GitSpecs {
+		spec := &l.GitSpecs[i]
+		manifest.Packages = append(manifest.Packages, ManifestPackage{
+			Name:           spec.Name,
+			Version:        spec.Version,
+			SourceType:     ManifestSourceGit,
+			SourceLocation: spec.Remote,
+			Revision:       spec.Revision,
+			Direct:         direct[spec.Name],
+		})
+	}
+
+	for i := range l.PathSpecs {
+		spec := &l.PathSpecs[i]
+		manifest.Packages = append(manifest.Packages, ManifestPackage{
+			Name:           spec.Name,
+			Version:        spec.Version,
+			SourceType:     ManifestSourcePath,
+			SourceLocation: spec.Remote,
+			Direct:         direct[spec.Name],
+		})
+	}
+
+	return manifest
+}
+
+// ToYAML renders the lockfile as YAML, using the same field names as its JSON form.
+func (l *Lockfile) ToYAML() ([]byte, error) {
+	return yaml.Marshal(l)
+}
+
+// FromYAML reads a lockfile previously exported with ToYAML.
+func FromYAML(data []byte) (*Lockfile, error) {
+	var l Lockfile
+	if err := yaml.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to read lockfile YAML: %w", err)
+	}
+	return &l, nil
+}
@@ -0,0 +1,199 @@
+package lockfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFlatList(t *testing.T) {
+	data, err := os.ReadFile("../testdata/git.lock")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	lockfile, err := Parse(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	list := lockfile.FlatList()
+
+	expected := []string{
+		"activemodel 7.0.4",
+		"activerecord 7.0.4",
+		"activesupport 7.0.4",
+		"concurrent-ruby 1.2.2",
+		"i18n 1.14.1",
+		"minitest 5.19.0",
+		"no_fly_list 0.6.0",
+		"state_machines 0.6.0",
+		"tzinfo 2.0.6",
+	}
+
+	if strings.Join(list, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected flat list:\n%s", strings.Join(list, "\n"))
+	}
+}
+
+func TestToCycloneDX(t *testing.T) {
+	data, err := os.ReadFile("../testdata/git.lock")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	lockfile, err := Parse(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := lockfile.ToCycloneDX(&buf); err != nil {
+		t.Fatalf("ToCycloneDX failed: %v", err)
+	}
+
+	var bom struct {
+		BOMFormat  string `json:"bomFormat"`
+		Components []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+			PURL    string `json:"purl"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	if bom.BOMFormat != "CycloneDX" {
+		t.Errorf("expected bomFormat CycloneDX, got %q", bom.BOMFormat)
+	}
+
+	expectedCount := len(lockfile.GemSpecs) + len(lockfile.GitSpecs) + len(lockfile.PathSpecs)
+	if len(bom.Components) != expectedCount {
+		t.Fatalf("expected %d components, got %d", expectedCount, len(bom.Components))
+	}
+
+	for _, component := range bom.Components {
+		expectedPURL := "pkg:gem/" + component.Name + "@" + component.Version
+		if component.PURL != expectedPURL {
+			t.Errorf("expected purl %q, got %q", expectedPURL, component.PURL)
+		}
+	}
+}
+
+func TestToCycloneDXUniqueBOMRefs(t *testing.T) {
+	lockfile := &Lockfile{
+		GemSpecs:  []GemSpec{{Name: "rack", Version: "3.0.8"}},
+		GitSpecs:  []GitGemSpec{{Name: "rack", Version: "3.0.8", Remote: "https://github.com/rack/rack.git"}},
+		PathSpecs: []PathGemSpec{{Name: "rack", Version: "3.0.8", Remote: "vendor/rack"}},
+	}
+
+	var buf bytes.Buffer
+	if err := lockfile.ToCycloneDX(&buf); err != nil {
+		t.Fatalf("ToCycloneDX failed: %v", err)
+	}
+
+	var bom struct {
+		Components []struct {
+			BOMRef string `json:"bom-ref"`
+			PURL   string `json:"purl"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &bom); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	expected := []string{"gem:pkg:gem/rack@3.0.8", "git:pkg:gem/rack@3.0.8", "path:pkg:gem/rack@3.0.8"}
+	if len(bom.Components) != len(expected) {
+		t.Fatalf("expected %d components, got %d", len(expected), len(bom.Components))
+	}
+	for i, component := range bom.Components {
+		if component.BOMRef != expected[i] {
+			t.Errorf("component %d: expected bom-ref %q, got %q", i, expected[i], component.BOMRef)
+		}
+		if component.PURL != "pkg:gem/rack@3.0.8" {
+			t.Errorf("component %d: expected purl pkg:gem/rack@3.0.8, got %q", i, component.PURL)
+		}
+	}
+}
+
+func TestToGenericManifest(t *testing.T) {
+	lockfile, err := ParseFile("../testdata/git.lock")
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	manifest := lockfile.ToGenericManifest()
+
+	if manifest.Ecosystem != "rubygems" {
+		t.Errorf("expected ecosystem rubygems, got %q", manifest.Ecosystem)
+	}
+	expectedCount := len(lockfile.GemSpecs) + len(lockfile.GitSpecs) + len(lockfile.PathSpecs)
+	if len(manifest.Packages) != expectedCount {
+		t.Fatalf("expected %d packages, got %d", expectedCount, len(manifest.Packages))
+	}
+
+	packages := make(map[string]ManifestPackage)
+	for _, pkg := range manifest.Packages {
+		packages[pkg.Name] = pkg
+	}
+
+	noFlyList := packages["no_fly_list"]
+	expected := ManifestPackage{
+		Name:           "no_fly_list",
+		Version:        "0.6.0",
+		SourceType:     ManifestSourceGit,
+		SourceLocation: "https://github.com/seuros/no_fly_list.git",
+		Revision:       "abc123def456",
+		Direct:         true,
+	}
+	if noFlyList != expected {
+		t.Errorf("unexpected git package:\n got: %+v\nwant: %+v", noFlyList, expected)
+	}
+
+	activesupport := packages["activesupport"]
+	if activesupport.Direct {
+		t.Error("expected activesupport to be a transitive package")
+	}
+	if activesupport.SourceType != ManifestSourceRegistry || activesupport.SourceLocation != "https://rubygems.org/" {
+		t.Errorf("unexpected registry source for activesupport: %+v", activesupport)
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	if !bytes.Contains(data, []byte(`"source_type":"git"`)) || !bytes.Contains(data, []byte(`"direct":true`)) {
+		t.Errorf("unexpected manifest JSON: %s", data)
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	for _, fixture := range []string{"Gemfile.lock", "git.lock", "platforms.lock", "multi_source.lock"} {
+		t.Run(fixture, func(t *testing.T) {
+			lockfile, err := ParseFile("../testdata/" + fixture)
+			if err != nil {
+				t.Fatalf("Failed to parse lockfile: %v", err)
+			}
+
+			data, err := lockfile.ToYAML()
+			if err != nil {
+				t.Fatalf("ToYAML failed: %v", err)
+			}
+			if !strings.Contains(string(data), "GemSpecs:\n  - Name: ") {
+				t.Errorf("expected block-style gem specs, got:\n%s", data)
+			}
+
+			decoded, err := FromYAML(data)
+			if err != nil {
+				t.Fatalf("FromYAML failed: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, lockfile) {
+				t.Errorf("round trip mismatch:\n got: %+v\nwant: %+v", decoded, lockfile)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package lockfile
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+)
+
+// ApplyGemspec records a gemspec as a PATH gem, the way Bundler locks the gem
+// behind a Gemfile `gemspec` directive. The PathGemSpec with the gemspec's name
+// is updated in place, or inserted when missing, and its dependencies are
+// replaced with the gemspec's runtime dependencies, as is its metadata when the
+// gemspec has any. An empty remote defaults to ".". The gem is also added to
+// DEPENDENCIES with the "!" marker if absent.
+func (l *Lockfile) ApplyGemspec(g *gemfile.GemspecFile, remote string) {
+	if remote == "" {
+		remote = "."
+	}
+
+	deps := make([]Dependency, 0, len(g.RuntimeDependencies))
+	for i := range g.RuntimeDependencies {
+		dep := &g.RuntimeDependencies[i]
+		deps = append(deps, Dependency{
+			Name:        dep.Name,
+			Constraints: slices.Clone(dep.Constraints),
+		})
+	}
+	slices.SortFunc(deps, func(a, b Dependency) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	idx := slices.IndexFunc(l.PathSpecs, func(spec PathGemSpec) bool {
+		return spec.Name == g.Name
+	})
+	if idx == -1 {
+		l.PathSpecs = append(l.PathSpecs, PathGemSpec{Name: g.Name})
+		idx = len(l.PathSpecs) - 1
+	}
+
+	spec := &l.PathSpecs[idx]
+	spec.Version = g.Version
+	spec.Remote = remote
+	spec.Dependencies = deps
+	if g.RequiredRubyVersion != "" {
+		spec.RequiredRubyVersion = g.RequiredRubyVersion
+	}
+	if len(g.Metadata) > 0 {
+		spec.Metadata = maps.Clone(g.Metadata)
+	}
+
+	hasDependency := slices.ContainsFunc(l.Dependencies, func(dep Dependency) bool {
+		return strings.TrimSuffix(dep.Name, "!") == g.Name
+	})
+	if !hasDependency {
+		l.Dependencies = append(l.Dependencies, Dependency{Name: g.Name + "!"})
+	}
+}
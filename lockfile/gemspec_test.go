@@ -0,0 +1,91 @@
+package lockfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+)
+
+func TestApplyGemspec(t *testing.T) {
+	lockfileContent := `PATH
+  remote: .
+  specs:
+    payment_core (0.9.0)
+      money (~> 5.0)
+      stale_dep
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    money (6.16.0)
+    rspec (3.12.0)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  payment_core!
+  rspec
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	gemspec := &gemfile.GemspecFile{
+		Name:     "payment_core",
+		Version:  "1.0.0",
+		Metadata: map[string]string{"rubygems_mfa_required": "true"},
+		RuntimeDependencies: []gemfile.GemDependency{
+			{Name: "zeitwerk", Constraints: []string{"~> 2.6"}},
+			{Name: "money", Constraints: []string{"~> 6.0", ">= 6.16"}},
+		},
+		DevelopmentDependencies: []gemfile.GemDependency{
+			{Name: "rspec"},
+		},
+	}
+
+	lockfile.ApplyGemspec(gemspec, "")
+
+	if len(lockfile.PathSpecs) != 1 {
+		t.Fatalf("Expected existing path spec to be updated, got %+v", lockfile.PathSpecs)
+	}
+
+	spec := lockfile.PathSpecs[0]
+	if spec.Version != "1.0.0" || spec.Remote != "." {
+		t.Errorf("Expected payment_core 1.0.0 at '.', got %s at %q", spec.Version, spec.Remote)
+	}
+
+	if spec.Metadata["rubygems_mfa_required"] != "true" {
+		t.Errorf("Expected gemspec metadata on the path spec, got %v", spec.Metadata)
+	}
+
+	var deps []string
+	for _, dep := range spec.Dependencies {
+		deps = append(deps, dep.Name+" "+strings.Join(dep.Constraints, ", "))
+	}
+	expected := []string{"money ~> 6.0, >= 6.16", "zeitwerk ~> 2.6"}
+	if strings.Join(deps, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected dependencies %v, got %v", expected, deps)
+	}
+
+	if len(lockfile.Dependencies) != 2 {
+		t.Errorf("Expected DEPENDENCIES to be unchanged, got %+v", lockfile.Dependencies)
+	}
+
+	// A gemspec without a matching path spec is inserted
+	lockfile.ApplyGemspec(&gemfile.GemspecFile{Name: "payment_ui", Version: "0.1.0"}, "engines/payment_ui")
+
+	if len(lockfile.PathSpecs) != 2 {
+		t.Fatalf("Expected new path spec to be inserted, got %+v", lockfile.PathSpecs)
+	}
+	inserted := lockfile.PathSpecs[1]
+	if inserted.Name != "payment_ui" || inserted.Remote != "engines/payment_ui" || len(inserted.Dependencies) != 0 {
+		t.Errorf("Unexpected inserted path spec: %+v", inserted)
+	}
+	if last := lockfile.Dependencies[len(lockfile.Dependencies)-1]; last.Name != "payment_ui!" {
+		t.Errorf("Expected payment_ui! to be added to DEPENDENCIES, got %+v", lockfile.Dependencies)
+	}
+}
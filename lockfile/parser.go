@@ -10,6 +10,7 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -17,13 +18,58 @@ import (
 
 // Lockfile represents a parsed Gemfile.lock file.
 type Lockfile struct {
-	GemSpecs     []GemSpec           // Gems from the GEM section
-	GitSpecs     []GitGemSpec        // Gems from git repositories
-	PathSpecs    []PathGemSpec       // Gems from local paths
-	Platforms    []string            // Supported platforms (e.g., "ruby", "x86_64-linux")
-	Dependencies []Dependency        // Top-level dependencies from Gemfile
-	BundledWith  string              // Bundler version used
-	Groups       map[string][]string // Group name to gem names mapping
+	GemSpecs      []GemSpec           // Gems from the GEM section
+	GitSpecs      []GitGemSpec        // Gems from git repositories
+	PathSpecs     []PathGemSpec       // Gems from local paths
+	Platforms     []string            // Supported platforms (e.g., "ruby", "x86_64-linux")
+	Dependencies  []Dependency        // Top-level dependencies from Gemfile
+	BundledWith   string              // Bundler version used
+	RubyVersion   string              // Ruby version from RUBY VERSION, e.g. "3.2.0p0"
+	RubyEngine    string              // Ruby engine when it isn't MRI, e.g. "jruby"
+	EngineVersion string              // Version of RubyEngine, e.g. "9.4.0.0"
+	Groups        map[string][]string // Group name to gem names mapping
+	SectionOrder  []string            // Section names in the order they first appeared when parsed
+	Warnings      []string            // Problems found in the file that were skipped while parsing
+}
+
+// Normalize applies the defaults Bundler assumes for information missing from
+// the lockfile. Parse never applies them on its own.
+func (l *Lockfile) Normalize() {
+	l.EnsureRubyPlatform()
+}
+
+// EnsureRubyPlatform sets Platforms to ["ruby"] when the lockfile lists no
+// platforms, matching Bundler's behavior for lockfiles without a PLATFORMS section.
+func (l *Lockfile) EnsureRubyPlatform() {
+	if len(l.Platforms) == 0 {
+		l.Platforms = []string{"ruby"}
+	}
+}
+
+// StripPlatformGems removes every GEM spec locked for a specific platform,
+// keeping only pure-Ruby variants, for generating a platform-agnostic lockfile.
+// Gems published only as platform variants are removed entirely. The PLATFORMS
+// list is left as is.
+func (l *Lockfile) StripPlatformGems() {
+	l.GemSpecs = slices.DeleteFunc(l.GemSpecs, func(spec GemSpec) bool {
+		return spec.Platform != ""
+	})
+}
+
+// ConsolidatePlatformGems drops the platform variants of every gem+version that
+// also has a pure-Ruby spec, so each such gem is locked once. Variants without a
+// pure-Ruby counterpart are kept, since there is nothing to collapse them into.
+func (l *Lockfile) ConsolidatePlatformGems() {
+	pure := make(map[string]bool)
+	for i := range l.GemSpecs {
+		if l.GemSpecs[i].Platform == "" {
+			pure[l.GemSpecs[i].Name+" "+l.GemSpecs[i].Version] = true
+		}
+	}
+
+	l.GemSpecs = slices.DeleteFunc(l.GemSpecs, func(spec GemSpec) bool {
+		return spec.Platform != "" && pure[spec.Name+" "+spec.Version]
+	})
 }
 
 // FindGem searches for a gem by name in the lockfile.
@@ -45,9 +91,9 @@ type GemSpec struct {
 	Platform     string       // Platform restriction (empty for pure Ruby)
 	Dependencies []Dependency // Runtime dependencies
 	Groups       []string     // Groups this gem belongs to
-	Checksum     string       // SHA256 for integrity verification
+	Checksum     string       // Digest from the CHECKSUMS section, e.g. "sha256=..."
 	// Security and metadata
-	SourceURL               string            `json:"source_url,omitempty"`
+	SourceURL               string            `json:"source_url,omitempty"` // GEM remote, empty for the default https://rubygems.org/
 	PostInstallMessage      string            `json:"post_install_message,omitempty"`
 	Extensions              []string          `json:"extensions,omitempty"`
 	RequiredRubyVersion     string            `json:"required_ruby_version,omitempty"`
@@ -67,6 +113,7 @@ type GitGemSpec struct {
 	Tag          string
 	Dependencies []Dependency
 	Groups       []string
+	Checksum     string // Digest from the CHECKSUMS section, usually empty for git gems
 	// Additional metadata for Git gems
 	PostInstallMessage  string            `json:"post_install_message,omitempty"`
 	Extensions          []string          `json:"extensions,omitempty"`
@@ -84,6 +131,7 @@ type PathGemSpec struct {
 	Remote       string // local path
 	Dependencies []Dependency
 	Groups       []string
+	Checksum     string // Digest from the CHECKSUMS section, usually empty for path gems
 	// Additional metadata for PATH gems
 	PostInstallMessage  string            `json:"post_install_message,omitempty"`
 	Extensions          []string          `json:"extensions,omitempty"`
@@ -102,8 +150,9 @@ type Dependency struct {
 	Type        string `json:"type,omitempty"`        // "runtime", "development", "test"
 	Scope       string `json:"scope,omitempty"`       // "direct", "transitive"
 	Optional    bool   `json:"optional,omitempty"`    // Whether dependency is optional
-	Platform    string `json:"platform,omitempty"`    // Platform restriction
+	Platform    string `json:"platform,omitempty"`    // Platform of the linked spec; Bundler writes none on dependency lines
 	Environment string `json:"environment,omitempty"` // Environment restriction
+	Source      string `json:"source,omitempty"`      // Remote or path the dependency was resolved from
 }
 
 const (
@@ -112,12 +161,27 @@ const (
 	sectionPATH         = "PATH"
 	sectionPLATFORMS    = "PLATFORMS"
 	sectionDEPENDENCIES = "DEPENDENCIES"
+	sectionCHECKSUMS    = "CHECKSUMS"
+	sectionRUBY_VERSION = "RUBY_VERSION"
 	sectionBUNDLED_WITH = "BUNDLED_WITH"
 )
 
+// Gem names may contain letters, digits, dots, dashes and underscores (RubyGems naming rules).
 var (
-	gemSpecRegex = regexp.MustCompile(`^ {4}([a-zA-Z0-9\-_]+) \(([^)]+)\)$`)
-	depRegex     = regexp.MustCompile(`^ {6}([a-zA-Z0-9\-_]+)(?: \(([^)]+)\))?$`)
+	gemSpecRegex = regexp.MustCompile(`^ {4}([a-zA-Z0-9.\-_]+) \(([^)]+)\)$`)
+	// depRegex matches a dependency line under a spec. Bundler writes these as a name and
+	// requirement only; platforms appear on spec lines such as "nokogiri (1.16.0-x86_64-linux)",
+	// so Dependency.Platform is only filled in by LinkDependencies.
+	depRegex = regexp.MustCompile(`^ {6}([a-zA-Z0-9.\-_]+)\s*(?:\(([^)]+)\))?\s*$`)
+	// versionlessSpecRegex matches a spec line missing its version, found only in corrupt lockfiles
+	versionlessSpecRegex = regexp.MustCompile(`^ {4}([a-zA-Z0-9.\-_]+)$`)
+	// topLevelDepRegex matches DEPENDENCIES entries, tolerating irregular spacing before the parens
+	topLevelDepRegex = regexp.MustCompile(`^([a-zA-Z0-9.\-_]+)\s*\(([^)]+)\)$`)
+	// checksumRegex matches a CHECKSUMS entry: the spec as it appears in its section,
+	// followed by its digests unless the source provides none (git and path gems)
+	checksumRegex = regexp.MustCompile(`^ {2}([a-zA-Z0-9.\-_]+) \(([^)]+)\)(?: (\S+))?$`)
+	// constraintOpRegex splits a single constraint into its operator and version
+	constraintOpRegex = regexp.MustCompile(`^(~>|>=|<=|!=|=|>|<)\s*(\S+)$`)
 )
 
 // ParseFile parses a Gemfile.lock from a file path.
@@ -132,6 +196,8 @@ func ParseFile(path string) (*Lockfile, error) {
 }
 
 // Parse reads and parses a Gemfile.lock from an io.Reader.
+// The result reflects exactly what is in the file; Bundler's implicit defaults
+// (such as the ruby platform) are only applied by Normalize.
 func Parse(reader io.Reader) (*Lockfile, error) {
 	lockfile := &Lockfile{
 		Groups: make(map[string][]string),
@@ -142,28 +208,42 @@ func Parse(reader io.Reader) (*Lockfile, error) {
 	var currentGem *GemSpec
 	var currentGitGem *GitGemSpec
 	var currentPathGem *PathGemSpec
+	var currentGemRemote string
+	checksums := make(map[string]string) // Spec full name to digest
 
 	for scanner.Scan() {
-		line := scanner.Text()
+		// Hand-edited lockfiles may carry trailing whitespace or CRLF line endings.
+		// Leading indentation is significant and kept as is.
+		line := strings.TrimRight(scanner.Text(), " \t\r")
 
 		// Check for section headers
 		if newSection := checkSectionHeaders(line); newSection != "" {
 			savePendingGems(lockfile, &currentGem, &currentGitGem, &currentPathGem)
 			currentSection = newSection
+			if !slices.Contains(lockfile.SectionOrder, newSection) {
+				lockfile.SectionOrder = append(lockfile.SectionOrder, newSection)
+			}
 			continue
 		}
 
 		// Handle special lines
-		if handleSpecialLines(line, currentSection, &currentGitGem, &currentPathGem) {
+		if handleSpecialLines(line, currentSection, &currentGemRemote, &currentGitGem, &currentPathGem) {
+			continue
+		}
+
+		// Checksums are matched to specs once every section has been read
+		if currentSection == sectionCHECKSUMS {
+			processChecksumsSection(line, checksums)
 			continue
 		}
 
 		// Process content based on current section
-		processSection(line, currentSection, lockfile, &currentGem, &currentGitGem, &currentPathGem)
+		processSection(line, currentSection, currentGemRemote, lockfile, &currentGem, &currentGitGem, &currentPathGem)
 	}
 
 	// Finalize parsing
 	finalizeGems(lockfile, currentGem, currentGitGem, currentPathGem)
+	applyChecksums(lockfile, checksums)
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("❌ Error reading lockfile\n   💡 File may be corrupted - try regenerating with 'bundle lock'")
@@ -185,6 +265,12 @@ func checkSectionHeaders(line string) string {
 		return sectionPLATFORMS
 	case sectionDEPENDENCIES:
 		return sectionDEPENDENCIES
+	case sectionCHECKSUMS:
+		return sectionCHECKSUMS
+	}
+
+	if line == "RUBY VERSION" {
+		return sectionRUBY_VERSION
 	}
 
 	if strings.HasPrefix(line, "BUNDLED WITH") {
@@ -195,9 +281,10 @@ func checkSectionHeaders(line string) string {
 }
 
 // handleSpecialLines handles special lines like remote, revision, branch, tag, and specs
-func handleSpecialLines(line, currentSection string, currentGitGem **GitGemSpec, currentPathGem **PathGemSpec) bool {
+func handleSpecialLines(line, currentSection string, currentGemRemote *string,
+	currentGitGem **GitGemSpec, currentPathGem **PathGemSpec) bool {
 	if strings.HasPrefix(line, "  remote:") {
-		handleRemoteLine(line, currentSection, currentGitGem, currentPathGem)
+		handleRemoteLine(line, currentSection, currentGemRemote, currentGitGem, currentPathGem)
 		return true
 	}
 
@@ -223,11 +310,15 @@ func handleSpecialLines(line, currentSection string, currentGitGem **GitGemSpec,
 	return false
 }
 
-// handleRemoteLine processes remote lines for GIT and PATH sections
-func handleRemoteLine(line, currentSection string, currentGitGem **GitGemSpec, currentPathGem **PathGemSpec) {
+// handleRemoteLine processes remote lines for GEM, GIT and PATH sections
+func handleRemoteLine(line, currentSection string, currentGemRemote *string,
+	currentGitGem **GitGemSpec, currentPathGem **PathGemSpec) {
 	remote := strings.TrimSpace(strings.TrimPrefix(line, "  remote:"))
 
 	switch currentSection {
+	case sectionGEM:
+		// Specs that follow belong to this remote until the next GEM block
+		*currentGemRemote = remote
 	case sectionGIT:
 		if *currentGitGem == nil {
 			*currentGitGem = &GitGemSpec{}
@@ -269,19 +360,21 @@ func handleTagLine(line string, currentGitGem **GitGemSpec) {
 }
 
 // processSection processes content lines based on the current section
-func processSection(line, currentSection string, lockfile *Lockfile,
+func processSection(line, currentSection, currentGemRemote string, lockfile *Lockfile,
 	currentGem **GemSpec, currentGitGem **GitGemSpec, currentPathGem **PathGemSpec) {
 	switch currentSection {
 	case sectionGEM:
-		processGemSection(line, lockfile, currentGem, gemSpecRegex, depRegex)
+		processGemSection(line, currentGemRemote, lockfile, currentGem, gemSpecRegex, depRegex)
 	case sectionGIT:
-		processGitPathSection(line, currentGitGem, currentPathGem, true, gemSpecRegex, depRegex)
+		processGitPathSection(line, lockfile, currentGitGem, currentPathGem, true, gemSpecRegex, depRegex)
 	case sectionPATH:
-		processGitPathSection(line, currentGitGem, currentPathGem, false, gemSpecRegex, depRegex)
+		processGitPathSection(line, lockfile, currentGitGem, currentPathGem, false, gemSpecRegex, depRegex)
 	case sectionPLATFORMS:
 		processPlatformsSection(line, lockfile)
 	case sectionDEPENDENCIES:
 		processDependenciesSection(line, lockfile)
+	case sectionRUBY_VERSION:
+		processRubyVersionSection(line, lockfile)
 	case "BUNDLED_WITH":
 		processBundledWithSection(line, lockfile)
 	}
@@ -302,7 +395,7 @@ func processDependenciesSection(line string, lockfile *Lockfile) {
 	}
 
 	depLine := strings.TrimSpace(line)
-	if matches := regexp.MustCompile(`^([a-zA-Z0-9\-_]+) \(([^)]+)\)$`).FindStringSubmatch(depLine); matches != nil {
+	if matches := topLevelDepRegex.FindStringSubmatch(depLine); matches != nil {
 		dep := Dependency{
 			Name:        matches[1],
 			Constraints: parseConstraints(matches[2]),
@@ -316,22 +409,64 @@ func processDependenciesSection(line string, lockfile *Lockfile) {
 	}
 }
 
-// processBundledWithSection processes lines in the BUNDLED_WITH section
+// processChecksumsSection records a line of the CHECKSUMS section, e.g.
+// "  nokogiri (1.16.0-x86_64-linux) sha256=...", keyed by the spec's full name
+func processChecksumsSection(line string, checksums map[string]string) {
+	if matches := checksumRegex.FindStringSubmatch(line); matches != nil && matches[3] != "" {
+		checksums[matches[1]+"-"+matches[2]] = matches[3]
+	}
+}
+
+// applyChecksums fills in the Checksum of every spec listed in the CHECKSUMS section
+func applyChecksums(lockfile *Lockfile, checksums map[string]string) {
+	if len(checksums) == 0 {
+		return
+	}
+	for i := range lockfile.GemSpecs {
+		lockfile.GemSpecs[i].Checksum = checksums[lockfile.GemSpecs[i].FullName()]
+	}
+	for i := range lockfile.GitSpecs {
+		lockfile.GitSpecs[i].Checksum = checksums[lockfile.GitSpecs[i].FullName()]
+	}
+	for i := range lockfile.PathSpecs {
+		lockfile.PathSpecs[i].Checksum = checksums[lockfile.PathSpecs[i].FullName()]
+	}
+}
+
+// processRubyVersionSection processes the line of the RUBY VERSION section,
+// e.g. "   ruby 3.2.0p0" or "   ruby 3.1.4p0 (jruby 9.4.5.0)" for other engines
+func processRubyVersionSection(line string, lockfile *Lockfile) {
+	version, ok := strings.CutPrefix(strings.TrimSpace(line), "ruby ")
+	if !ok {
+		return
+	}
+
+	version, engine, hasEngine := strings.Cut(version, "(")
+	lockfile.RubyVersion = strings.TrimSpace(version)
+	if hasEngine {
+		engine = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(engine), ")"))
+		lockfile.RubyEngine, lockfile.EngineVersion, _ = strings.Cut(engine, " ")
+	}
+}
+
+// processBundledWithSection processes lines in the BUNDLED_WITH section.
+// Bundler indents the version with three spaces, but any indentation is accepted.
 func processBundledWithSection(line string, lockfile *Lockfile) {
-	if strings.HasPrefix(line, "   ") {
-		lockfile.BundledWith = strings.TrimSpace(line)
+	if version := strings.TrimLeft(line, " \t"); version != "" && version != line {
+		lockfile.BundledWith = version
 	}
 }
 
-// finalizeGems adds any remaining gems to the lockfile
+// finalizeGems adds any remaining gems to the lockfile. GIT and PATH gems
+// without a name only hold the source of a skipped spec and are dropped.
 func finalizeGems(lockfile *Lockfile, currentGem *GemSpec, currentGitGem *GitGemSpec, currentPathGem *PathGemSpec) {
 	if currentGem != nil {
 		lockfile.GemSpecs = append(lockfile.GemSpecs, *currentGem)
 	}
-	if currentGitGem != nil {
+	if currentGitGem != nil && currentGitGem.Name != "" {
 		lockfile.GitSpecs = append(lockfile.GitSpecs, *currentGitGem)
 	}
-	if currentPathGem != nil {
+	if currentPathGem != nil && currentPathGem.Name != "" {
 		lockfile.PathSpecs = append(lockfile.PathSpecs, *currentPathGem)
 	}
 }
@@ -341,7 +476,11 @@ func parseConstraints(constraintStr string) []string {
 	result := make([]string, 0, len(constraints))
 
 	for _, constraint := range constraints {
-		constraint = strings.TrimSpace(constraint)
+		// Collapse irregular spacing so ">=  7.0" and ">=7.0" both become ">= 7.0"
+		constraint = strings.Join(strings.Fields(constraint), " ")
+		if matches := constraintOpRegex.FindStringSubmatch(constraint); matches != nil {
+			constraint = matches[1] + " " + matches[2]
+		}
 		if constraint != "" {
 			result = append(result, constraint)
 		}
@@ -416,17 +555,29 @@ func savePendingGems(lockfile *Lockfile, currentGem **GemSpec, currentGitGem **G
 		*currentGem = nil
 	}
 	if *currentGitGem != nil {
-		lockfile.GitSpecs = append(lockfile.GitSpecs, **currentGitGem)
+		if (*currentGitGem).Name != "" {
+			lockfile.GitSpecs = append(lockfile.GitSpecs, **currentGitGem)
+		}
 		*currentGitGem = nil
 	}
 	if *currentPathGem != nil {
-		lockfile.PathSpecs = append(lockfile.PathSpecs, **currentPathGem)
+		if (*currentPathGem).Name != "" {
+			lockfile.PathSpecs = append(lockfile.PathSpecs, **currentPathGem)
+		}
 		*currentPathGem = nil
 	}
 }
 
+// remote returns the GEM remote the spec was locked from
+func (s *GemSpec) remote() string {
+	if s.SourceURL == "" {
+		return defaultGemRemote
+	}
+	return s.SourceURL
+}
+
 // processGemSection processes lines in the GEM section
-func processGemSection(line string, lockfile *Lockfile, currentGem **GemSpec, gemSpecRegex, depRegex *regexp.Regexp) {
+func processGemSection(line, remote string, lockfile *Lockfile, currentGem **GemSpec, gemSpecRegex, depRegex *regexp.Regexp) {
 	if matches := gemSpecRegex.FindStringSubmatch(line); matches != nil {
 		// Save current gem before starting new one
 		if *currentGem != nil {
@@ -451,12 +602,16 @@ func processGemSection(line string, lockfile *Lockfile, currentGem **GemSpec, ge
 			platform = strings.Join(parts[1:], "-")
 		}
 
-		// Start new gem
+		// Start new gem. Gems from the default remote keep an empty SourceURL,
+		// so LockfileWriter.DefaultGemRemote decides where they are written.
 		*currentGem = &GemSpec{
 			Name:     name,
 			Version:  version,
 			Platform: platform,
 		}
+		if remote != defaultGemRemote {
+			(*currentGem).SourceURL = remote
+		}
 	} else if matches := depRegex.FindStringSubmatch(line); matches != nil && *currentGem != nil {
 		// Add dependency to current gem
 		dep := Dependency{
@@ -466,12 +621,21 @@ func processGemSection(line string, lockfile *Lockfile, currentGem **GemSpec, ge
 			dep.Constraints = parseConstraints(matches[2])
 		}
 		(*currentGem).Dependencies = append((*currentGem).Dependencies, dep)
+	} else if matches := versionlessSpecRegex.FindStringSubmatch(line); matches != nil {
+		// Close the previous gem so the dependencies listed under this
+		// spec aren't attached to it
+		if *currentGem != nil {
+			lockfile.GemSpecs = append(lockfile.GemSpecs, **currentGem)
+			*currentGem = nil
+		}
+		lockfile.Warnings = append(lockfile.Warnings,
+			fmt.Sprintf("gem %q in GEM section has no version, spec skipped", matches[1]))
 	}
 }
 
 // processGitPathSection processes lines in GIT or PATH sections
 func processGitPathSection(
-	line string, currentGitGem **GitGemSpec, currentPathGem **PathGemSpec,
+	line string, lockfile *Lockfile, currentGitGem **GitGemSpec, currentPathGem **PathGemSpec,
 	isGitSection bool, gemSpecRegex, depRegex *regexp.Regexp) {
 	result := parseGemSpecSection(line, gemSpecRegex, depRegex)
 	if isGitSection {
@@ -479,6 +643,10 @@ func processGitPathSection(
 			if *currentGitGem == nil {
 				*currentGitGem = &GitGemSpec{}
 			}
+			if (*currentGitGem).Name == "" {
+				// Drop dependencies collected under a skipped version-less spec
+				(*currentGitGem).Dependencies = nil
+			}
 			(*currentGitGem).Name = result.GemName
 			(*currentGitGem).Version = result.GemVersion
 		} else if result.IsDep && *currentGitGem != nil {
@@ -487,12 +655,24 @@ func processGitPathSection(
 				dep.Constraints = parseConstraints(result.DepConstraints)
 			}
 			(*currentGitGem).Dependencies = append((*currentGitGem).Dependencies, dep)
+		} else if matches := versionlessSpecRegex.FindStringSubmatch(line); matches != nil {
+			// Close the previous gem and keep the source on a nameless placeholder,
+			// so the dependencies listed under this spec aren't attached to it
+			if gem := *currentGitGem; gem != nil && gem.Name != "" {
+				lockfile.GitSpecs = append(lockfile.GitSpecs, *gem)
+				*currentGitGem = &GitGemSpec{Remote: gem.Remote, Revision: gem.Revision, Branch: gem.Branch, Tag: gem.Tag}
+			}
+			lockfile.Warnings = append(lockfile.Warnings,
+				fmt.Sprintf("gem %q in GIT section has no version, spec skipped", matches[1]))
 		}
 	} else {
 		if result.IsGemSpec {
 			if *currentPathGem == nil {
 				*currentPathGem = &PathGemSpec{}
 			}
+			if (*currentPathGem).Name == "" {
+				(*currentPathGem).Dependencies = nil
+			}
 			(*currentPathGem).Name = result.GemName
 			(*currentPathGem).Version = result.GemVersion
 		} else if result.IsDep && *currentPathGem != nil {
@@ -501,6 +681,13 @@ func processGitPathSection(
 				dep.Constraints = parseConstraints(result.DepConstraints)
 			}
 			(*currentPathGem).Dependencies = append((*currentPathGem).Dependencies, dep)
+		} else if matches := versionlessSpecRegex.FindStringSubmatch(line); matches != nil {
+			if gem := *currentPathGem; gem != nil && gem.Name != "" {
+				lockfile.PathSpecs = append(lockfile.PathSpecs, *gem)
+				*currentPathGem = &PathGemSpec{Remote: gem.Remote}
+			}
+			lockfile.Warnings = append(lockfile.Warnings,
+				fmt.Sprintf("gem %q in PATH section has no version, spec skipped", matches[1]))
 		}
 	}
 }
@@ -3,6 +3,7 @@ package lockfile
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -380,3 +381,458 @@ func validatePathGemMethods(t *testing.T, lockfile *Lockfile) {
 		t.Errorf("PATH gem SemVer parsing failed: %v", err)
 	}
 }
+
+func TestParseIrregularDependencySpacing(t *testing.T) {
+	lockfileContent := `GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.0.4)
+      activesupport  (= 7.0.4)
+      rack(~> 2.0, >= 2.2.0)
+      rails-html-sanitizer (~> 1.0)  
+    activesupport (7.0.4)
+
+DEPENDENCIES
+  actionpack  (~> 7.0)
+  rack(>= 2.2)
+
+BUNDLED WITH
+   2.3.26`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	actionpack := findGem(lockfile.GemSpecs, "actionpack")
+	if actionpack == nil {
+		t.Fatal("actionpack gem not found")
+	}
+
+	expected := map[string]string{
+		"activesupport":        "= 7.0.4",
+		"rack":                 "~> 2.0,>= 2.2.0",
+		"rails-html-sanitizer": "~> 1.0",
+	}
+	if len(actionpack.Dependencies) != len(expected) {
+		t.Fatalf("Expected %d dependencies, got %+v", len(expected), actionpack.Dependencies)
+	}
+	for _, dep := range actionpack.Dependencies {
+		if got := strings.Join(dep.Constraints, ","); got != expected[dep.Name] {
+			t.Errorf("Dependency %s: expected constraints %q, got %q", dep.Name, expected[dep.Name], got)
+		}
+	}
+
+	if len(lockfile.Dependencies) != 2 {
+		t.Fatalf("Expected 2 top-level dependencies, got %+v", lockfile.Dependencies)
+	}
+	if lockfile.Dependencies[0].Name != "actionpack" || lockfile.Dependencies[0].Constraints[0] != "~> 7.0" {
+		t.Errorf("Unexpected first dependency: %+v", lockfile.Dependencies[0])
+	}
+	if lockfile.Dependencies[1].Name != "rack" || lockfile.Dependencies[1].Constraints[0] != ">= 2.2" {
+		t.Errorf("Unexpected second dependency: %+v", lockfile.Dependencies[1])
+	}
+}
+
+func TestParseDottedGemNames(t *testing.T) {
+	lockfileContent := `GEM
+  remote: https://rubygems.org/
+  specs:
+    jquery.fileupload-rails (1.0.0)
+      actionpack (>= 3.1)
+      rails.assets-helper (~> 0.2)
+    rails.assets-helper (0.2.1)
+
+DEPENDENCIES
+  jquery.fileupload-rails (~> 1.0)
+
+BUNDLED WITH
+   2.4.13`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	if len(lockfile.GemSpecs) != 2 {
+		t.Fatalf("Expected 2 gem specs, got %+v", lockfile.GemSpecs)
+	}
+
+	gem := lockfile.FindGem("jquery.fileupload-rails")
+	if gem == nil {
+		t.Fatal("jquery.fileupload-rails gem not found")
+	}
+	if gem.Version != "1.0.0" {
+		t.Errorf("Expected version 1.0.0, got %s", gem.Version)
+	}
+	if len(gem.Dependencies) != 2 || gem.Dependencies[1].Name != "rails.assets-helper" {
+		t.Errorf("Expected dotted dependency rails.assets-helper, got %+v", gem.Dependencies)
+	}
+
+	if len(lockfile.Dependencies) != 1 || lockfile.Dependencies[0].Name != "jquery.fileupload-rails" {
+		t.Errorf("Expected dotted top-level dependency, got %+v", lockfile.Dependencies)
+	}
+	if len(lockfile.Dependencies[0].Constraints) != 1 {
+		t.Errorf("Expected constraint for dotted dependency, got %+v", lockfile.Dependencies[0])
+	}
+}
+
+func TestParseWhitespaceAroundSections(t *testing.T) {
+	lockfileContent := "GIT \n" +
+		"  remote: https://github.com/seuros/state_machines.git \n" +
+		"  revision: def456abc789\n" +
+		"  specs:\n" +
+		"    state_machines (0.6.0)\n" +
+		"\n" +
+		"\n" +
+		"GEM  \n" +
+		"  remote: https://rubygems.org/\n" +
+		"  specs:  \n" +
+		"    rack (2.2.8)\t\n" +
+		"\n" +
+		"    rack-test (2.1.0)\n" +
+		"      rack (>= 1.3)\n" +
+		"\n" +
+		"\n" +
+		"\n" +
+		"PLATFORMS\r\n" +
+		"  ruby \r\n" +
+		"\r\n" +
+		"DEPENDENCIES \n" +
+		"  rack \n" +
+		"  state_machines!\n" +
+		"\n" +
+		"BUNDLED WITH  \n" +
+		"   2.4.13 \n" +
+		"\n"
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	if len(lockfile.GitSpecs) != 1 || lockfile.GitSpecs[0].Remote != "https://github.com/seuros/state_machines.git" {
+		t.Errorf("Expected one git spec with trimmed remote, got %+v", lockfile.GitSpecs)
+	}
+
+	if len(lockfile.GemSpecs) != 2 {
+		t.Fatalf("Expected 2 gem specs, got %+v", lockfile.GemSpecs)
+	}
+	rackTest := findGem(lockfile.GemSpecs, "rack-test")
+	if rackTest == nil || len(rackTest.Dependencies) != 1 {
+		t.Errorf("Expected rack-test with one dependency, got %+v", rackTest)
+	}
+
+	if len(lockfile.Platforms) != 1 || lockfile.Platforms[0] != "ruby" {
+		t.Errorf("Expected platforms [ruby], got %v", lockfile.Platforms)
+	}
+
+	if len(lockfile.Dependencies) != 2 || lockfile.Dependencies[0].Name != "rack" {
+		t.Errorf("Expected 2 dependencies starting with rack, got %+v", lockfile.Dependencies)
+	}
+
+	if lockfile.BundledWith != "2.4.13" {
+		t.Errorf("Expected bundler version 2.4.13, got %q", lockfile.BundledWith)
+	}
+}
+
+func TestNormalizePlatforms(t *testing.T) {
+	lockfileContent := `GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (2.2.8)
+
+DEPENDENCIES
+  rack
+
+BUNDLED WITH
+   2.4.13`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	if len(lockfile.Platforms) != 0 {
+		t.Errorf("Parse should not invent platforms, got %v", lockfile.Platforms)
+	}
+
+	lockfile.Normalize()
+	if len(lockfile.Platforms) != 1 || lockfile.Platforms[0] != "ruby" {
+		t.Errorf("Expected platforms [ruby] after Normalize, got %v", lockfile.Platforms)
+	}
+
+	// Existing platforms are left alone
+	lockfile.Platforms = []string{"x86_64-linux"}
+	lockfile.EnsureRubyPlatform()
+	if len(lockfile.Platforms) != 1 || lockfile.Platforms[0] != "x86_64-linux" {
+		t.Errorf("Expected platforms to be unchanged, got %v", lockfile.Platforms)
+	}
+}
+
+func TestParseMultipleDependencyConstraints(t *testing.T) {
+	lockfileContent := `GEM
+  remote: https://rubygems.org/
+  specs:
+    rails (7.1.3)
+      activesupport (>=7.1.3,  < 7.2)
+
+DEPENDENCIES
+  pg ( >= 1.1 ,  < 2.0 )
+  rails (>= 7.0, < 8.0)
+  rake (>=  13.0)
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	expected := map[string][]string{
+		"pg":    {">= 1.1", "< 2.0"},
+		"rails": {">= 7.0", "< 8.0"},
+		"rake":  {">= 13.0"},
+	}
+	if len(lockfile.Dependencies) != len(expected) {
+		t.Fatalf("Expected %d dependencies, got %+v", len(expected), lockfile.Dependencies)
+	}
+	for _, dep := range lockfile.Dependencies {
+		if got := strings.Join(dep.Constraints, "|"); got != strings.Join(expected[dep.Name], "|") {
+			t.Errorf("Dependency %s: expected constraints %q, got %q", dep.Name, expected[dep.Name], dep.Constraints)
+		}
+	}
+
+	rails := findGem(lockfile.GemSpecs, "rails")
+	if rails == nil || len(rails.Dependencies) != 1 {
+		t.Fatalf("Expected rails with one dependency, got %+v", rails)
+	}
+	if got := strings.Join(rails.Dependencies[0].Constraints, "|"); got != ">= 7.1.3|< 7.2" {
+		t.Errorf("Expected activesupport constraints [>= 7.1.3 < 7.2], got %q", rails.Dependencies[0].Constraints)
+	}
+}
+
+func TestParseVersionlessSpec(t *testing.T) {
+	lockfileContent := `GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.1.3)
+      rack (>= 2.2.4)
+    brokengem
+      nokogiri (>= 1.0)
+    rack (3.0.9)
+
+DEPENDENCIES
+  actionpack
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	if len(lockfile.GemSpecs) != 2 {
+		t.Fatalf("Expected 2 gem specs, got %+v", lockfile.GemSpecs)
+	}
+	if findGem(lockfile.GemSpecs, "brokengem") != nil {
+		t.Error("Expected version-less spec to be skipped")
+	}
+
+	actionpack := findGem(lockfile.GemSpecs, "actionpack")
+	if actionpack == nil || len(actionpack.Dependencies) != 1 || actionpack.Dependencies[0].Name != "rack" {
+		t.Errorf("Expected actionpack to keep only its own dependency, got %+v", actionpack)
+	}
+
+	if len(lockfile.Warnings) != 1 || !strings.Contains(lockfile.Warnings[0], `"brokengem"`) {
+		t.Errorf("Expected one warning about brokengem, got %v", lockfile.Warnings)
+	}
+}
+
+func TestParseVersionlessGitAndPathSpecs(t *testing.T) {
+	lockfileContent := `GIT
+  remote: https://github.com/example/broken.git
+  revision: 0123456789abcdef0123456789abcdef01234567
+  specs:
+    brokengit
+      rack (>= 2.0)
+
+PATH
+  remote: engines/core
+  specs:
+    core (0.1.0)
+      rack (>= 2.0)
+    brokenpath
+      nokogiri (>= 1.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (3.0.9)
+
+DEPENDENCIES
+  core!
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	if len(lockfile.GitSpecs) != 0 {
+		t.Errorf("Expected version-less GIT spec to be skipped, got %+v", lockfile.GitSpecs)
+	}
+
+	if len(lockfile.PathSpecs) != 1 || lockfile.PathSpecs[0].Name != "core" {
+		t.Fatalf("Expected only the core PATH spec, got %+v", lockfile.PathSpecs)
+	}
+	if deps := lockfile.PathSpecs[0].Dependencies; len(deps) != 1 || deps[0].Name != "rack" {
+		t.Errorf("Expected core to keep only its own dependency, got %+v", deps)
+	}
+
+	if len(lockfile.Warnings) != 2 ||
+		!strings.Contains(lockfile.Warnings[0], `"brokengit" in GIT section`) ||
+		!strings.Contains(lockfile.Warnings[1], `"brokenpath" in PATH section`) {
+		t.Errorf("Expected warnings about brokengit and brokenpath, got %v", lockfile.Warnings)
+	}
+}
+
+func TestParseBundledWithIndentation(t *testing.T) {
+	tests := map[string]string{
+		"three spaces": "   2.5.6",
+		"two spaces":   "  2.5.6",
+		"tab":          "\t2.5.6",
+		"mixed":        " \t 2.5.6",
+	}
+
+	for name, versionLine := range tests {
+		t.Run(name, func(t *testing.T) {
+			content := "GEM\n" +
+				"  remote: https://rubygems.org/\n" +
+				"  specs:\n" +
+				"    rack (3.0.9)\n" +
+				"\n" +
+				"PLATFORMS\n" +
+				"  ruby\n" +
+				"\n" +
+				"DEPENDENCIES\n" +
+				"  rack\n" +
+				"\n" +
+				"BUNDLED WITH\n" +
+				versionLine + "\n"
+
+			lockfile, err := Parse(strings.NewReader(content))
+			if err != nil {
+				t.Fatalf("Failed to parse lockfile: %v", err)
+			}
+			if lockfile.BundledWith != "2.5.6" {
+				t.Errorf("Expected bundler version 2.5.6, got %q", lockfile.BundledWith)
+			}
+		})
+	}
+}
+
+func TestParseRubyVersion(t *testing.T) {
+	tests := []struct {
+		line                               string
+		rubyVersion, engine, engineVersion string
+	}{
+		{"   ruby 3.2.0p0", "3.2.0p0", "", ""},
+		{"   ruby 3.1.4p0 (jruby 9.4.5.0)", "3.1.4p0", "jruby", "9.4.5.0"},
+		{"   ruby 3.3.0 (truffleruby 24.0.0)", "3.3.0", "truffleruby", "24.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.line), func(t *testing.T) {
+			content := "DEPENDENCIES\n  rack\n\nRUBY VERSION\n" + tt.line + "\n\nBUNDLED WITH\n   2.5.6\n"
+
+			lockfile, err := Parse(strings.NewReader(content))
+			if err != nil {
+				t.Fatalf("Failed to parse lockfile: %v", err)
+			}
+			if lockfile.RubyVersion != tt.rubyVersion || lockfile.RubyEngine != tt.engine || lockfile.EngineVersion != tt.engineVersion {
+				t.Errorf("Expected %q %q %q, got %q %q %q", tt.rubyVersion, tt.engine, tt.engineVersion,
+					lockfile.RubyVersion, lockfile.RubyEngine, lockfile.EngineVersion)
+			}
+			if lockfile.BundledWith != "2.5.6" {
+				t.Errorf("Expected bundler version 2.5.6, got %q", lockfile.BundledWith)
+			}
+		})
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	lockfile, err := ParseFile("../testdata/checksums.lock")
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	expected := map[string]string{
+		"nokogiri-1.16.0-arm64-darwin": "sha256=4ae3a1ba9c9ba30c7d1d2e77ddc5c4b4e8e31d1fa0b2de4b0f1fe7c1d2e4a6b8",
+		"nokogiri-1.16.0-x86_64-linux": "sha256=9c4a82a7e2f96f8ad0c5b1c86d4b5e9e1d8a1c6f3b2e7d9a0c4f8e2b6d1a3c5e",
+		"racc-1.7.3":                   "",
+		"rack-3.0.9":                   "sha256=e5e4a4b07d3a6b3c8f9a1d2e0b7c6f5a4d3e2b1c0a9f8e7d6c5b4a3f2e1d0c9b",
+	}
+	for i := range lockfile.GemSpecs {
+		spec := &lockfile.GemSpecs[i]
+		if want := expected[spec.FullName()]; spec.Checksum != want {
+			t.Errorf("%s: expected checksum %q, got %q", spec.FullName(), want, spec.Checksum)
+		}
+	}
+	if len(lockfile.PathSpecs) != 1 || lockfile.PathSpecs[0].Checksum != "" {
+		t.Errorf("Expected one path spec without a checksum, got %+v", lockfile.PathSpecs)
+	}
+
+	// The section doesn't swallow the ones around it
+	if len(lockfile.Dependencies) != 2 || lockfile.BundledWith != "2.5.6" {
+		t.Errorf("Expected 2 dependencies and bundler 2.5.6, got %d and %q", len(lockfile.Dependencies), lockfile.BundledWith)
+	}
+}
+
+func TestStripAndConsolidatePlatformGems(t *testing.T) {
+	content, err := os.ReadFile("../testdata/platforms.lock")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	// A gem shipped only as a native variant has no pure-Ruby entry to fall back to
+	withNativeOnly := strings.Replace(string(content), "    racc (1.7.1)\n",
+		"    racc (1.7.1)\n    sqlite3 (1.7.0-x86_64-linux)\n", 1)
+
+	specNames := func(lockfile *Lockfile) []string {
+		var names []string
+		for i := range lockfile.GemSpecs {
+			names = append(names, lockfile.GemSpecs[i].FullName())
+		}
+		return names
+	}
+
+	t.Run("strip", func(t *testing.T) {
+		lockfile, err := Parse(strings.NewReader(withNativeOnly))
+		if err != nil {
+			t.Fatalf("Failed to parse lockfile: %v", err)
+		}
+		lockfile.StripPlatformGems()
+
+		expected := []string{"nokogiri-1.15.4", "mini_portile2-2.8.2", "racc-1.7.1"}
+		if got := specNames(lockfile); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+		if len(lockfile.Platforms) != 3 {
+			t.Errorf("expected PLATFORMS to be left alone, got %v", lockfile.Platforms)
+		}
+	})
+
+	t.Run("consolidate", func(t *testing.T) {
+		lockfile, err := Parse(strings.NewReader(withNativeOnly))
+		if err != nil {
+			t.Fatalf("Failed to parse lockfile: %v", err)
+		}
+		lockfile.ConsolidatePlatformGems()
+
+		expected := []string{"nokogiri-1.15.4", "mini_portile2-2.8.2", "racc-1.7.1", "sqlite3-1.7.0-x86_64-linux"}
+		if got := specNames(lockfile); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+		if nokogiri := findGem(lockfile.GemSpecs, "nokogiri"); nokogiri == nil || len(nokogiri.Dependencies) != 2 {
+			t.Errorf("expected the pure-Ruby nokogiri with its 2 dependencies, got %+v", nokogiri)
+		}
+	})
+}
@@ -0,0 +1,642 @@
+package lockfile
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/contriboss/gemfile-go/internal/gemversion"
+)
+
+// Closure returns the named gems together with their full transitive
+// dependency closure from the GEM section, deduplicated.
+// Ruby equivalent: Bundler.locked_gems.specs.for(dependencies)
+//
+// Dependencies of GIT and PATH gems are followed too, so the GEM gems they
+// pull in are included; ExternalClosure returns the GIT and PATH gems
+// themselves. Every platform variant of a matching gem is included. Dependency
+// cycles are handled, and names that aren't locked are skipped. Results are
+// returned in lockfile order.
+func (l *Lockfile) Closure(names []string) []GemSpec {
+	needed := l.closureNames(names)
+
+	result := make([]GemSpec, 0, len(needed))
+	for i := range l.GemSpecs {
+		if needed[l.GemSpecs[i].Name] {
+			result = append(result, l.GemSpecs[i])
+		}
+	}
+
+	return result
+}
+
+// ExternalClosure returns the GIT and PATH gems in the closure of the named
+// gems, the counterpart of Closure for gems not locked from a gem server.
+// Results are returned in lockfile order.
+func (l *Lockfile) ExternalClosure(names []string) (git []GitGemSpec, path []PathGemSpec) {
+	needed := l.closureNames(names)
+
+	for i := range l.GitSpecs {
+		if needed[l.GitSpecs[i].Name] {
+			git = append(git, l.GitSpecs[i])
+		}
+	}
+	for i := range l.PathSpecs {
+		if needed[l.PathSpecs[i].Name] {
+			path = append(path, l.PathSpecs[i])
+		}
+	}
+
+	return git, path
+}
+
+// closureNames returns the names of the named gems and every gem they depend
+// on, directly or not, across the GEM, GIT and PATH sections
+func (l *Lockfile) closureNames(names []string) map[string]bool {
+	needed := reachableFrom(l.dependencyGraph(), names)
+	for _, name := range names {
+		needed[name] = true
+	}
+	return needed
+}
+
+// DependencyLevels returns the depth of every locked gem in the dependency
+// graph: gems without dependencies are at level 0, and every other gem sits one
+// level above its deepest dependency. Installing level by level, each level in
+// parallel, never installs a gem before its dependencies.
+//
+// GEM, GIT and PATH specs are included, platform variants count as one gem and
+// dependencies that aren't locked are ignored. A cycle is broken by ignoring the
+// dependency that closes it, walking gems and dependencies in name order, so the
+// result is the same on every run.
+func (l *Lockfile) DependencyLevels() map[string]int {
+	deps := l.dependencyGraph()
+	levels := make(map[string]int, len(deps))
+	inProgress := make(map[string]bool)
+
+	var visit func(name string) int
+	visit = func(name string) int {
+		if level, done := levels[name]; done {
+			return level
+		}
+		inProgress[name] = true
+
+		names := slices.Clone(deps[name])
+		slices.Sort(names)
+		level := 0
+		for _, dep := range names {
+			if _, locked := deps[dep]; !locked || inProgress[dep] {
+				continue
+			}
+			level = max(level, visit(dep)+1)
+		}
+
+		delete(inProgress, name)
+		levels[name] = level
+		return level
+	}
+
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		visit(name)
+	}
+
+	return levels
+}
+
+// dependencyGraph maps every gem locked in the GEM, GIT and PATH sections to the
+// names of its dependencies, merging the dependencies of platform variants
+func (l *Lockfile) dependencyGraph() map[string][]string {
+	deps := make(map[string][]string)
+	addSpec := func(name string, specDeps []Dependency) {
+		if _, ok := deps[name]; !ok {
+			deps[name] = []string{}
+		}
+		for _, dep := range specDeps {
+			deps[name] = append(deps[name], dep.Name)
+		}
+	}
+	for i := range l.GemSpecs {
+		addSpec(l.GemSpecs[i].Name, l.GemSpecs[i].Dependencies)
+	}
+	for i := range l.GitSpecs {
+		addSpec(l.GitSpecs[i].Name, l.GitSpecs[i].Dependencies)
+	}
+	for i := range l.PathSpecs {
+		addSpec(l.PathSpecs[i].Name, l.PathSpecs[i].Dependencies)
+	}
+	return deps
+}
+
+// reachableFrom returns the gems reached by following one or more dependency
+// edges from names. A name is only included when a cycle leads back to it.
+func reachableFrom(graph map[string][]string, names []string) map[string]bool {
+	seen := make(map[string]bool)
+	var queue []string
+	for _, name := range names {
+		queue = append(queue, graph[name]...)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		queue = append(queue, graph[name]...)
+	}
+	return seen
+}
+
+// RedundantDependencies returns the sorted names of the DEPENDENCIES entries
+// that another top-level dependency already pulls in, e.g. rack next to rails.
+// Removing all of them from the Gemfile leaves the same set of locked gems.
+//
+// A dependency is still reported as needed when it is only there on purpose:
+//   - it has version constraints, which only the Gemfile enforces
+//   - it comes from a git or path source, marked with "!"
+//   - the gem pulling it in depends on it back, as in a cycle, since removing
+//     either side would drop the other
+func (l *Lockfile) RedundantDependencies() []string {
+	graph := l.dependencyGraph()
+	direct := make([]string, 0, len(l.Dependencies))
+	reach := make(map[string]map[string]bool, len(l.Dependencies))
+	for _, dep := range l.Dependencies {
+		name := strings.TrimSuffix(dep.Name, "!")
+		direct = append(direct, name)
+		reach[name] = reachableFrom(graph, []string{name})
+	}
+
+	var redundant []string
+	for _, dep := range l.Dependencies {
+		if len(dep.Constraints) > 0 || strings.HasSuffix(dep.Name, "!") {
+			continue
+		}
+		for _, other := range direct {
+			if other != dep.Name && reach[other][dep.Name] && !reach[dep.Name][other] {
+				redundant = append(redundant, dep.Name)
+				break
+			}
+		}
+	}
+
+	slices.Sort(redundant)
+	return slices.Compact(redundant)
+}
+
+// DuplicateAcrossSources returns the sorted names of gems that appear in more
+// than one of the GEM, GIT and PATH sections. This usually points at a source
+// override that left a stale entry behind.
+func (l *Lockfile) DuplicateAcrossSources() []string {
+	sections := make(map[string]map[string]bool)
+	record := func(name, section string) {
+		if sections[name] == nil {
+			sections[name] = make(map[string]bool)
+		}
+		sections[name][section] = true
+	}
+
+	for i := range l.GemSpecs {
+		record(l.GemSpecs[i].Name, sectionGEM)
+	}
+	for i := range l.GitSpecs {
+		record(l.GitSpecs[i].Name, sectionGIT)
+	}
+	for i := range l.PathSpecs {
+		record(l.PathSpecs[i].Name, sectionPATH)
+	}
+
+	var duplicates []string
+	for name, seenIn := range sections {
+		if len(seenIn) > 1 {
+			duplicates = append(duplicates, name)
+		}
+	}
+	slices.Sort(duplicates)
+
+	return duplicates
+}
+
+// LockfileStats summarizes the contents of a lockfile.
+type LockfileStats struct {
+	TotalGems        int // Specs across GEM, GIT and PATH sections, counting platform variants
+	GitGems          int // Specs in GIT sections
+	PathGems         int // Specs in PATH sections
+	PlatformSpecific int // GEM specs locked to a non-ruby platform
+	WithChecksums    int // GEM specs that carry a checksum
+	DirectGems       int // Distinct locked gems listed in DEPENDENCIES
+	TransitiveGems   int // Distinct locked gems pulled in only as dependencies of others
+}
+
+// Stats computes summary statistics for the lockfile.
+func (l *Lockfile) Stats() LockfileStats {
+	stats := LockfileStats{
+		TotalGems: len(l.GemSpecs) + len(l.GitSpecs) + len(l.PathSpecs),
+		GitGems:   len(l.GitSpecs),
+		PathGems:  len(l.PathSpecs),
+	}
+
+	locked := make(map[string]bool)
+	for i := range l.GemSpecs {
+		spec := &l.GemSpecs[i]
+		locked[spec.Name] = true
+		if spec.Platform != "" && spec.Platform != "ruby" {
+			stats.PlatformSpecific++
+		}
+		if spec.Checksum != "" {
+			stats.WithChecksums++
+		}
+	}
+	for i := range l.GitSpecs {
+		locked[l.GitSpecs[i].Name] = true
+	}
+	for i := range l.PathSpecs {
+		locked[l.PathSpecs[i].Name] = true
+	}
+
+	direct := make(map[string]bool)
+	for _, dep := range l.Dependencies {
+		// Gems from git/path sources are marked with a trailing "!"
+		name := strings.TrimSuffix(dep.Name, "!")
+		if locked[name] {
+			direct[name] = true
+		}
+	}
+
+	stats.DirectGems = len(direct)
+	stats.TransitiveGems = len(locked) - len(direct)
+
+	return stats
+}
+
+// LinkDependencies associates each DEPENDENCIES entry with the spec it was
+// resolved to. Source is set to the GEM remote, git remote or local path the
+// gem was locked from, Platform is set when the gem is locked to a single
+// platform-specific variant, and Scope is set to "direct".
+//
+// The "!" marker Bundler adds for gems with an explicit source is ignored when
+// matching, and GIT and PATH specs take precedence over GEM specs of the same
+// name. Entries without a matching spec are left untouched.
+func (l *Lockfile) LinkDependencies() {
+	for i := range l.Dependencies {
+		dep := &l.Dependencies[i]
+		name := strings.TrimSuffix(dep.Name, "!")
+
+		source, platform, found := l.findGitOrPathSource(name)
+		if !found {
+			source, platform, found = l.findGemSource(name)
+		}
+		if !found {
+			continue
+		}
+
+		dep.Scope = "direct"
+		dep.Source = source
+		if platform != "" {
+			dep.Platform = platform
+		}
+	}
+}
+
+// findGitOrPathSource returns the remote of the GIT or PATH block that locks name
+func (l *Lockfile) findGitOrPathSource(name string) (source, platform string, found bool) {
+	for i := range l.GitSpecs {
+		if l.GitSpecs[i].Name == name {
+			return l.GitSpecs[i].Remote, "", true
+		}
+	}
+	for i := range l.PathSpecs {
+		if l.PathSpecs[i].Name == name {
+			return l.PathSpecs[i].Remote, "", true
+		}
+	}
+	return "", "", false
+}
+
+// findGemSource returns the GEM remote that locks name, along with its platform
+// when every locked variant shares the same non-ruby platform
+func (l *Lockfile) findGemSource(name string) (source, platform string, found bool) {
+	var platforms []string
+	for i := range l.GemSpecs {
+		spec := &l.GemSpecs[i]
+		if spec.Name != name {
+			continue
+		}
+		if !found {
+			source = spec.remote()
+			found = true
+		}
+		if !slices.Contains(platforms, spec.Platform) {
+			platforms = append(platforms, spec.Platform)
+		}
+	}
+
+	if len(platforms) == 1 && platforms[0] != "ruby" {
+		platform = platforms[0]
+	}
+	return source, platform, found
+}
+
+// PrereleaseGems returns the GEM section specs locked to a prerelease version,
+// in lockfile order. As in RubyGems, a version is a prerelease when it has an
+// alphabetic segment, e.g. 8.1.0.rc1, 2.0.0.beta2 or 1.0.0.pre; platform
+// suffixes don't count.
+// Ruby equivalent: Bundler.locked_gems.specs.select { |s| s.version.prerelease? }
+func (l *Lockfile) PrereleaseGems() []GemSpec {
+	var result []GemSpec
+	for i := range l.GemSpecs {
+		if gemversion.IsPrerelease(l.GemSpecs[i].Version) {
+			result = append(result, l.GemSpecs[i])
+		}
+	}
+	return result
+}
+
+// NativeExtensionGems returns the sorted names of the gems that come with
+// native code: specs whose Extensions are known, and otherwise GEM specs locked
+// to a platform-specific variant, which ship precompiled extensions.
+// Platform-specific variants of the same gem are listed once.
+func (l *Lockfile) NativeExtensionGems() []string {
+	var names []string
+	add := func(name string) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	for i := range l.GemSpecs {
+		spec := &l.GemSpecs[i]
+		if len(spec.Extensions) > 0 || (spec.Platform != "" && spec.Platform != "ruby") {
+			add(spec.Name)
+		}
+	}
+	for i := range l.GitSpecs {
+		if len(l.GitSpecs[i].Extensions) > 0 {
+			add(l.GitSpecs[i].Name)
+		}
+	}
+	for i := range l.PathSpecs {
+		if len(l.PathSpecs[i].Extensions) > 0 {
+			add(l.PathSpecs[i].Name)
+		}
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// GemsWithMetadata maps the name of every locked gem that has the metadata key
+// to its value. Lockfiles don't record metadata, so it has to be filled in
+// first, e.g. by ApplyGemspec or other tooling; platform variants of a GEM spec
+// are expected to agree, and the first one wins.
+func (l *Lockfile) GemsWithMetadata(key string) map[string]string {
+	result := make(map[string]string)
+	add := func(name string, metadata map[string]string) {
+		if value, ok := metadata[key]; ok {
+			if _, seen := result[name]; !seen {
+				result[name] = value
+			}
+		}
+	}
+
+	for i := range l.GemSpecs {
+		add(l.GemSpecs[i].Name, l.GemSpecs[i].Metadata)
+	}
+	for i := range l.GitSpecs {
+		add(l.GitSpecs[i].Name, l.GitSpecs[i].Metadata)
+	}
+	for i := range l.PathSpecs {
+		add(l.PathSpecs[i].Name, l.PathSpecs[i].Metadata)
+	}
+	return result
+}
+
+// PlatformCoverage returns the sorted names of the gems locked only as
+// platform-specific variants that lack a variant for one of the lockfile's
+// PLATFORMS, e.g. a gem with x86_64-linux and x86_64-darwin variants in a
+// lockfile that also lists arm64-darwin. bundle install fails on such a platform.
+// Gems with a pure-Ruby variant install everywhere and are never reported.
+//
+// A variant without an OS version covers the versioned platform, so
+// arm64-darwin covers arm64-darwin-22, and x86_64-linux-gnu covers x86_64-linux.
+// java and universal-java-NN cover each other; glibc variants don't cover musl.
+func PlatformCoverage(l *Lockfile) []string {
+	variants := make(map[string][]string)
+	pure := make(map[string]bool)
+	for i := range l.GemSpecs {
+		spec := &l.GemSpecs[i]
+		if spec.Platform == "" || spec.Platform == "ruby" {
+			pure[spec.Name] = true
+		} else {
+			variants[spec.Name] = append(variants[spec.Name], spec.Platform)
+		}
+	}
+
+	var missing []string
+	for name, platforms := range variants {
+		if pure[name] {
+			continue
+		}
+		for _, target := range l.Platforms {
+			covered := slices.ContainsFunc(platforms, func(platform string) bool {
+				return platformCovers(platform, target)
+			})
+			if !covered {
+				missing = append(missing, name)
+				break
+			}
+		}
+	}
+
+	slices.Sort(missing)
+	return missing
+}
+
+// platformCovers reports whether a gem variant built for platform installs on
+// the lockfile platform target
+func platformCovers(platform, target string) bool {
+	// RubyGems reads java as universal-java with any Java version
+	if (platform == "java" && isJavaPlatform(target)) || (target == "java" && isJavaPlatform(platform)) {
+		return true
+	}
+	// A musl libc is not the gnu libc an unsuffixed linux variant is built for
+	if strings.HasSuffix(target, "-musl") != strings.HasSuffix(platform, "-musl") {
+		return false
+	}
+	return platform == target ||
+		strings.HasPrefix(target, platform+"-") ||
+		platform == target+"-gnu"
+}
+
+// isJavaPlatform reports whether platform is java or universal-java[-NN]
+func isJavaPlatform(platform string) bool {
+	return platform == "java" || platform == "universal-java" || strings.HasPrefix(platform, "universal-java-")
+}
+
+// ExternalGems returns the gems locked from git repositories and local paths,
+// the ones a vendoring tool has to fetch itself rather than from a gem server.
+func (l *Lockfile) ExternalGems() (git []GitGemSpec, path []PathGemSpec) {
+	return l.GitSpecs, l.PathSpecs
+}
+
+// HasExternalSources reports whether any gem is locked from a GIT or PATH section.
+func (l *Lockfile) HasExternalSources() bool {
+	return len(l.GitSpecs) > 0 || len(l.PathSpecs) > 0
+}
+
+// GitRemotes maps each git-sourced gem name to the remote URL it is cloned from.
+func (l *Lockfile) GitRemotes() map[string]string {
+	remotes := make(map[string]string, len(l.GitSpecs))
+	for i := range l.GitSpecs {
+		remotes[l.GitSpecs[i].Name] = l.GitSpecs[i].Remote
+	}
+	return remotes
+}
+
+// GitClone holds what a mirroring tool needs to clone a git-sourced gem and
+// check out the locked revision: git clone Remote && git checkout Revision.
+// Branch and Tag record what the Gemfile asked for; Revision is authoritative.
+type GitClone struct {
+	Name     string `json:"name"`
+	Remote   string `json:"remote"`
+	Revision string `json:"revision"`
+	Branch   string `json:"branch,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+}
+
+// GitCloneManifest returns one GitClone per git-sourced gem, in lockfile order.
+func (l *Lockfile) GitCloneManifest() []GitClone {
+	clones := make([]GitClone, 0, len(l.GitSpecs))
+	for i := range l.GitSpecs {
+		spec := &l.GitSpecs[i]
+		clones = append(clones, GitClone{
+			Name:     spec.Name,
+			Remote:   spec.Remote,
+			Revision: spec.Revision,
+			Branch:   spec.Branch,
+			Tag:      spec.Tag,
+		})
+	}
+	return clones
+}
+
+// MinimalDependencies returns the DEPENDENCIES entries needed to pull in the
+// keep gems and their dependency closure, dropping unrelated top-level
+// dependencies. Entries keep their constraints and lockfile order. A kept gem
+// that is neither listed in DEPENDENCIES nor pulled in by a returned entry is
+// appended as an unconstrained dependency.
+func (l *Lockfile) MinimalDependencies(keep []string) []Dependency {
+	graph := l.dependencyGraph()
+	covered := reachableFrom(graph, keep)
+	for _, name := range keep {
+		covered[name] = true
+	}
+
+	var result []Dependency
+	var resultNames []string
+	for _, dep := range l.Dependencies {
+		// Gems from git/path sources are marked with a trailing "!"
+		name := strings.TrimSuffix(dep.Name, "!")
+		if covered[name] {
+			result = append(result, dep)
+			resultNames = append(resultNames, name)
+		}
+	}
+
+	pulledIn := reachableFrom(graph, resultNames)
+	for _, name := range resultNames {
+		pulledIn[name] = true
+	}
+	for _, name := range keep {
+		if !pulledIn[name] {
+			pulledIn[name] = true
+			result = append(result, Dependency{Name: name})
+		}
+	}
+
+	return result
+}
+
+// ForGroups returns a copy of the lockfile trimmed to what bundle install
+// --with would install for the include groups: the DEPENDENCIES entries in those
+// groups or in the default group, and the GEM, GIT and PATH specs they pull in.
+// Group membership is read from the specs' Groups, so it must be applied first;
+// specs without groups count as default.
+//
+// Specs and dependencies keep their lockfile order, and Platforms, the Ruby
+// version and BundledWith are copied, so the result can be written as a
+// lockfile of its own.
+func (l *Lockfile) ForGroups(include []string) *Lockfile {
+	groups := make(map[string][]string)
+	for i := range l.GemSpecs {
+		if _, ok := groups[l.GemSpecs[i].Name]; !ok {
+			groups[l.GemSpecs[i].Name] = getGemGroups(&l.GemSpecs[i])
+		}
+	}
+	for i := range l.GitSpecs {
+		groups[l.GitSpecs[i].Name] = getGemGroups(&GemSpec{Groups: l.GitSpecs[i].Groups})
+	}
+	for i := range l.PathSpecs {
+		groups[l.PathSpecs[i].Name] = getGemGroups(&GemSpec{Groups: l.PathSpecs[i].Groups})
+	}
+
+	result := &Lockfile{
+		Platforms:     slices.Clone(l.Platforms),
+		BundledWith:   l.BundledWith,
+		RubyVersion:   l.RubyVersion,
+		RubyEngine:    l.RubyEngine,
+		EngineVersion: l.EngineVersion,
+		Groups:        make(map[string][]string),
+		SectionOrder:  slices.Clone(l.SectionOrder),
+	}
+
+	var roots []string
+	for _, dep := range l.Dependencies {
+		name := strings.TrimSuffix(dep.Name, "!")
+		gemGroups, ok := groups[name]
+		if !ok {
+			gemGroups = []string{"default"}
+		}
+		if isGemIncluded(gemGroups, include) {
+			result.Dependencies = append(result.Dependencies, dep)
+			roots = append(roots, name)
+		}
+	}
+
+	needed := reachableFrom(l.dependencyGraph(), roots)
+	for _, name := range roots {
+		needed[name] = true
+	}
+
+	for i := range l.GemSpecs {
+		if needed[l.GemSpecs[i].Name] {
+			result.GemSpecs = append(result.GemSpecs, l.GemSpecs[i])
+		}
+	}
+	for i := range l.GitSpecs {
+		if needed[l.GitSpecs[i].Name] {
+			result.GitSpecs = append(result.GitSpecs, l.GitSpecs[i])
+		}
+	}
+	for i := range l.PathSpecs {
+		if needed[l.PathSpecs[i].Name] {
+			result.PathSpecs = append(result.PathSpecs, l.PathSpecs[i])
+		}
+	}
+	for group, names := range l.Groups {
+		var kept []string
+		for _, name := range names {
+			if needed[name] {
+				kept = append(kept, name)
+			}
+		}
+		if len(kept) > 0 {
+			result.Groups[group] = kept
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,721 @@
+package lockfile
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const closureLockfile = `GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.0.4)
+      activesupport (= 7.0.4)
+      rack (~> 2.0)
+    activesupport (7.0.4)
+      concurrent-ruby (~> 1.0)
+    concurrent-ruby (1.2.2)
+      activesupport
+    puma (6.4.0)
+      nio4r (~> 2.0)
+    nio4r (2.5.9)
+    rack (2.2.8)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  actionpack
+  puma
+
+BUNDLED WITH
+   2.4.13`
+
+func TestClosure(t *testing.T) {
+	lockfile, err := Parse(strings.NewReader(closureLockfile))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	closure := lockfile.Closure([]string{"actionpack"})
+
+	var names []string
+	for i := range closure {
+		names = append(names, closure[i].Name)
+	}
+
+	// concurrent-ruby -> activesupport forms a cycle and must not loop forever
+	expected := []string{"actionpack", "activesupport", "concurrent-ruby", "rack"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected closure %v, got %v", expected, names)
+	}
+
+	if got := lockfile.Closure([]string{"missing"}); len(got) != 0 {
+		t.Errorf("Expected empty closure for unknown gem, got %v", got)
+	}
+}
+
+func TestClosureFollowsGitAndPathGems(t *testing.T) {
+	lockfileContent := `GIT
+  remote: https://github.com/rails/rails.git
+  revision: 0123456789abcdef0123456789abcdef01234567
+  specs:
+    actionpack (7.1.0.alpha)
+      engine
+      rack (~> 2.0)
+
+PATH
+  remote: engines/engine
+  specs:
+    engine (0.1.0)
+      concurrent-ruby (~> 1.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    concurrent-ruby (1.2.2)
+    puma (6.4.0)
+    rack (2.2.8)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  actionpack!
+  puma`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	gems := lockfile.Closure([]string{"actionpack"})
+	git, path := lockfile.ExternalClosure([]string{"actionpack"})
+
+	var names []string
+	for i := range gems {
+		names = append(names, gems[i].Name)
+	}
+	if expected := []string{"concurrent-ruby", "rack"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected GEM closure %v, got %v", expected, names)
+	}
+	if len(git) != 1 || git[0].Name != "actionpack" {
+		t.Errorf("Expected GIT closure [actionpack], got %v", git)
+	}
+	if len(path) != 1 || path[0].Name != "engine" {
+		t.Errorf("Expected PATH closure [engine], got %v", path)
+	}
+}
+
+func TestDuplicateAcrossSources(t *testing.T) {
+	lockfileContent := `PATH
+  remote: vendor/rack
+  specs:
+    rack (2.2.8)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    puma (6.4.0)
+      nio4r (~> 2.0)
+    nio4r (2.5.9)
+    rack (2.2.8)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  puma
+  rack!
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	duplicates := lockfile.DuplicateAcrossSources()
+	if len(duplicates) != 1 || duplicates[0] != "rack" {
+		t.Errorf("Expected [rack], got %v", duplicates)
+	}
+}
+
+func TestStats(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		expected LockfileStats
+	}{
+		{
+			fixture: "git.lock",
+			expected: LockfileStats{
+				TotalGems:      9,
+				GitGems:        2,
+				DirectGems:     2,
+				TransitiveGems: 7,
+			},
+		},
+		{
+			fixture: "platforms.lock",
+			expected: LockfileStats{
+				TotalGems:        5,
+				PlatformSpecific: 2,
+				DirectGems:       1,
+				TransitiveGems:   2,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			lockfile, err := ParseFile(filepath.Join("..", "testdata", tt.fixture))
+			if err != nil {
+				t.Fatalf("Failed to parse lockfile: %v", err)
+			}
+
+			if got := lockfile.Stats(); got != tt.expected {
+				t.Errorf("Expected stats %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("checksums", func(t *testing.T) {
+		lockfile := &Lockfile{
+			GemSpecs: []GemSpec{
+				{Name: "rack", Version: "2.2.8", Checksum: "sha256=abc"},
+				{Name: "puma", Version: "6.4.0"},
+			},
+		}
+
+		if got := lockfile.Stats().WithChecksums; got != 1 {
+			t.Errorf("Expected 1 gem with checksum, got %d", got)
+		}
+	})
+}
+
+func TestLinkDependencies(t *testing.T) {
+	lockfileContent := `GEM
+  remote: https://rubygems.org/
+  specs:
+    nokogiri (1.15.4)
+      racc (~> 1.4)
+    nokogiri (1.15.4-x86_64-linux)
+      racc (~> 1.4)
+    racc (1.7.1)
+    rails (7.0.0)
+    tzinfo-data (1.2023.3-x86_64-linux)
+
+GEM
+  remote: https://gems.example.com/
+  specs:
+    private_gem (1.0.0)
+
+GIT
+  remote: https://github.com/seuros/state_machines.git
+  revision: def456abc789
+  specs:
+    state_machines (0.6.0)
+
+PLATFORMS
+  ruby
+  x86_64-linux
+
+DEPENDENCIES
+  missing
+  nokogiri
+  private_gem
+  rails (= 7.0.0)
+  state_machines!
+  tzinfo-data
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	lockfile.LinkDependencies()
+
+	expected := map[string]struct{ source, platform, scope string }{
+		"missing":         {"", "", ""},
+		"nokogiri":        {"https://rubygems.org/", "", "direct"},
+		"private_gem":     {"https://gems.example.com/", "", "direct"},
+		"rails":           {"https://rubygems.org/", "", "direct"},
+		"state_machines!": {"https://github.com/seuros/state_machines.git", "", "direct"},
+		"tzinfo-data":     {"https://rubygems.org/", "x86_64-linux", "direct"},
+	}
+
+	for _, dep := range lockfile.Dependencies {
+		want, ok := expected[dep.Name]
+		if !ok {
+			t.Fatalf("Unexpected dependency %q", dep.Name)
+		}
+		if dep.Source != want.source {
+			t.Errorf("%s: expected source %q, got %q", dep.Name, want.source, dep.Source)
+		}
+		if dep.Platform != want.platform {
+			t.Errorf("%s: expected platform %q, got %q", dep.Name, want.platform, dep.Platform)
+		}
+		if dep.Scope != want.scope {
+			t.Errorf("%s: expected scope %q, got %q", dep.Name, want.scope, dep.Scope)
+		}
+	}
+}
+
+func TestPrereleaseGems(t *testing.T) {
+	content := `GEM
+  remote: https://rubygems.org/
+  specs:
+    nokogiri (1.16.0-x86_64-linux)
+    rack (3.0.9)
+    rails (8.1.0.rc1)
+    sorbet-runtime (0.5.11.pre.1)
+    sqlite3 (1.7.2-x64-mingw-ucrt)
+    turbo-rails (2.0.0.beta2)
+
+PLATFORMS
+  x86_64-linux
+
+DEPENDENCIES
+  rails (= 8.1.0.rc1)
+`
+
+	lockfile, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	var names []string
+	for _, spec := range lockfile.PrereleaseGems() {
+		names = append(names, spec.Name+" "+spec.Version)
+	}
+
+	expected := []string{"rails 8.1.0.rc1", "sorbet-runtime 0.5.11.pre.1", "turbo-rails 2.0.0.beta2"}
+	if strings.Join(names, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("PrereleaseGems() = %v, want %v", names, expected)
+	}
+}
+
+func TestMinimalDependencies(t *testing.T) {
+	lockfile, err := Parse(strings.NewReader(`GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.0.4)
+      activesupport (= 7.0.4)
+      rack (~> 2.0)
+    activesupport (7.0.4)
+      concurrent-ruby (~> 1.0)
+    concurrent-ruby (1.2.2)
+    puma (6.4.0)
+      nio4r (~> 2.0)
+    nio4r (2.5.9)
+    rack (2.2.8)
+
+DEPENDENCIES
+  actionpack (~> 7.0)
+  puma
+  rack (>= 2.2)
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	var got []string
+	for _, dep := range lockfile.MinimalDependencies([]string{"actionpack"}) {
+		got = append(got, dep.Name+" "+strings.Join(dep.Constraints, ", "))
+	}
+	// rack is pulled in by actionpack and keeps its top-level constraint; puma is unrelated
+	expected := []string{"actionpack ~> 7.0", "rack >= 2.2"}
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Errorf("MinimalDependencies([actionpack]) = %v, want %v", got, expected)
+	}
+
+	got = nil
+	for _, dep := range lockfile.MinimalDependencies([]string{"nio4r"}) {
+		got = append(got, dep.Name)
+	}
+	if strings.Join(got, "|") != "nio4r" {
+		t.Errorf("MinimalDependencies([nio4r]) = %v, want [nio4r]", got)
+	}
+}
+
+func TestExternalGems(t *testing.T) {
+	content := `GIT
+  remote: https://github.com/rails/rails.git
+  revision: 0123456789abcdef0123456789abcdef01234567
+  branch: main
+  specs:
+    rails (8.1.0.alpha)
+
+GIT
+  remote: https://github.com/heartcombo/devise.git
+  revision: fedcba9876543210fedcba9876543210fedcba98
+  specs:
+    devise (4.9.4)
+
+PATH
+  remote: engines/billing
+  specs:
+    billing (0.1.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (3.0.9)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  billing!
+  devise!
+  rack
+  rails!
+`
+
+	lockfile, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	if !lockfile.HasExternalSources() {
+		t.Error("expected HasExternalSources() to be true")
+	}
+
+	git, path := lockfile.ExternalGems()
+	if len(git) != 2 || len(path) != 1 || path[0].Name != "billing" {
+		t.Errorf("unexpected external gems: git=%v path=%v", git, path)
+	}
+
+	expected := map[string]string{
+		"rails":  "https://github.com/rails/rails.git",
+		"devise": "https://github.com/heartcombo/devise.git",
+	}
+	if remotes := lockfile.GitRemotes(); !reflect.DeepEqual(remotes, expected) {
+		t.Errorf("GitRemotes() = %v, want %v", remotes, expected)
+	}
+
+	registryOnly, err := Parse(strings.NewReader(closureLockfile))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+	if registryOnly.HasExternalSources() {
+		t.Error("expected HasExternalSources() to be false for a GEM-only lockfile")
+	}
+	if remotes := registryOnly.GitRemotes(); len(remotes) != 0 {
+		t.Errorf("expected no git remotes, got %v", remotes)
+	}
+}
+
+func TestGitCloneManifest(t *testing.T) {
+	lockfile, err := ParseFile(filepath.Join("..", "testdata", "git.lock"))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	expected := []GitClone{
+		{Name: "no_fly_list", Remote: "https://github.com/seuros/no_fly_list.git", Revision: "abc123def456", Tag: "v0.6.0"},
+		{Name: "state_machines", Remote: "https://github.com/seuros/state_machines.git", Revision: "def456abc789", Branch: "master"},
+	}
+	if got := lockfile.GitCloneManifest(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("GitCloneManifest() = %+v, want %+v", got, expected)
+	}
+
+	if got := (&Lockfile{}).GitCloneManifest(); len(got) != 0 {
+		t.Errorf("Expected an empty manifest without git gems, got %+v", got)
+	}
+}
+
+func TestDependencyLevels(t *testing.T) {
+	content := `PATH
+  remote: engines/billing
+  specs:
+    billing (0.1.0)
+      actionpack (>= 7.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.0.4)
+      activesupport (= 7.0.4)
+      rack (~> 2.0)
+    activesupport (7.0.4)
+      concurrent-ruby (~> 1.0)
+    concurrent-ruby (1.2.2)
+    nokogiri (1.16.0)
+      racc (~> 1.4)
+    nokogiri (1.16.0-x86_64-linux)
+      racc (~> 1.4)
+    pry (0.14.2)
+      method_source (~> 1.0)
+    rack (2.2.8)
+      rackup
+    rackup (2.1.0)
+      rack (>= 3)
+
+PLATFORMS
+  ruby
+  x86_64-linux
+
+DEPENDENCIES
+  billing!
+  nokogiri
+  pry
+`
+
+	lockfile, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	// rack <-> rackup is a cycle; walking in name order reaches rack first, so
+	// rackup's dependency on rack is the one ignored. racc and method_source aren't locked.
+	expected := map[string]int{
+		"concurrent-ruby": 0,
+		"activesupport":   1,
+		"rackup":          0,
+		"rack":            1,
+		"actionpack":      2,
+		"billing":         3,
+		"nokogiri":        0,
+		"pry":             0,
+	}
+	if got := lockfile.DependencyLevels(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("DependencyLevels() = %v, want %v", got, expected)
+	}
+}
+
+func TestNativeExtensionGems(t *testing.T) {
+	content := `GEM
+  remote: https://rubygems.org/
+  specs:
+    bcrypt (3.1.20)
+    nokogiri (1.16.0-arm64-darwin)
+    nokogiri (1.16.0-x86_64-linux)
+    rack (3.0.9)
+
+PLATFORMS
+  arm64-darwin
+  x86_64-linux
+
+DEPENDENCIES
+  bcrypt
+  nokogiri
+  rack
+`
+
+	lockfile, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+	// Bundler doesn't write extensions to the lockfile, they come from the installed specs
+	for i := range lockfile.GemSpecs {
+		if lockfile.GemSpecs[i].Name == "bcrypt" {
+			lockfile.GemSpecs[i].Extensions = []string{"ext/mri/extconf.rb"}
+		}
+	}
+
+	expected := []string{"bcrypt", "nokogiri"}
+	if got := lockfile.NativeExtensionGems(); strings.Join(got, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("NativeExtensionGems() = %v, want %v", got, expected)
+	}
+}
+
+func TestRedundantDependencies(t *testing.T) {
+	content := `GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.1.3)
+      rack (>= 2.2.4)
+    activesupport (7.1.3)
+    cyclic-a (1.0.0)
+      cyclic-b
+    cyclic-b (1.0.0)
+      cyclic-a
+    puma (6.4.2)
+      nio4r (~> 2.0)
+    nio4r (2.7.0)
+    rack (3.0.9)
+    rails (7.1.3)
+      actionpack (= 7.1.3)
+      activesupport (= 7.1.3)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  activesupport
+  cyclic-a
+  cyclic-b
+  nio4r (~> 2.7)
+  puma
+  rack
+  rails
+`
+
+	lockfile, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	// nio4r is pinned on purpose, and the cyclic gems need each other
+	expected := []string{"activesupport", "rack"}
+	if got := lockfile.RedundantDependencies(); strings.Join(got, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("RedundantDependencies() = %v, want %v", got, expected)
+	}
+}
+
+func TestForGroups(t *testing.T) {
+	content := `GEM
+  remote: https://rubygems.org/
+  specs:
+    debug (1.9.1)
+      irb (~> 1.10)
+    diff-lcs (1.5.1)
+    irb (1.11.2)
+    rack (3.0.9)
+    rspec (3.13.0)
+      rspec-core (~> 3.13.0)
+      rspec-expectations (~> 3.13.0)
+    rspec-core (3.13.0)
+    rspec-expectations (3.13.0)
+      diff-lcs (>= 1.2.0, < 2.0)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  debug
+  rack (~> 3.0)
+  rspec
+
+BUNDLED WITH
+   2.5.6
+`
+
+	lockfile, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+	groups := map[string][]string{"debug": {"development"}, "rspec": {"test"}}
+	for i := range lockfile.GemSpecs {
+		lockfile.GemSpecs[i].Groups = groups[lockfile.GemSpecs[i].Name]
+	}
+
+	testOnly := lockfile.ForGroups([]string{"test"})
+
+	var names []string
+	for _, spec := range testOnly.GemSpecs {
+		names = append(names, spec.Name)
+	}
+	expected := []string{"diff-lcs", "rack", "rspec", "rspec-core", "rspec-expectations"}
+	if strings.Join(names, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("ForGroups() specs = %v, want %v", names, expected)
+	}
+
+	var buf strings.Builder
+	if err := NewLockfileWriter().Write(testOnly, &buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "DEPENDENCIES\n  rack (~> 3.0)\n  rspec\n") {
+		t.Errorf("expected DEPENDENCIES trimmed to rack and rspec, got:\n%s", buf.String())
+	}
+	if len(lockfile.GemSpecs) != 7 || len(lockfile.Dependencies) != 3 {
+		t.Errorf("ForGroups() modified the original lockfile")
+	}
+}
+
+func TestGemsWithMetadata(t *testing.T) {
+	lockfile := &Lockfile{
+		GemSpecs: []GemSpec{
+			{Name: "rack", Version: "3.0.9", Metadata: map[string]string{"changelog_uri": "https://github.com/rack/rack/blob/main/CHANGELOG.md"}},
+			{Name: "rails", Version: "7.1.3", Metadata: map[string]string{"rubygems_mfa_required": "true"}},
+			{Name: "nokogiri", Version: "1.16.0", Platform: "x86_64-linux", Metadata: map[string]string{"rubygems_mfa_required": "true"}},
+			{Name: "nokogiri", Version: "1.16.0", Platform: "arm64-darwin", Metadata: map[string]string{"rubygems_mfa_required": "true"}},
+		},
+		GitSpecs: []GitGemSpec{
+			{Name: "state_machines", Metadata: map[string]string{"rubygems_mfa_required": "false"}},
+		},
+		PathSpecs: []PathGemSpec{
+			{Name: "billing"},
+		},
+	}
+
+	expected := map[string]string{"rails": "true", "nokogiri": "true", "state_machines": "false"}
+	if got := lockfile.GemsWithMetadata("rubygems_mfa_required"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("GemsWithMetadata() = %v, want %v", got, expected)
+	}
+	if got := lockfile.GemsWithMetadata("funding_uri"); len(got) != 0 {
+		t.Errorf("Expected no gems with funding_uri, got %v", got)
+	}
+}
+
+func TestPlatformCoverage(t *testing.T) {
+	content := `GEM
+  remote: https://rubygems.org/
+  specs:
+    ffi (1.16.3)
+    ffi (1.16.3-x86_64-linux-gnu)
+    google-protobuf (3.25.2-x86_64-darwin)
+    google-protobuf (3.25.2-x86_64-linux)
+    nokogiri (1.16.0-arm64-darwin)
+    nokogiri (1.16.0-x86_64-darwin)
+    nokogiri (1.16.0-x86_64-linux)
+    rack (3.0.9)
+    sqlite3 (1.7.2-arm64-darwin)
+    sqlite3 (1.7.2-x86_64-darwin)
+    sqlite3 (1.7.2-x86_64-linux-gnu)
+
+PLATFORMS
+  arm64-darwin-23
+  x86_64-darwin
+  x86_64-linux
+
+DEPENDENCIES
+  ffi
+  google-protobuf
+  nokogiri
+  rack
+  sqlite3
+`
+
+	lockfile, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	// google-protobuf has no arm64-darwin variant
+	expected := []string{"google-protobuf"}
+	if got := PlatformCoverage(lockfile); strings.Join(got, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("PlatformCoverage() = %v, want %v", got, expected)
+	}
+}
+
+func TestPlatformCovers(t *testing.T) {
+	tests := []struct {
+		platform string
+		target   string
+		expected bool
+	}{
+		{"x86_64-linux", "x86_64-linux", true},
+		{"arm64-darwin", "arm64-darwin-23", true},
+		{"x86_64-linux-gnu", "x86_64-linux", true},
+		{"x86_64-linux", "x86_64-linux-gnu", true},
+		{"x86_64-darwin", "arm64-darwin", false},
+		{"java", "universal-java-17", true},
+		{"java", "universal-java", true},
+		{"universal-java-17", "java", true},
+		{"universal-java", "universal-java-21", true},
+		{"java", "x86_64-linux", false},
+		{"x86_64-linux", "x86_64-linux-musl", false},
+		{"x86_64-linux-gnu", "x86_64-linux-musl", false},
+		{"x86_64-linux-musl", "x86_64-linux-musl", true},
+		{"x86_64-linux-musl", "x86_64-linux", false},
+	}
+
+	for _, tt := range tests {
+		if got := platformCovers(tt.platform, tt.target); got != tt.expected {
+			t.Errorf("platformCovers(%q, %q) = %v, want %v", tt.platform, tt.target, got, tt.expected)
+		}
+	}
+}
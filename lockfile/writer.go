@@ -2,11 +2,14 @@ package lockfile
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
-	"os"
+	"net/url"
 	"slices"
 	"strings"
+
+	"github.com/contriboss/gemfile-go/internal/atomicfile"
 )
 
 const (
@@ -19,6 +22,10 @@ const (
 // LockfileWriter handles writing Gemfile.lock files.
 type LockfileWriter struct {
 	DefaultGemRemote string
+	// PreserveOrder re-emits sections in the order recorded in Lockfile.SectionOrder
+	// instead of the canonical order. Sections missing from the recorded order follow
+	// in canonical order.
+	PreserveOrder bool
 }
 
 // NewLockfileWriter creates a new LockfileWriter with default settings.
@@ -28,47 +35,129 @@ func NewLockfileWriter() *LockfileWriter {
 	}
 }
 
+// NewLockfileWriterWithRemote creates a LockfileWriter that emits gems without
+// a SourceURL under the given remote, e.g. an internal RubyGems mirror.
+// A trailing slash is added to match Bundler's output. It returns an error if
+// the remote isn't an absolute http, https or file URL.
+func NewLockfileWriterWithRemote(remote string) (*LockfileWriter, error) {
+	if err := validateRemote(remote); err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(remote, "/") {
+		remote += "/"
+	}
+	return &LockfileWriter{
+		DefaultGemRemote: remote,
+	}, nil
+}
+
+// validateRemote checks that a GEM remote is an absolute http(s) or file URL
+func validateRemote(remote string) error {
+	parsed, err := url.Parse(remote)
+	if err != nil {
+		return fmt.Errorf("invalid gem remote %q: %w", remote, err)
+	}
+
+	switch parsed.Scheme {
+	case "http", "https":
+		if parsed.Host == "" {
+			return fmt.Errorf("invalid gem remote %q: missing host", remote)
+		}
+	case "file":
+	default:
+		return fmt.Errorf("invalid gem remote %q: expected http, https or file URL", remote)
+	}
+
+	return nil
+}
+
 // Write serializes a Lockfile to the given writer in Bundler's Gemfile.lock format.
 func (w *LockfileWriter) Write(lf *Lockfile, writer io.Writer) error {
 	buf := bufio.NewWriter(writer)
 	defer buf.Flush()
 
-	sections := []func(*Lockfile, *bufio.Writer) error{
-		w.writeGemSection,
-		w.writeGitSection,
-		w.writePathSection,
-		w.writePlatformsSection,
-		w.writeDependenciesSection,
-		w.writeBundledWithSection,
-	}
-
-	firstSection := true
-	for _, writeSection := range sections {
-		// Check if section has content before writing
-		if err := writeSection(lf, buf); err != nil {
+	sectionWriters := map[string]func(*Lockfile, *bufio.Writer) error{
+		sectionGEM:          w.writeGemSection,
+		sectionGIT:          w.writeGitSection,
+		sectionPATH:         w.writePathSection,
+		sectionPLATFORMS:    w.writePlatformsSection,
+		sectionDEPENDENCIES: w.writeDependenciesSection,
+		sectionCHECKSUMS:    w.writeChecksumsSection,
+		sectionRUBY_VERSION: w.writeRubyVersionSection,
+		sectionBUNDLED_WITH: w.writeBundledWithSection,
+	}
+
+	wroteSection := false
+	for _, name := range w.sectionOrder(lf) {
+		// Render each section separately so empty ones don't produce stray blank lines
+		var section bytes.Buffer
+		sectionBuf := bufio.NewWriter(&section)
+		if err := sectionWriters[name](lf, sectionBuf); err != nil {
+			return err
+		}
+		if err := sectionBuf.Flush(); err != nil {
 			return err
 		}
+		if section.Len() == 0 {
+			continue
+		}
+
 		// Add blank line between sections (except before first)
-		if !firstSection {
+		if wroteSection {
 			if _, err := buf.WriteString("\n"); err != nil {
 				return err
 			}
 		}
-		firstSection = false
+		if _, err := buf.Write(section.Bytes()); err != nil {
+			return err
+		}
+		wroteSection = true
 	}
 
 	return buf.Flush()
 }
 
+// sectionOrder returns the order in which sections are written.
+func (w *LockfileWriter) sectionOrder(lf *Lockfile) []string {
+	canonical := []string{
+		sectionGEM,
+		sectionGIT,
+		sectionPATH,
+		sectionPLATFORMS,
+		sectionDEPENDENCIES,
+		sectionCHECKSUMS,
+		sectionRUBY_VERSION,
+		sectionBUNDLED_WITH,
+	}
+
+	if !w.PreserveOrder || len(lf.SectionOrder) == 0 {
+		return canonical
+	}
+
+	order := make([]string, 0, len(canonical))
+	for _, name := range lf.SectionOrder {
+		if slices.Contains(canonical, name) && !slices.Contains(order, name) {
+			order = append(order, name)
+		}
+	}
+	for _, name := range canonical {
+		if !slices.Contains(order, name) {
+			order = append(order, name)
+		}
+	}
+
+	return order
+}
+
 // WriteFile writes a Lockfile to the specified file path.
+// The lockfile is replaced atomically, so a failed write leaves the old one intact.
 func (w *LockfileWriter) WriteFile(lf *Lockfile, path string) error {
-	file, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("failed to create lockfile: %w", err)
+	if err := atomicfile.WriteFile(path, 0644, func(out io.Writer) error {
+		return w.Write(lf, out)
+	}); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
 	}
-	defer file.Close()
-
-	return w.Write(lf, file)
+	return nil
 }
 
 // writeGemSection writes the GEM section(s) with sorted specs.
@@ -200,8 +289,14 @@ func (w *LockfileWriter) writeGitSection(lf *Lockfile, buf *bufio.Writer) error
 	})
 
 	// Write each git source block
-	for _, src := range sources {
-		if _, err := buf.WriteString("\nGIT\n"); err != nil {
+	for i, src := range sources {
+		if i > 0 {
+			// Add blank line between GIT sections
+			if _, err := buf.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := buf.WriteString("GIT\n"); err != nil {
 			return err
 		}
 		if _, err := buf.WriteString(indent2 + "remote: " + src.remote + "\n"); err != nil {
@@ -297,8 +392,14 @@ func (w *LockfileWriter) writePathSection(lf *Lockfile, buf *bufio.Writer) error
 	})
 
 	// Write each path source block
-	for _, src := range sources {
-		if _, err := buf.WriteString("\nPATH\n"); err != nil {
+	for i, src := range sources {
+		if i > 0 {
+			// Add blank line between PATH sections
+			if _, err := buf.WriteString("\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := buf.WriteString("PATH\n"); err != nil {
 			return err
 		}
 		if _, err := buf.WriteString(indent2 + "remote: " + src.remote + "\n"); err != nil {
@@ -353,7 +454,7 @@ func (w *LockfileWriter) writePlatformsSection(lf *Lockfile, buf *bufio.Writer)
 		return nil
 	}
 
-	if _, err := buf.WriteString("\nPLATFORMS\n"); err != nil {
+	if _, err := buf.WriteString("PLATFORMS\n"); err != nil {
 		return err
 	}
 
@@ -384,7 +485,7 @@ func (w *LockfileWriter) writeDependenciesSection(lf *Lockfile, buf *bufio.Write
 		return nil
 	}
 
-	if _, err := buf.WriteString("\nDEPENDENCIES\n"); err != nil {
+	if _, err := buf.WriteString("DEPENDENCIES\n"); err != nil {
 		return err
 	}
 
@@ -404,13 +505,81 @@ func (w *LockfileWriter) writeDependenciesSection(lf *Lockfile, buf *bufio.Write
 	return nil
 }
 
+// writeChecksumsSection writes the CHECKSUMS section when any spec has a checksum.
+// Like Bundler, every spec is listed; those without a checksum get no digest.
+func (w *LockfileWriter) writeChecksumsSection(lf *Lockfile, buf *bufio.Writer) error {
+	var entries []string
+	hasChecksum := false
+	addEntry := func(name, version, checksum string) {
+		entry := fmt.Sprintf("%s%s (%s)", indent2, name, version)
+		if checksum != "" {
+			entry += " " + checksum
+			hasChecksum = true
+		}
+		entries = append(entries, entry)
+	}
+
+	for i := range lf.GemSpecs {
+		spec := &lf.GemSpecs[i]
+		version := spec.Version
+		if spec.Platform != "" {
+			version += "-" + spec.Platform
+		}
+		addEntry(spec.Name, version, spec.Checksum)
+	}
+	for i := range lf.GitSpecs {
+		addEntry(lf.GitSpecs[i].Name, lf.GitSpecs[i].Version, lf.GitSpecs[i].Checksum)
+	}
+	for i := range lf.PathSpecs {
+		addEntry(lf.PathSpecs[i].Name, lf.PathSpecs[i].Version, lf.PathSpecs[i].Checksum)
+	}
+
+	if !hasChecksum {
+		return nil
+	}
+	slices.Sort(entries)
+
+	if _, err := buf.WriteString("CHECKSUMS\n"); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, err := buf.WriteString(entry + "\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeRubyVersionSection writes the RUBY VERSION section, adding the engine
+// in parentheses when it isn't MRI.
+func (w *LockfileWriter) writeRubyVersionSection(lf *Lockfile, buf *bufio.Writer) error {
+	if lf.RubyVersion == "" {
+		return nil
+	}
+
+	version := "ruby " + lf.RubyVersion
+	if lf.RubyEngine != "" {
+		version += " (" + strings.TrimSpace(lf.RubyEngine+" "+lf.EngineVersion) + ")"
+	}
+
+	if _, err := buf.WriteString("RUBY VERSION\n"); err != nil {
+		return err
+	}
+	if _, err := buf.WriteString("   " + version + "\n"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // writeBundledWithSection writes the BUNDLED WITH section.
 func (w *LockfileWriter) writeBundledWithSection(lf *Lockfile, buf *bufio.Writer) error {
 	if lf.BundledWith == "" {
 		return nil
 	}
 
-	if _, err := buf.WriteString("\nBUNDLED WITH\n"); err != nil {
+	if _, err := buf.WriteString("BUNDLED WITH\n"); err != nil {
 		return err
 	}
 	if _, err := buf.WriteString("   " + lf.BundledWith + "\n"); err != nil {
@@ -429,7 +598,8 @@ func (w *LockfileWriter) writeDependency(buf *bufio.Writer, dep *Dependency, ind
 		return nil
 	}
 
-	constraints := strings.Join(dep.Constraints, ", ")
+	// Constraints set by callers may be spaced irregularly, e.g. ">=2.2.0"; Bundler writes ">= 2.2.0"
+	constraints := strings.Join(parseConstraints(strings.Join(dep.Constraints, ",")), ", ")
 	if _, err := fmt.Fprintf(buf, "%s%s (%s)\n", indent, dep.Name, constraints); err != nil {
 		return err
 	}
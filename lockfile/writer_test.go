@@ -303,6 +303,9 @@ func TestRoundTrip(t *testing.T) {
 		"../testdata/Gemfile.lock",
 		"../testdata/git.lock",
 		"../testdata/platforms.lock",
+		"../testdata/multi_source.lock",
+		"../testdata/ruby_version.lock",
+		"../testdata/checksums.lock",
 	}
 
 	for _, testFile := range testFiles {
@@ -353,6 +356,13 @@ func TestRoundTrip(t *testing.T) {
 					original.BundledWith, reparsed.BundledWith)
 			}
 
+			if original.RubyVersion != reparsed.RubyVersion || original.RubyEngine != reparsed.RubyEngine ||
+				original.EngineVersion != reparsed.EngineVersion {
+				t.Errorf("Ruby version mismatch: original=%s %s %s, reparsed=%s %s %s",
+					original.RubyVersion, original.RubyEngine, original.EngineVersion,
+					reparsed.RubyVersion, reparsed.RubyEngine, reparsed.EngineVersion)
+			}
+
 			// Verify specific gems are preserved
 			for _, originalGem := range original.GemSpecs {
 				found := false
@@ -377,6 +387,133 @@ func TestRoundTrip(t *testing.T) {
 	}
 }
 
+func TestMultiSourceRoundTrip(t *testing.T) {
+	fixture := filepath.Join("..", "testdata", "multi_source.lock")
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	original, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", fixture, err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewLockfileWriter().Write(original, &buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// One GEM block per remote, sorted by remote, exactly as Bundler writes it
+	if buf.String() != string(data) {
+		t.Errorf("Expected output to match fixture:\n%s\n\nGot:\n%s", data, buf.String())
+	}
+
+	reparsed, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Failed to reparse written lockfile: %v", err)
+	}
+
+	expected := map[string]string{
+		"private_auth": "https://gems.example.com/",
+		"jwt":          "",
+		"rack":         "",
+	}
+	for name, remote := range expected {
+		gem := reparsed.FindGem(name)
+		if gem == nil {
+			t.Fatalf("Gem %s not found in reparsed output", name)
+		}
+		if gem.SourceURL != remote {
+			t.Errorf("Gem %s: expected source %q, got %q", name, remote, gem.SourceURL)
+		}
+	}
+}
+
+func TestNewLockfileWriterWithRemote(t *testing.T) {
+	lf := &Lockfile{
+		GemSpecs: []GemSpec{
+			{Name: "rack", Version: "2.2.8"},
+			{Name: "private_gem", Version: "1.0.0", SourceURL: "https://gems.example.com/"},
+		},
+		Platforms: []string{"ruby"},
+	}
+
+	writer, err := NewLockfileWriterWithRemote("https://mirror.example.com/rubygems")
+	if err != nil {
+		t.Fatalf("NewLockfileWriterWithRemote failed: %v", err)
+	}
+	if writer.DefaultGemRemote != "https://mirror.example.com/rubygems/" {
+		t.Errorf("Expected trailing slash to be added, got %q", writer.DefaultGemRemote)
+	}
+
+	var buf bytes.Buffer
+	if err := writer.Write(lf, &buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "GEM\n  remote: https://mirror.example.com/rubygems/\n  specs:\n    rack (2.2.8)\n") {
+		t.Errorf("Expected rack under the mirror remote:\n%s", output)
+	}
+	if !strings.Contains(output, "GEM\n  remote: https://gems.example.com/\n  specs:\n    private_gem (1.0.0)\n") {
+		t.Errorf("Expected private_gem to keep its own remote:\n%s", output)
+	}
+	if strings.Contains(output, "rubygems.org") {
+		t.Errorf("Default remote should not be used:\n%s", output)
+	}
+
+	for _, remote := range []string{"", "mirror.example.com", "ftp://mirror.example.com/", "https:///gems"} {
+		if _, err := NewLockfileWriterWithRemote(remote); err == nil {
+			t.Errorf("Expected error for invalid remote %q", remote)
+		}
+	}
+}
+
+func TestNewLockfileWriterWithRemoteParsedLockfile(t *testing.T) {
+	content := `GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (3.0.8)
+
+GEM
+  remote: https://gems.example.com/
+  specs:
+    private_gem (1.0.0)
+
+PLATFORMS
+  ruby
+`
+	lf, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Only gems from a non-default remote record it
+	if rack := lf.FindGem("rack"); rack.SourceURL != "" {
+		t.Errorf("Expected rack to have no SourceURL, got %q", rack.SourceURL)
+	}
+	if private := lf.FindGem("private_gem"); private.SourceURL != "https://gems.example.com/" {
+		t.Errorf("Expected private_gem SourceURL, got %q", private.SourceURL)
+	}
+
+	writer, err := NewLockfileWriterWithRemote("https://mirror.example.com/")
+	if err != nil {
+		t.Fatalf("NewLockfileWriterWithRemote failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := writer.Write(lf, &buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "GEM\n  remote: https://mirror.example.com/\n  specs:\n    rack (3.0.8)\n") {
+		t.Errorf("Expected rack under the mirror remote:\n%s", output)
+	}
+	if !strings.Contains(output, "GEM\n  remote: https://gems.example.com/\n  specs:\n    private_gem (1.0.0)\n") {
+		t.Errorf("Expected private_gem to keep its own remote:\n%s", output)
+	}
+}
+
 func TestWriteFile(t *testing.T) {
 	lf := &Lockfile{
 		GemSpecs: []GemSpec{
@@ -541,3 +678,237 @@ func TestPlatformDeduplication(t *testing.T) {
 		t.Errorf("Expected 'x86_64-linux' platform to appear once, found %d times", linuxCount)
 	}
 }
+
+func TestPreserveSectionOrder(t *testing.T) {
+	lockfileContent := `PLATFORMS
+  ruby
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (2.2.8)
+
+DEPENDENCIES
+  rack
+  sinatra!
+
+GIT
+  remote: https://github.com/sinatra/sinatra.git
+  revision: 1234567890abcdef1234567890abcdef12345678
+  specs:
+    sinatra (4.0.0)
+      rack (>= 2.0)
+
+BUNDLED WITH
+   2.4.13
+`
+
+	lf, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	recordedOrder := []string{sectionPLATFORMS, sectionGEM, sectionDEPENDENCIES, sectionGIT, sectionBUNDLED_WITH}
+	if strings.Join(lf.SectionOrder, ",") != strings.Join(recordedOrder, ",") {
+		t.Errorf("Expected section order %v, got %v", recordedOrder, lf.SectionOrder)
+	}
+
+	sectionHeaders := func(output string) []string {
+		var headers []string
+		for _, line := range strings.Split(output, "\n") {
+			if line != "" && !strings.HasPrefix(line, " ") {
+				headers = append(headers, line)
+			}
+		}
+		return headers
+	}
+
+	var buf bytes.Buffer
+	writer := NewLockfileWriter()
+	writer.PreserveOrder = true
+	if err := writer.Write(lf, &buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expectedOrder := []string{"PLATFORMS", "GEM", "DEPENDENCIES", "GIT", "BUNDLED WITH"}
+	if got := sectionHeaders(buf.String()); strings.Join(got, ",") != strings.Join(expectedOrder, ",") {
+		t.Errorf("Expected preserved order %v, got %v", expectedOrder, got)
+	}
+	if strings.Contains(buf.String(), "\n\n\n") {
+		t.Errorf("Output should not contain consecutive blank lines:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := NewLockfileWriter().Write(lf, &buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	canonicalOrder := []string{"GEM", "GIT", "PLATFORMS", "DEPENDENCIES", "BUNDLED WITH"}
+	if got := sectionHeaders(buf.String()); strings.Join(got, ",") != strings.Join(canonicalOrder, ",") {
+		t.Errorf("Expected canonical order %v, got %v", canonicalOrder, got)
+	}
+}
+
+func TestPathDotRemoteRoundTrip(t *testing.T) {
+	lockfileContent := `PATH
+  remote: .
+  specs:
+    payment_core (0.9.0)
+      money (~> 6.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    money (6.16.0)
+    rake (13.1.0)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  payment_core!
+  rake
+
+BUNDLED WITH
+   2.5.6
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	if len(lockfile.PathSpecs) != 1 || lockfile.PathSpecs[0].Remote != "." {
+		t.Fatalf("Expected one PATH spec with remote '.', got %+v", lockfile.PathSpecs)
+	}
+
+	// Keep PATH ahead of GEM as Bundler writes it
+	writer := NewLockfileWriter()
+	writer.PreserveOrder = true
+
+	var buf bytes.Buffer
+	if err := writer.Write(lockfile, &buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if buf.String() != lockfileContent {
+		t.Errorf("Expected output to match input:\n%s\n\nGot:\n%s", lockfileContent, buf.String())
+	}
+}
+
+func TestConstraintSpacingRoundTrip(t *testing.T) {
+	lockfileContent := `GIT
+  remote: https://github.com/rack/rack.git
+  revision: abc123
+  specs:
+    rack (3.1.0)
+      webrick (>=1.8)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    puma (6.4.2)
+      nio4r (~>2.0)
+    nio4r (2.7.0)
+    webrick (1.8.1)
+
+DEPENDENCIES
+  puma (>=2.2.0, <7)
+  rack!
+`
+	expected := `GIT
+  remote: https://github.com/rack/rack.git
+  revision: abc123
+  specs:
+    rack (3.1.0)
+      webrick (>= 1.8)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    nio4r (2.7.0)
+    puma (6.4.2)
+      nio4r (~> 2.0)
+    webrick (1.8.1)
+
+DEPENDENCIES
+  puma (>= 2.2.0, < 7)
+  rack!
+`
+
+	lockfile, err := Parse(strings.NewReader(lockfileContent))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writer := NewLockfileWriter()
+	writer.PreserveOrder = true
+	if err := writer.Write(lockfile, &buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if buf.String() != expected {
+		t.Errorf("Expected canonical constraint spacing:\n%s\n\nGot:\n%s", expected, buf.String())
+	}
+
+	// Constraints set directly are normalized on write too
+	lockfile.Dependencies[0].Constraints = []string{">=2.2.0", "  <7"}
+	buf.Reset()
+	if err := writer.Write(lockfile, &buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "  puma (>= 2.2.0, < 7)\n") {
+		t.Errorf("Expected normalized puma constraints, got:\n%s", buf.String())
+	}
+}
+
+func TestRubyVersionSectionRoundTrip(t *testing.T) {
+	original, err := os.ReadFile("../testdata/ruby_version.lock")
+	if err != nil {
+		t.Fatalf("Failed to read lockfile: %v", err)
+	}
+	lockfile, err := Parse(bytes.NewReader(original))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewLockfileWriter().Write(lockfile, &buf); err != nil {
+		t.Fatalf("Failed to write lockfile: %v", err)
+	}
+	// Bundler writes RUBY VERSION between DEPENDENCIES and BUNDLED WITH
+	if buf.String() != string(original) {
+		t.Errorf("Round trip changed the lockfile:\n%s\nexpected:\n%s", buf.String(), original)
+	}
+}
+
+func TestChecksumsSectionRoundTrip(t *testing.T) {
+	original, err := os.ReadFile("../testdata/checksums.lock")
+	if err != nil {
+		t.Fatalf("Failed to read lockfile: %v", err)
+	}
+	lockfile, err := Parse(bytes.NewReader(original))
+	if err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewLockfileWriter().Write(lockfile, &buf); err != nil {
+		t.Fatalf("Failed to write lockfile: %v", err)
+	}
+	// Bundler writes CHECKSUMS between DEPENDENCIES and RUBY VERSION, listing gems without a digest by name only
+	if buf.String() != string(original) {
+		t.Errorf("Round trip changed the lockfile:\n%s\nexpected:\n%s", buf.String(), original)
+	}
+
+	// Without any checksum the section is left out entirely
+	for i := range lockfile.GemSpecs {
+		lockfile.GemSpecs[i].Checksum = ""
+	}
+	buf.Reset()
+	if err := NewLockfileWriter().Write(lockfile, &buf); err != nil {
+		t.Fatalf("Failed to write lockfile: %v", err)
+	}
+	if strings.Contains(buf.String(), "CHECKSUMS") {
+		t.Errorf("Expected no CHECKSUMS section, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,84 @@
+// Package resolver defines the extension point for turning Gemfile dependencies
+// into a locked set of gems. gemfile-go does not ship a resolver of its own;
+// implementations plug in through the Resolver interface.
+//
+// Ruby equivalent: Bundler::Resolver
+package resolver
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+// Resolver turns declared dependencies into a lockfile.
+type Resolver interface {
+	Resolve(deps []gemfile.GemDependency) (*lockfile.Lockfile, error)
+}
+
+// NopResolver is a Resolver that performs no resolution. The returned lockfile
+// lists the declared dependencies but contains no specs.
+type NopResolver struct{}
+
+// Resolve implements Resolver
+func (NopResolver) Resolve(deps []gemfile.GemDependency) (*lockfile.Lockfile, error) {
+	return &lockfile.Lockfile{
+		Dependencies: LockfileDependencies(deps),
+		Groups:       LockfileGroups(deps),
+	}, nil
+}
+
+// ResolveGemfile parses the Gemfile at path and hands its dependencies to r.
+func ResolveGemfile(path string, r Resolver) (*lockfile.Lockfile, error) {
+	parsed, err := gemfile.NewGemfileParser(path).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return ResolveParsed(parsed, r)
+}
+
+// ResolveParsed hands the dependencies of an already parsed Gemfile to r.
+func ResolveParsed(parsed *gemfile.ParsedGemfile, r Resolver) (*lockfile.Lockfile, error) {
+	lf, err := r.Resolve(parsed.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve dependencies: %w", err)
+	}
+	if lf == nil {
+		return nil, fmt.Errorf("resolver returned no lockfile")
+	}
+	return lf, nil
+}
+
+// LockfileDependencies converts Gemfile dependencies into DEPENDENCIES entries.
+// Gems with a git or path source get Bundler's "!" marker. Unlike
+// lockfile.FromGemDependency, the entries carry only a name and constraints.
+func LockfileDependencies(deps []gemfile.GemDependency) []lockfile.Dependency {
+	result := make([]lockfile.Dependency, 0, len(deps))
+	for i := range deps {
+		dep := lockfile.FromGemDependency(&deps[i])
+		dep.Source = ""
+		result = append(result, dep)
+	}
+	return result
+}
+
+// LockfileGroups maps each group to the names of the gems declared in it.
+// Gems without explicit groups belong to "default".
+func LockfileGroups(deps []gemfile.GemDependency) map[string][]string {
+	groups := make(map[string][]string)
+	for i := range deps {
+		dep := &deps[i]
+		depGroups := dep.Groups
+		if len(depGroups) == 0 {
+			depGroups = []string{"default"}
+		}
+		for _, group := range depGroups {
+			if !slices.Contains(groups[group], dep.Name) {
+				groups[group] = append(groups[group], dep.Name)
+			}
+		}
+	}
+	return groups
+}
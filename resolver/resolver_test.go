@@ -0,0 +1,120 @@
+package resolver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/contriboss/gemfile-go/gemfile"
+	"github.com/contriboss/gemfile-go/lockfile"
+)
+
+// pinResolver locks every dependency to version 1.0.0
+type pinResolver struct{}
+
+func (pinResolver) Resolve(deps []gemfile.GemDependency) (*lockfile.Lockfile, error) {
+	lf := &lockfile.Lockfile{
+		Dependencies: LockfileDependencies(deps),
+		Groups:       LockfileGroups(deps),
+		Platforms:    []string{"ruby"},
+	}
+	for i := range deps {
+		lf.GemSpecs = append(lf.GemSpecs, lockfile.GemSpec{Name: deps[i].Name, Version: "1.0.0"})
+		lf.Dependencies[i].Constraints = []string{"= 1.0.0"}
+	}
+	return lf, nil
+}
+
+// failingResolver always fails
+type failingResolver struct{}
+
+var errUnresolvable = errors.New("unresolvable")
+
+func (failingResolver) Resolve([]gemfile.GemDependency) (*lockfile.Lockfile, error) {
+	return nil, errUnresolvable
+}
+
+func TestResolveGemfile(t *testing.T) {
+	gemfilePath := filepath.Join(t.TempDir(), "Gemfile")
+	content := `source 'https://rubygems.org'
+
+gem 'rails', '~> 7.0'
+
+group :test do
+  gem 'rspec'
+end
+`
+	if err := os.WriteFile(gemfilePath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write Gemfile: %v", err)
+	}
+
+	lf, err := ResolveGemfile(gemfilePath, pinResolver{})
+	if err != nil {
+		t.Fatalf("ResolveGemfile failed: %v", err)
+	}
+
+	for _, name := range []string{"rails", "rspec"} {
+		spec := lf.FindGem(name)
+		if spec == nil {
+			t.Fatalf("Expected %s to be locked", name)
+		}
+		if spec.Version != "1.0.0" {
+			t.Errorf("Expected %s to be pinned to 1.0.0, got %s", name, spec.Version)
+		}
+	}
+
+	if len(lf.Dependencies) != 2 || lf.Dependencies[0].Constraints[0] != "= 1.0.0" {
+		t.Errorf("Expected pinned dependencies, got %+v", lf.Dependencies)
+	}
+	if groups := lf.Groups["test"]; len(groups) != 1 || groups[0] != "rspec" {
+		t.Errorf("Expected rspec in test group, got %v", lf.Groups)
+	}
+
+	if _, err := ResolveGemfile(gemfilePath, failingResolver{}); !errors.Is(err, errUnresolvable) {
+		t.Errorf("Expected resolver error to be wrapped, got %v", err)
+	}
+}
+
+func TestNopResolver(t *testing.T) {
+	parsed := &gemfile.ParsedGemfile{
+		Dependencies: []gemfile.GemDependency{
+			{Name: "rails", Constraints: []string{"~> 7.0"}},
+			{Name: "state_machines", Source: &gemfile.Source{Type: "git", URL: "https://github.com/state-machines/state_machines.git"}},
+		},
+	}
+
+	lf, err := ResolveParsed(parsed, NopResolver{})
+	if err != nil {
+		t.Fatalf("ResolveParsed failed: %v", err)
+	}
+
+	if len(lf.GemSpecs) != 0 {
+		t.Errorf("Expected no specs, got %+v", lf.GemSpecs)
+	}
+	if len(lf.Dependencies) != 2 || lf.Dependencies[1].Name != "state_machines!" {
+		t.Errorf("Expected git dependency to carry the ! marker, got %+v", lf.Dependencies)
+	}
+	if len(lf.Dependencies[0].Constraints) != 1 || lf.Dependencies[0].Constraints[0] != "~> 7.0" {
+		t.Errorf("Expected constraints to be kept, got %+v", lf.Dependencies[0])
+	}
+}
+
+func TestLockfileDependencies(t *testing.T) {
+	deps := []gemfile.GemDependency{
+		{Name: "rails", Constraints: []string{"~> 7.0"}, Groups: []string{"default"}},
+		{Name: "state_machines", Source: &gemfile.Source{Type: "git", URL: "https://github.com/state-machines/state_machines.git"}},
+		{Name: "engine", Source: &gemfile.Source{Type: "path", URL: "engines/engine"}},
+	}
+
+	// Only the name, with Bundler's ! marker, and the constraints are filled in
+	expected := []lockfile.Dependency{
+		{Name: "rails", Constraints: []string{"~> 7.0"}},
+		{Name: "state_machines!"},
+		{Name: "engine!"},
+	}
+	if got := LockfileDependencies(deps); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+}